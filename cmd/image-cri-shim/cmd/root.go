@@ -17,11 +17,13 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/labring/image-cri-shim/pkg/server"
 	"github.com/labring/image-cri-shim/pkg/shim"
 	"github.com/labring/image-cri-shim/pkg/types"
 	"github.com/spf13/cobra"
@@ -88,15 +90,74 @@ func run(cfg *types.Config, auth *types.ShimAuthConfig) {
 		logger.Fatal(fmt.Sprintf("failed to start image_shim, %s", err))
 	}
 
-	signalCh := make(chan os.Signal, 1)
-	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.Metrics != "" {
+		go func() {
+			if err := server.ServeMetrics(ctx, cfg.Metrics); err != nil {
+				logger.Error("failed to serve metrics on %s: %v", cfg.Metrics, err)
+			}
+		}()
+	}
 
-	stopCh := make(chan struct{}, 1)
-	select {
-	case <-signalCh:
-		close(stopCh)
-	case <-stopCh:
+	reloadCh := make(chan struct{}, 1)
+	stopWatch, err := shim.WatchConfig(cfgFile, func() {
+		select {
+		case reloadCh <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		logger.Warn("failed to watch image shim config %s, reload it by SIGHUP: %v", cfgFile, err)
+	} else {
+		defer stopWatch()
 	}
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	waitForStop(imgShim, cfg, signalCh, reloadCh)
 	_ = os.Remove(cfg.ImageShimSocket)
 	logger.Info("shutting down the image_shim")
 }
+
+// waitForStop reloads the config on SIGHUP or the change of config file until
+// other signals are received.
+func waitForStop(imgShim shim.Shim, cfg *types.Config, signalCh <-chan os.Signal, reloadCh <-chan struct{}) {
+	for {
+		select {
+		case sig := <-signalCh:
+			if sig != syscall.SIGHUP {
+				return
+			}
+			logger.Info("received SIGHUP, reloading image shim config %s", cfgFile)
+			reload(imgShim, cfg)
+		case <-reloadCh:
+			logger.Info("image shim config %s changed, reloading", cfgFile)
+			reload(imgShim, cfg)
+		}
+	}
+}
+
+// reload applies the registry credentials and image rules of config file, the
+// sockets, metrics and audit log can't be changed without restart. The current
+// config is kept if the file is invalid.
+func reload(imgShim shim.Shim, current *types.Config) {
+	newCfg, err := types.Unmarshal(cfgFile)
+	if err != nil {
+		logger.Error("failed to reload image shim config, keep using the current one: %v", err)
+		return
+	}
+	auth, err := newCfg.PreProcess()
+	if err != nil {
+		logger.Error("failed to reload image shim config, keep using the current one: %v", err)
+		return
+	}
+	if newCfg.ImageShimSocket != current.ImageShimSocket || newCfg.RuntimeSocket != current.RuntimeSocket {
+		logger.Warn("the changes of shim or cri socket take effect after restart")
+	}
+	if newCfg.Metrics != current.Metrics || newCfg.AuditLog != current.AuditLog {
+		logger.Warn("the changes of metrics or audit log take effect after restart")
+	}
+	imgShim.UpdateAuth(auth)
+}
@@ -0,0 +1,109 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/labring/image-cri-shim/pkg/shim"
+	"github.com/labring/image-cri-shim/pkg/types"
+)
+
+type fakeShim struct {
+	shim.Shim
+	auths []*types.ShimAuthConfig
+}
+
+func (f *fakeShim) UpdateAuth(auth *types.ShimAuthConfig) {
+	f.auths = append(f.auths, auth)
+}
+
+func writeShimConfig(t *testing.T, content string) {
+	t.Helper()
+	cfgFile = filepath.Join(t.TempDir(), "image-cri-shim.yaml")
+	if err := os.WriteFile(cfgFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForStop(t *testing.T) {
+	current := &types.Config{Address: "http://sealos.hub:5000", Force: true}
+	tests := []struct {
+		name       string
+		config     string
+		signals    []os.Signal
+		changed    bool
+		wantReload int
+	}{
+		{
+			name:       "reload on SIGHUP",
+			config:     "address: http://new.hub:5000\nforce: true\ncri: /run/containerd/containerd.sock\nauth: admin:passw0rd\n",
+			signals:    []os.Signal{syscall.SIGHUP, syscall.SIGTERM},
+			wantReload: 1,
+		},
+		{
+			name:       "reload on config change",
+			config:     "address: http://new.hub:5000\nforce: true\ncri: /run/containerd/containerd.sock\nauth: admin:passw0rd\n",
+			signals:    []os.Signal{syscall.SIGTERM},
+			changed:    true,
+			wantReload: 1,
+		},
+		{
+			name:    "keep current config if the new one is invalid",
+			config:  "force: true\ncri: /run/containerd/containerd.sock\n",
+			signals: []os.Signal{syscall.SIGHUP, syscall.SIGTERM},
+		},
+		{
+			name:    "keep current config if the file is malformed",
+			config:  "address: [",
+			signals: []os.Signal{syscall.SIGHUP, syscall.SIGINT},
+		},
+		{
+			name:    "stop without reloading",
+			config:  "address: http://new.hub:5000\nforce: true\ncri: /run/containerd/containerd.sock\n",
+			signals: []os.Signal{syscall.SIGINT, syscall.SIGHUP},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeShimConfig(t, tt.config)
+			signalCh := make(chan os.Signal, len(tt.signals))
+			reloadCh := make(chan struct{})
+			go func() {
+				if tt.changed {
+					reloadCh <- struct{}{}
+				}
+				for _, sig := range tt.signals {
+					signalCh <- sig
+				}
+			}()
+			fake := &fakeShim{}
+			waitForStop(fake, current, signalCh, reloadCh)
+			if len(fake.auths) != tt.wantReload {
+				t.Fatalf("got %d reloads, want %d", len(fake.auths), tt.wantReload)
+			}
+			if tt.wantReload == 0 {
+				return
+			}
+			offline, ok := fake.auths[0].OfflineCRIConfigs["new.hub:5000"]
+			if !ok || offline.Username != "admin" || offline.Password != "passw0rd" {
+				t.Errorf("offline registry auth is not swapped: %+v", fake.auths[0].OfflineCRIConfigs)
+			}
+		})
+	}
+}
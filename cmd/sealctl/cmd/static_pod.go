@@ -47,12 +47,13 @@ func newStaticPodCmd() *cobra.Command {
 }
 
 type lvscarePod struct {
-	vip     string
-	master  []string
-	image   string
-	name    string
-	options []string
-	print   bool
+	vip         string
+	master      []string
+	image       string
+	name        string
+	options     []string
+	metricsPort int
+	print       bool
 }
 
 func newLvscareCmd() *cobra.Command {
@@ -81,13 +82,14 @@ func newLvscareCmd() *cobra.Command {
 	lvscareCmd.Flags().BoolVar(&setImage, "set-img", false, "update lvscare image to static pod")
 	lvscareCmd.Flags().StringSliceVar(&obj.master, "masters", []string{}, "generator masters addrs")
 	lvscareCmd.Flags().StringSliceVar(&obj.options, "options", []string{}, "lvscare args options")
+	lvscareCmd.Flags().IntVar(&obj.metricsPort, "metrics-port", 0, "port of node to serve lvscare metrics and healthz, disabled if 0")
 	lvscareCmd.Flags().BoolVar(&obj.print, "print", false, "is print yaml")
 	return lvscareCmd
 }
 
 func genNewPod(obj lvscarePod) error {
 	fileName := fmt.Sprintf("%s.%s", obj.name, constants.YamlFileSuffix)
-	yaml, err := ipvs.LvsStaticPodYaml(obj.vip, obj.master, obj.image, obj.name, obj.options)
+	yaml, err := ipvs.LvsStaticPodYaml(obj.vip, obj.master, obj.image, obj.name, obj.metricsPort, obj.options)
 	if err != nil {
 		return err
 	}
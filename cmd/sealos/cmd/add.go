@@ -16,10 +16,13 @@ package cmd
 
 import (
 	"errors"
+	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -39,8 +42,9 @@ Please note that the masters and nodes added in one command should have the save
 // addCmd represents the add command
 func newAddCmd() *cobra.Command {
 	addArgs := &apply.ScaleArgs{
-		Cluster: &apply.Cluster{},
-		SSH:     &apply.SSH{},
+		Cluster:    &apply.Cluster{},
+		SSH:        &apply.SSH{},
+		DryRunArgs: &apply.DryRunArgs{},
 	}
 	var addCmd = &cobra.Command{
 		Use:     "add",
@@ -52,10 +56,13 @@ func newAddCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if addArgs.DryRun {
+				return applier.Plan().Print(os.Stdout, addArgs.Format)
+			}
 			return applier.Apply()
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if addArgs.Nodes == "" && addArgs.Masters == "" {
+			if addArgs.Nodes == "" && addArgs.Masters == "" && !processor.Resume {
 				return errors.New("nodes and masters can't both be empty")
 			}
 			return nil
@@ -66,5 +73,7 @@ func newAddCmd() *cobra.Command {
 	}
 	setRequireBuildahAnnotation(addCmd)
 	addArgs.RegisterFlags(addCmd.Flags(), "be joined", "join")
+	addCmd.Flags().BoolVar(&processor.Resume, "resume", false, "resume the last failed apply, skip the steps and hosts that have succeeded")
+	checker.RegisterPreflightFlags(addCmd.Flags())
 	return addCmd
 }
@@ -15,9 +15,14 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/checker"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -25,6 +30,7 @@ var clusterFile string
 
 func newApplyCmd() *cobra.Command {
 	applyArgs := &apply.Args{}
+	dryRunArgs := &apply.DryRunArgs{}
 	// applyCmd represents the apply command
 	var applyCmd = &cobra.Command{
 		Use:     "apply",
@@ -36,6 +42,9 @@ func newApplyCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if dryRunArgs.DryRun {
+				return applier.Plan().Print(os.Stdout, dryRunArgs.Format)
+			}
 			return applier.Apply()
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
@@ -45,5 +54,9 @@ func newApplyCmd() *cobra.Command {
 	setRequireBuildahAnnotation(applyCmd)
 	applyCmd.Flags().StringVarP(&clusterFile, "Clusterfile", "f", "Clusterfile", "apply a kubernetes cluster")
 	applyArgs.RegisterFlags(applyCmd.Flags())
+	dryRunArgs.RegisterFlags(applyCmd.Flags())
+	applyCmd.Flags().BoolVar(&processor.Resume, "resume", false, "resume the last failed apply, skip the steps and hosts that have succeeded")
+	checker.RegisterPreflightFlags(applyCmd.Flags())
+	kubernetes.RegisterDrainFlags(applyCmd.Flags())
 	return applyCmd
 }
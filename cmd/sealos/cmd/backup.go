@@ -0,0 +1,116 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/backup"
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "backup or restore the local working dir of cluster",
+		Long: `Pack the Clusterfile, pki, kubeconfigs, runtime configs and the mounted images of cluster
+into a single archive, which can be restored on a fresh machine so that the cluster can
+still be scaled or upgraded from there.`,
+	}
+	cmd.AddCommand(newBackupCreateCmd())
+	cmd.AddCommand(newBackupRestoreCmd())
+	return cmd
+}
+
+func newBackupCreateCmd() *cobra.Command {
+	var (
+		output     string
+		withImages bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a backup archive of cluster",
+		Example: `
+	sealos backup create -c default -o /data/default-backup.tar.gz`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				output = fmt.Sprintf("%s-backup-%s.tar.gz", clusterName, time.Now().Format("20060102150405"))
+			}
+			var bder buildah.Interface
+			if withImages {
+				var err error
+				if bder, err = buildah.New(clusterName); err != nil {
+					return err
+				}
+			}
+			md, err := backup.Create(clusterName, output, bder)
+			if err != nil {
+				return err
+			}
+			logger.Info("cluster %s with %d images backed up to %s", clusterName, len(md.Mounts), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of backup archive, default is <cluster>-backup-<timestamp>.tar.gz in current dir")
+	cmd.Flags().BoolVar(&withImages, "with-images", false, "also save the contents of the mounted images, so that the backup can be restored on offline hosts")
+
+	return cmd
+}
+
+func newBackupRestoreCmd() *cobra.Command {
+	var (
+		force     bool
+		skipMount bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore ARCHIVE",
+		Short: "restore the working dir of cluster from a backup archive",
+		Example: `
+	sealos backup restore -c default /data/default-backup.tar.gz`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var bder buildah.Interface
+			if !skipMount {
+				var err error
+				if bder, err = buildah.New(clusterName); err != nil {
+					return err
+				}
+			}
+			md, err := backup.Extract(args[0], clusterName, force, bder)
+			if err != nil {
+				return err
+			}
+			logger.Info("working dir of cluster %s restored from backup created at %s by sealos %s",
+				clusterName, md.CreatedAt.Format(time.RFC3339), md.SealosVersion)
+			if skipMount {
+				return nil
+			}
+			return backup.Remount(md, bder)
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.Flags().BoolVar(&force, "force", false, "replace the working dir of cluster if it already exists")
+	cmd.Flags().BoolVar(&skipMount, "skip-mount", false, "do not load, pull or mount the images of cluster")
+
+	return cmd
+}
@@ -15,7 +15,9 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"path"
 
 	"github.com/labring/sealos/pkg/runtime"
@@ -23,9 +25,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/cert"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/runtime/factory"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	fileutils "github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
@@ -48,49 +52,124 @@ func newCertCmd() *cobra.Command {
     3. kubectl get pod, to check if it works or not
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			processor.SyncNewVersionConfig(clusterName)
-
-			clusterPath := constants.Clusterfile(clusterName)
-			pathResolver := constants.NewPathResolver(clusterName)
-
-			var runtimeConfigPath string
-
-			for _, f := range []string{
-				path.Join(pathResolver.ConfigsPath(), "kubeadm-init.yaml"),
-				path.Join(pathResolver.EtcPath(), "kubeadm-init.yaml"),
-				path.Join(pathResolver.ConfigsPath(), "k3s-init.yaml"),
-			} {
-				if fileutils.IsExist(f) {
-					runtimeConfigPath = f
-					break
-				}
-			}
-			if runtimeConfigPath == "" {
-				logger.Warn("cannot locate the default runtime config file")
-			}
-			var opts []clusterfile.OptionFunc
-			if runtimeConfigPath != "" {
-				opts = append(opts, clusterfile.WithCustomRuntimeConfigFiles([]string{runtimeConfigPath}))
-			}
-			cf := clusterfile.NewClusterFile(clusterPath, opts...)
-			if err := cf.Process(); err != nil {
+			cm, err := getCertManager(clusterName)
+			if err != nil {
 				return err
 			}
+			return cm.UpdateCertSANs(altNames)
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.Flags().StringSliceVar(&altNames, "alt-names", []string{}, "add extra Subject Alternative Names for certs, domain or ip, eg. sealos.io or 10.103.97.2")
+	_ = cmd.MarkFlagRequired("alt-names")
+	cmd.AddCommand(newCertRenewCmd())
+
+	return cmd
+}
 
-			rt, err := factory.New(cf.GetCluster(), cf.GetRuntimeConfig())
+func newCertRenewCmd() *cobra.Command {
+	var checkOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "renew",
+		Short: "renew the certificates and kubeconfigs of the control plane",
+		Long: `Re-issue all leaf certificates (apiserver, etcd, front-proxy) and the kubeconfigs of the
+control plane components from the existing CAs on every master, then restart the
+static pods one master at a time and write the refreshed admin kubeconfig back.`,
+		Example: `
+check the expiration of certificates:
+	sealos cert renew --check-only
+renew certificates:
+	sealos cert renew`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cm, err := getCertManager(clusterName)
 			if err != nil {
-				return fmt.Errorf("create runtime failed: %v", err)
+				return err
 			}
-			if cm, ok := rt.(runtime.CertManager); ok {
-				logger.Info("using %s cert update implement", cf.GetCluster().GetDistribution())
-				return cm.UpdateCertSANs(altNames)
+			if checkOnly {
+				list, err := cm.CheckExpiration()
+				if err != nil {
+					return err
+				}
+				return printCertExpiration(cmd.OutOrStdout(), list)
 			}
-			return nil
+			return cm.Renew()
 		},
 	}
 	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
-	cmd.Flags().StringSliceVar(&altNames, "alt-names", []string{}, "add extra Subject Alternative Names for certs, domain or ip, eg. sealos.io or 10.103.97.2")
-	_ = cmd.MarkFlagRequired("alt-names")
+	cmd.Flags().BoolVar(&checkOnly, "check-only", false, "only print the expiration of certificates, do not renew them, as JSON with --output-format json")
 
 	return cmd
 }
+
+// printCertExpiration writes the expirations of every master as tables, or as
+// one line of JSON in json output format.
+func printCertExpiration(w io.Writer, list []cert.HostExpiration) error {
+	if outputFormat == outputFormatJSON {
+		return json.NewEncoder(w).Encode(list)
+	}
+	for _, host := range list {
+		if _, err := fmt.Fprintf(w, "master %s:\n", host.Host); err != nil {
+			return err
+		}
+		if err := cert.PrintExpiration(w, host.Certificates); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getCertManager(clusterName string) (runtime.CertManager, error) {
+	rt, cluster, err := newRuntimeFromClusterName(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	cm, ok := rt.(runtime.CertManager)
+	if !ok {
+		return nil, fmt.Errorf("cert management is not supported by %s", cluster.GetDistribution())
+	}
+	logger.Info("using %s cert management implement", cluster.GetDistribution())
+	return cm, nil
+}
+
+// newRuntimeFromClusterName creates the runtime of a running cluster from its Clusterfile and runtime config.
+func newRuntimeFromClusterName(clusterName string) (runtime.Interface, *v2.Cluster, error) {
+	processor.SyncNewVersionConfig(clusterName)
+
+	clusterPath := constants.Clusterfile(clusterName)
+	pathResolver := constants.NewPathResolver(clusterName)
+
+	var runtimeConfigPath string
+
+	for _, f := range []string{
+		path.Join(pathResolver.ConfigsPath(), "kubeadm-init.yaml"),
+		path.Join(pathResolver.EtcPath(), "kubeadm-init.yaml"),
+		path.Join(pathResolver.ConfigsPath(), "k3s-init.yaml"),
+	} {
+		if fileutils.IsExist(f) {
+			runtimeConfigPath = f
+			break
+		}
+	}
+	if runtimeConfigPath == "" {
+		logger.Warn("cannot locate the default runtime config file")
+	}
+	var opts []clusterfile.OptionFunc
+	if runtimeConfigPath != "" {
+		opts = append(opts, clusterfile.WithCustomRuntimeConfigFiles([]string{runtimeConfigPath}))
+	}
+	cf := clusterfile.NewClusterFile(clusterPath, opts...)
+	if err := cf.Process(); err != nil {
+		return nil, nil, err
+	}
+
+	rt, err := factory.New(cf.GetCluster(), cf.GetRuntimeConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("create runtime failed: %v", err)
+	}
+	return rt, cf.GetCluster(), nil
+}
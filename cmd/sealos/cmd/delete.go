@@ -16,11 +16,13 @@ package cmd
 
 import (
 	"errors"
+	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/labring/sealos/pkg/apply"
 	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -44,7 +46,8 @@ Please note that sealos will delete your master if the --masters parameter is sp
 // deleteCmd represents the delete command
 func newDeleteCmd() *cobra.Command {
 	deleteArgs := &apply.ScaleArgs{
-		Cluster: &apply.Cluster{},
+		Cluster:    &apply.Cluster{},
+		DryRunArgs: &apply.DryRunArgs{},
 	}
 	var deleteCmd = &cobra.Command{
 		Use:     "delete",
@@ -52,13 +55,17 @@ func newDeleteCmd() *cobra.Command {
 		Args:    cobra.NoArgs,
 		Example: exampleDelete,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := processor.ConfirmDeleteNodes(); err != nil {
-				return err
-			}
 			applier, err := apply.NewScaleApplierFromArgs(cmd, deleteArgs)
 			if err != nil {
 				return err
 			}
+			if deleteArgs.DryRun {
+				return applier.Plan().Print(os.Stdout, deleteArgs.Format)
+			}
+			if err = processor.ConfirmDeleteNodes(); err != nil {
+				return err
+			}
+			kubernetes.IgnoreDrainErrors(processor.ForceDelete)
 			return applier.Apply()
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -73,6 +80,7 @@ func newDeleteCmd() *cobra.Command {
 	}
 	setRequireBuildahAnnotation(deleteCmd)
 	deleteArgs.RegisterFlags(deleteCmd.Flags(), "removed", "remove")
-	deleteCmd.Flags().BoolVar(&processor.ForceDelete, "force", false, "we also can input an --force flag to delete cluster by force")
+	deleteCmd.Flags().BoolVar(&processor.ForceDelete, "force", false, "we also can input an --force flag to delete cluster by force, the nodes failing to drain are deleted too")
+	kubernetes.RegisterDrainFlags(deleteCmd.Flags())
 	return deleteCmd
 }
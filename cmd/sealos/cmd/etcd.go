@@ -0,0 +1,188 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const etcdSnapshotPrefix = "etcd-snapshot-"
+
+func newEtcdCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "etcd",
+		Short: "manage the etcd of cluster",
+	}
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "save or restore etcd snapshots",
+	}
+	snapshotCmd.AddCommand(newEtcdSnapshotSaveCmd())
+	snapshotCmd.AddCommand(newEtcdSnapshotRestoreCmd())
+	cmd.AddCommand(snapshotCmd)
+	return cmd
+}
+
+func newEtcdSnapshotSaveCmd() *cobra.Command {
+	var (
+		master string
+		dir    string
+		retain int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "save",
+		Short: "take an etcd snapshot on a master and fetch it to local",
+		Example: `
+save a snapshot from master0 into the cluster dir:
+	sealos etcd snapshot save
+save a snapshot from a chosen master and keep the latest 7 snapshots only:
+	sealos etcd snapshot save --master 192.168.0.3 --dir /data/backup --retain 7`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			em, masters, err := getEtcdManager(clusterName)
+			if err != nil {
+				return err
+			}
+			if master == "" {
+				master = masters[0]
+			} else if master, err = lookupMaster(masters, master); err != nil {
+				return err
+			}
+			if dir == "" {
+				dir = filepath.Join(constants.ClusterDir(clusterName), "etcd-snapshots")
+			}
+			if err = os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			dst := filepath.Join(dir, fmt.Sprintf("%s%s-%s.db", etcdSnapshotPrefix, clusterName, time.Now().Format("20060102150405")))
+			if err = em.SaveSnapshot(master, dst); err != nil {
+				return err
+			}
+			logger.Info("etcd snapshot saved to %s", dst)
+			if retain > 0 {
+				return pruneEtcdSnapshots(dir, clusterName, retain)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.Flags().StringVar(&master, "master", "", "the master to take snapshot on, default is master0")
+	cmd.Flags().StringVar(&dir, "dir", "", "local dir to save snapshots, default is the dir of cluster")
+	cmd.Flags().IntVar(&retain, "retain", 0, "keep only the latest N snapshots of the cluster in dir, zero means keep all")
+
+	return cmd
+}
+
+func newEtcdSnapshotRestoreCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "restore SNAPSHOT",
+		Short: "restore an etcd snapshot on all masters",
+		Long: `Restore the snapshot into the etcd data dir of every master with the member names etcd
+already uses. The control plane is stopped while the data dirs are replaced,
+all changes made after the snapshot was taken will be lost.`,
+		Example: `
+	sealos etcd snapshot restore /root/.sealos/default/etcd-snapshots/etcd-snapshot-default-20230101000000.db`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if _, err = os.Stat(src); err != nil {
+				return err
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to restore etcd of cluster %s from %s?", clusterName, src)
+				cancel := "you have canceled to restore etcd !"
+				if pass, err := confirm.Confirm(prompt, cancel); err != nil {
+					return err
+				} else if !pass {
+					return processor.ErrCancelled
+				}
+			}
+			em, _, err := getEtcdManager(clusterName)
+			if err != nil {
+				return err
+			}
+			return em.RestoreSnapshot(src)
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
+	cmd.Flags().BoolVar(&force, "force", false, "restore without confirmation")
+
+	return cmd
+}
+
+func getEtcdManager(clusterName string) (runtime.EtcdManager, []string, error) {
+	rt, cluster, err := newRuntimeFromClusterName(clusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+	em, ok := rt.(runtime.EtcdManager)
+	if !ok {
+		return nil, nil, fmt.Errorf("etcd snapshot is not supported by %s", cluster.GetDistribution())
+	}
+	masters := cluster.GetMasterIPAndPortList()
+	if len(masters) == 0 {
+		return nil, nil, fmt.Errorf("no master found in cluster %s", clusterName)
+	}
+	return em, masters, nil
+}
+
+// lookupMaster accepts the master both with and without ssh port.
+func lookupMaster(masters []string, master string) (string, error) {
+	for _, m := range masters {
+		if m == master || iputils.GetHostIP(m) == master {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a master of cluster", master)
+}
+
+// pruneEtcdSnapshots removes the old snapshots of cluster in dir, the latest retain ones are kept.
+func pruneEtcdSnapshots(dir, clusterName string, retain int) error {
+	snapshots, err := filepath.Glob(filepath.Join(dir, etcdSnapshotPrefix+clusterName+"-*.db"))
+	if err != nil {
+		return err
+	}
+	if len(snapshots) <= retain {
+		return nil
+	}
+	// names end with the timestamp, so the lexical order is the time order
+	sort.Strings(snapshots)
+	for _, f := range snapshots[:len(snapshots)-retain] {
+		logger.Info("remove old etcd snapshot %s", f)
+		if err = os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
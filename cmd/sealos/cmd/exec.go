@@ -17,15 +17,13 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
-
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 var clusterName string
@@ -86,12 +84,7 @@ func runCommand(cluster *v2.Cluster, targets []string, args []string) error {
 	if err != nil {
 		return err
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, ipAddr := range targets {
-		ip := ipAddr
-		eg.Go(func() error {
-			return execer.CmdAsync(ip, args...)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(targets, func(ip string) error {
+		return execer.CmdAsync(ip, args...)
+	})
 }
@@ -0,0 +1,58 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/checker"
+	"github.com/labring/sealos/pkg/clusterfile"
+)
+
+var examplePreflight = `
+check all the hosts of default cluster:
+	sealos preflight
+check the hosts before adding them to cluster my-cluster:
+	sealos preflight -c my-cluster --hosts 172.16.1.38,172.16.1.39
+report the errors of swap and ports as warnings:
+	sealos preflight --ignore-preflight-errors Swap,Ports
+`
+
+func newPreflightCmd() *cobra.Command {
+	var hosts []string
+	preflightCmd := &cobra.Command{
+		Use:     "preflight",
+		Short:   "Check whether the hosts are ready to be installed",
+		Example: examplePreflight,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get cluster %s failed, %v", clusterName, err)
+			}
+			if len(hosts) == 0 {
+				hosts = cluster.GetAllIPS()
+			}
+			_, err = checker.NewPreflightChecker(hosts).Check(cluster, checker.PhasePre)
+			return err
+		},
+	}
+	preflightCmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster whose ssh config is used")
+	preflightCmd.Flags().StringSliceVar(&hosts, "hosts", nil, "hosts to check, the hosts not in cluster are checked as nodes, defaults to all the hosts of cluster")
+	checker.RegisterPreflightFlags(preflightCmd.Flags())
+	return preflightCmd
+}
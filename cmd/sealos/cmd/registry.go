@@ -30,6 +30,8 @@ func newRegistryCmd(examplePrefix string) *cobra.Command {
 	}
 	examplePrefix = examplePrefix + " registry"
 	cmd.AddCommand(commands.NewRegistryPasswdCmd())
+	cmd.AddCommand(commands.NewRegistryGCCmd(examplePrefix))
+	cmd.AddCommand(commands.NewRegistryReplicateCmd(examplePrefix))
 	cmd.AddCommand(sregcmd.NewServeRegistryCommand())
 	cmd.AddCommand(sregcmd.NewRegistryImageSaveCmd(examplePrefix))
 	cmd.AddCommand(sregcmd.NewSyncRegistryCommand(examplePrefix))
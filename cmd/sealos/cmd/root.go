@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -24,14 +25,26 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/events"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+)
+
+const (
+	outputFormatText = "text"
+	outputFormatJSON = "json"
 )
 
 var (
-	debug bool
+	debug        bool
+	outputFormat string
+	eventsFile   string
+	// eventsOutput is the opened events file, closed when sealos exits
+	eventsOutput *os.File
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -46,17 +59,44 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	closeEvents()
+	if err != nil {
 		if rootCmd.SilenceErrors {
 			fmt.Println(err)
 		}
+		var ee *exitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError makes sealos exit with the code instead of 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 func init() {
 	cobra.OnInitialize(onBootOnDie)
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug logger")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output-format", outputFormatText,
+		"format of progress output, text or json. json writes events to stdout as json lines and logs to stderr")
+	rootCmd.PersistentFlags().StringVar(&eventsFile, "events-file", "", "append progress events as json lines to the file")
+	parallel.RegisterFlags(rootCmd.PersistentFlags())
 	buildah.RegisterRootCommand(rootCmd)
 
 	groups := templates.CommandGroups{
@@ -65,9 +105,12 @@ func init() {
 			Commands: []*cobra.Command{
 				newApplyCmd(),
 				newCertCmd(),
+				newEtcdCmd(),
+				newBackupCmd(),
 				newRunCmd(),
 				newResetCmd(),
 				newStatusCmd(),
+				newPreflightCmd(),
 			},
 		},
 		{
@@ -82,6 +125,7 @@ func init() {
 			Commands: []*cobra.Command{
 				newExecCmd(),
 				newScpCmd(),
+				newSSHKeysCmd(),
 			},
 		},
 		{
@@ -120,8 +164,53 @@ func onBootOnDie() {
 		constants.WorkDir(),
 	}
 	errExit(file.MkDirs(rootDirs...))
+	errExit(setupEvents())
 
 	logger.CfgConsoleAndFileLogger(debug, constants.LogPath(), "sealos", false)
+	cfgSregLogger()
+}
+
+// setupEvents adds the sinks of progress events. In json format stdout is reserved
+// for events, anything else printed to the console, including logs, reports of
+// checkers and outputs of remote commands, goes to stderr so that stdout can be
+// parsed line by line.
+func setupEvents() error {
+	switch outputFormat {
+	case outputFormatText:
+	case outputFormatJSON:
+		events.AddSink(os.Stdout)
+		logger.SetConsoleOutput(os.Stderr)
+		checker.Output = os.Stderr
+	default:
+		return fmt.Errorf("unsupported output format %s, must be one of %s, %s", outputFormat, outputFormatText, outputFormatJSON)
+	}
+	if eventsFile != "" {
+		f, err := os.OpenFile(eventsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open events file: %v", err)
+		}
+		events.AddSink(f)
+		eventsOutput = f
+	}
+	return nil
+}
+
+func closeEvents() {
+	events.Reset()
+	if eventsOutput != nil {
+		_ = eventsOutput.Close()
+		eventsOutput = nil
+	}
+}
+
+// cfgSregLogger configures the logger of sreg, which always logs to the os.Stdout
+// at the time of configuring, so it's pointed to stderr only meanwhile in json format.
+func cfgSregLogger() {
+	if outputFormat == outputFormatJSON {
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+	}
 	sreglog.CfgConsoleAndFileLogger(debug, constants.LogPath(), "sealos", false)
 }
 
@@ -22,6 +22,8 @@ import (
 	"github.com/labring/sealos/pkg/apply"
 	"github.com/labring/sealos/pkg/apply/processor"
 	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/checker"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -90,6 +92,9 @@ func newRunCmd() *cobra.Command {
 		logger.Fatal(err)
 	}
 	runCmd.Flags().BoolVarP(&processor.ForceOverride, "force", "f", false, "force override app in this cluster")
+	runCmd.Flags().BoolVar(&processor.Resume, "resume", false, "resume the last failed apply, skip the steps and hosts that have succeeded")
+	checker.RegisterPreflightFlags(runCmd.Flags())
+	kubernetes.RegisterDrainFlags(runCmd.Flags())
 	runCmd.Flags().StringVarP(&transport, "transport", "t", buildah.OCIArchive,
 		fmt.Sprintf("load image transport from tar archive file.(optional value: %s, %s)", buildah.OCIArchive, buildah.DockerArchive))
 	return runCmd
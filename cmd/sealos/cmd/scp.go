@@ -17,16 +17,14 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
-
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 const exampleScp = `
@@ -71,14 +69,9 @@ func runCopy(cluster *v1beta1.Cluster, targets []string, args []string) error {
 	if err != nil {
 		return err
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, ipAddr := range targets {
-		ip := ipAddr
-		eg.Go(func() error {
-			return execer.Copy(ip, args[0], args[1])
-		})
-	}
-	if err = eg.Wait(); err != nil {
+	if err = parallel.Run(targets, func(ip string) error {
+		return execer.Copy(ip, args[0], args[1])
+	}); err != nil {
 		return err
 	}
 	logger.Info("transfers files success")
@@ -0,0 +1,61 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+func newSSHKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-keys",
+		Short: "manage the host keys trusted by cluster",
+		Long: `The host keys are recorded in the working dir of cluster on first use when the
+host key policy is tofu, a host with a changed key is refused to connect until its
+recorded key is reset.`,
+	}
+	cmd.AddCommand(newSSHKeysResetCmd())
+	return cmd
+}
+
+func newSSHKeysResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset IP...",
+		Short: "forget the recorded host keys, which are trusted again on next use",
+		Example: `
+	sealos ssh-keys reset 192.168.0.2
+	sealos ssh-keys reset -c my-cluster 192.168.0.2:2222 192.168.0.3`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := constants.KnownHostsFile(clusterName)
+			removed, err := ssh.ResetKnownHosts(file, args...)
+			if err != nil {
+				return err
+			}
+			if removed == 0 {
+				logger.Warn("no host keys of %v are recorded in %s", args, file)
+				return nil
+			}
+			logger.Info("%d host key(s) of %v removed from %s", removed, args, file)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster")
+	return cmd
+}
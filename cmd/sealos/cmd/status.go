@@ -15,29 +15,160 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/clusterfile"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
 
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
+const (
+	statusOutputTable = "table"
+	statusOutputJSON  = "json"
+	statusOutputYAML  = "yaml"
+)
+
+// the exit codes of status follow the convention of monitoring plugins.
+const (
+	statusExitWarning = 1
+	statusExitFailed  = 2
+	statusExitUnknown = 3
+)
+
+var exampleStatus = `
+show the state of default cluster:
+	sealos status
+print the results of checkers as json, e.g. for monitoring:
+	sealos status -o json
+`
+
 // newStatusCmd
 func newStatusCmd() *cobra.Command {
+	var statusOutput string
 	checkCmd := &cobra.Command{
-		Use:   "status",
-		Short: "state of sealos",
-		Args:  cobra.NoArgs,
+		Use:     "status",
+		Short:   "state of sealos",
+		Example: exampleStatus,
+		Args: func(cmd *cobra.Command, args []string) error {
+			return usageError(cobra.NoArgs(cmd, args))
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			switch statusOutput {
+			case statusOutputTable, statusOutputJSON, statusOutputYAML:
+			default:
+				return usageError(fmt.Errorf("--output must be one of %s, %s, %s", statusOutputTable, statusOutputJSON, statusOutputYAML))
+			}
+			cmd.SilenceUsage = true
 			cluster, err := clusterfile.GetClusterFromName(clusterName)
 			if err != nil {
-				return fmt.Errorf("get default cluster failed, %v", err)
+				return &exitError{code: statusExitUnknown, err: fmt.Errorf("get default cluster failed, %v", err)}
+			}
+			stdout := cmd.OutOrStdout()
+			if statusOutput != statusOutputTable {
+				// keep stdout parsable, the reports of checkers are dropped and
+				// the logs go to stderr
+				checker.Output = io.Discard
+				logger.SetConsoleOutput(os.Stderr)
+			} else if cp := cluster.Status.Checkpoint; cp != nil {
+				if err = printCheckpoint(stdout, cp); err != nil {
+					return err
+				}
 			}
 			list := []checker.Interface{checker.NewRegistryChecker(), checker.NewCRIShimChecker(), checker.NewCRICtlChecker(), checker.NewInitSystemChecker(), checker.NewNodeChecker(), checker.NewPodChecker(), checker.NewSvcChecker(), checker.NewClusterChecker()}
-			return checker.RunCheckList(list, cluster, checker.PhasePost)
+			results, checkErr := checker.RunCheckList(list, cluster, checker.PhasePost)
+			if err = printStatusResults(stdout, statusOutput, results); err != nil {
+				return err
+			}
+			worst := checker.WorstStatus(results)
+			if worst != checker.StatusOK && checkErr == nil {
+				checkErr = fmt.Errorf("status of cluster is %s", worst)
+			}
+			switch {
+			case worst == checker.StatusFailed:
+				return &exitError{code: statusExitFailed, err: checkErr}
+			case worst == checker.StatusWarning:
+				return &exitError{code: statusExitWarning, err: checkErr}
+			case checkErr != nil:
+				return &exitError{code: statusExitUnknown, err: checkErr}
+			}
+			return nil
 		},
 	}
 	checkCmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied status action")
+	checkCmd.Flags().StringVarP(&statusOutput, "output", "o", statusOutputTable, "output format of the results, one of table, json or yaml")
+	checkCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError(err)
+	})
 	return checkCmd
 }
+
+// usageError makes the invalid usage of status exit as unknown, so that it's
+// not taken as a warning of cluster by monitoring.
+func usageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: statusExitUnknown, err: err}
+}
+
+func printStatusResults(w io.Writer, format string, results []checker.Result) error {
+	if results == nil {
+		results = []checker.Result{}
+	}
+	switch format {
+	case statusOutputJSON:
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("fail to marshal json: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case statusOutputYAML:
+		data, err := yaml.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("fail to marshal yaml: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		fmt.Fprintln(w)
+		return checker.PrintResults(w, results)
+	}
+}
+
+// printCheckpoint shows the steps of the last apply and the hosts they failed on.
+func printCheckpoint(w io.Writer, cp *v2.Checkpoint) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "LAST APPLY\t%s %s\n", cp.Processor, cp.Phase)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "STEP\tPHASE\tTIME\tFAILED HOSTS\tMESSAGE")
+	for _, step := range cp.Steps {
+		var failed []string
+		for _, host := range step.Hosts {
+			if host.Phase == v2.StepFailed {
+				failed = append(failed, host.Host)
+			}
+		}
+		updated := "-"
+		if !step.LastUpdateTime.IsZero() {
+			updated = step.LastUpdateTime.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", step.Name, step.Phase, updated, strings.Join(failed, ","), strings.ReplaceAll(step.Message, "\n", " "))
+	}
+	if cp.Phase == v2.StepFailed {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "run the same command again with --resume to continue from the failed step")
+	}
+	fmt.Fprintln(tw)
+	return tw.Flush()
+}
@@ -23,8 +23,6 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/pkg/apply/processor"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
@@ -35,6 +33,8 @@ import (
 	"github.com/labring/sealos/pkg/utils/confirm"
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
@@ -95,8 +95,10 @@ func (c *Applier) Apply() error {
 		c.applyAfter()
 	}()
 	c.initStatus()
-	if c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero() {
-		if !c.ClusterDesired.CreationTimestamp.IsZero() {
+	cp := c.resume()
+	resumeCreate := isResumeCreate(cp)
+	if c.isCreate(cp) {
+		if !c.ClusterDesired.CreationTimestamp.IsZero() && !resumeCreate {
 			if yes, _ := confirm.Confirm("Desired cluster CreationTimestamp is not zero, do you want to initialize it again?", "you have canceled to create cluster"); !yes {
 				clusterErr = processor.NewPreProcessError(fmt.Errorf("canceled to create cluster"))
 				return clusterErr
@@ -122,18 +124,35 @@ func (c *Applier) Apply() error {
 }
 
 func (c *Applier) getWriteBackObjects() []interface{} {
-	obj := []interface{}{c.ClusterDesired}
-	if runtimeConfig := c.ClusterFile.GetRuntimeConfig(); runtimeConfig != nil {
-		if components := runtimeConfig.GetComponents(); len(components) > 0 {
-			obj = append(obj, components...)
+	return processor.GetWriteBackObjects(c.ClusterDesired, c.ClusterFile)
+}
+
+// resume restores the checkpoint of the last failed apply into the desired cluster,
+// the images and hosts which it was processing are added back.
+func (c *Applier) resume() *v2.Checkpoint {
+	cp := processor.ResumableCheckpoint(c.ClusterCurrent, "")
+	if cp == nil {
+		if processor.Resume {
+			logger.Info("no failed apply to resume, apply as usual")
 		}
+		return nil
 	}
-	if configs := c.ClusterFile.GetConfigs(); len(configs) > 0 {
-		for i := range configs {
-			obj = append(obj, configs[i])
+	c.ClusterDesired.Status.Checkpoint = cp.DeepCopy()
+	if cp.Processor == processor.InstallProcessorName {
+		for _, img := range cp.Images {
+			c.RunNewImages = stringsutil.Merge(c.RunNewImages, img)
 		}
 	}
-	return obj
+	return cp
+}
+
+func isResumeCreate(cp *v2.Checkpoint) bool {
+	return cp != nil && cp.Processor == processor.CreateProcessorName
+}
+
+// isCreate returns whether the cluster is to be created, cp is the checkpoint returned by resume.
+func (c *Applier) isCreate(cp *v2.Checkpoint) bool {
+	return c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero() || isResumeCreate(cp)
 }
 
 func (c *Applier) initStatus() {
@@ -186,9 +205,32 @@ func (c *Applier) reconcileCluster() (clusterErr error, appErr error) {
 			return nil, appErr
 		}
 	}
-	mj, md := iputils.GetDiffHosts(c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterDesired.GetMasterIPAndPortList())
-	nj, nd := iputils.GetDiffHosts(c.ClusterCurrent.GetNodeIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
-	return c.scaleCluster(mj, md, nj, nd), nil
+	return c.scaleCluster(c.hostsToScale()), nil
+}
+
+// hostsToScale returns the diff of hosts, with the hosts of the last failed scaling
+// added back when resuming.
+func (c *Applier) hostsToScale() (mj, md, nj, nd []string) {
+	mj, md, nj, nd = c.diffHosts()
+	if cp := processor.ResumableCheckpoint(c.ClusterCurrent, processor.ScaleProcessorName); cp != nil {
+		mj, md = mergeHosts(mj, cp.MastersToJoin), mergeHosts(md, cp.MastersToDelete)
+		nj, nd = mergeHosts(nj, cp.NodesToJoin), mergeHosts(nd, cp.NodesToDelete)
+	}
+	return
+}
+
+func mergeHosts(hosts, more []string) []string {
+	for _, host := range more {
+		hosts = stringsutil.Merge(hosts, host)
+	}
+	return hosts
+}
+
+// diffHosts returns masters to join, masters to delete, nodes to join and nodes to delete.
+func (c *Applier) diffHosts() (mj, md, nj, nd []string) {
+	mj, md = iputils.GetDiffHosts(c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterDesired.GetMasterIPAndPortList())
+	nj, nd = iputils.GetDiffHosts(c.ClusterCurrent.GetNodeIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
+	return
 }
 
 func (c *Applier) initCluster() error {
@@ -284,14 +326,9 @@ func (c *Applier) syncWorkdir() {
 	if err != nil {
 		logger.Error("failed to create ssh client: %v", err)
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, ipAddr := range ipList {
-		ip := ipAddr
-		eg.Go(func() error {
-			return execer.Copy(ip, workDir, workDir)
-		})
-	}
-	if err := eg.Wait(); err != nil {
+	if err := parallel.Run(ipList, func(ip string) error {
+		return execer.Copy(ip, workDir, workDir)
+	}); err != nil {
 		logger.Error("failed to sync workdir: %s error, %v", workDir, err)
 	}
 }
@@ -17,4 +17,6 @@ package applydrivers
 type Interface interface {
 	Apply() error
 	Delete() error
+	// Plan computes what Apply would do without touching any host.
+	Plan() *Plan
 }
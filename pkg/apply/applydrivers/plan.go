@@ -0,0 +1,157 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/utils/maps"
+)
+
+const (
+	PlanActionCreate = "create"
+	PlanActionUpdate = "update"
+	PlanActionNone   = "none"
+
+	PlanFormatTable = "table"
+	PlanFormatJSON  = "json"
+)
+
+// Plan is what Apply would do to the cluster, it's computed without touching any host.
+type Plan struct {
+	ClusterName      string      `json:"clusterName"`
+	Action           string      `json:"action"`
+	MastersToJoin    []string    `json:"mastersToJoin,omitempty"`
+	MastersToDelete  []string    `json:"mastersToDelete,omitempty"`
+	NodesToJoin      []string    `json:"nodesToJoin,omitempty"`
+	NodesToDelete    []string    `json:"nodesToDelete,omitempty"`
+	ImagesToInstall  []string    `json:"imagesToInstall,omitempty"`
+	ImagesToOverride []string    `json:"imagesToOverride,omitempty"`
+	EnvChanges       []EnvChange `json:"envChanges,omitempty"`
+}
+
+// EnvChange is a changed cluster env, an empty Old means added and an empty New means removed.
+type EnvChange struct {
+	Key string `json:"key"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// Plan computes the changes the same way as Apply, so the images and hosts of the
+// last failed apply are included when resuming.
+func (c *Applier) Plan() *Plan {
+	plan := &Plan{
+		ClusterName: c.ClusterDesired.Name,
+		Action:      PlanActionUpdate,
+	}
+	if cp := c.resume(); c.isCreate(cp) {
+		plan.Action = PlanActionCreate
+		plan.MastersToJoin = c.ClusterDesired.GetMasterIPAndPortList()
+		plan.NodesToJoin = c.ClusterDesired.GetNodeIPAndPortList()
+		plan.ImagesToInstall = c.ClusterDesired.Spec.Image
+		plan.EnvChanges = diffEnv(nil, c.ClusterDesired.Spec.Env)
+		return plan
+	}
+	plan.ImagesToInstall = c.RunNewImages
+	plan.ImagesToOverride = processor.GetImagesToOverride(c.ClusterCurrent, c.RunNewImages)
+	plan.MastersToJoin, plan.MastersToDelete, plan.NodesToJoin, plan.NodesToDelete = c.hostsToScale()
+	plan.EnvChanges = diffEnv(c.ClusterCurrent.Spec.Env, c.ClusterDesired.Spec.Env)
+	if plan.isEmpty() {
+		plan.Action = PlanActionNone
+	}
+	return plan
+}
+
+func (p *Plan) isEmpty() bool {
+	return len(p.MastersToJoin) == 0 && len(p.MastersToDelete) == 0 &&
+		len(p.NodesToJoin) == 0 && len(p.NodesToDelete) == 0 &&
+		len(p.ImagesToInstall) == 0 && len(p.EnvChanges) == 0
+}
+
+func diffEnv(current, desired []string) []EnvChange {
+	curr, dest := maps.FromSlice(current), maps.FromSlice(desired)
+	var changes []EnvChange
+	for k, v := range dest {
+		if old, ok := curr[k]; !ok || old != v {
+			changes = append(changes, EnvChange{Key: k, Old: old, New: v})
+		}
+	}
+	for k, v := range curr {
+		if _, ok := dest[k]; !ok {
+			changes = append(changes, EnvChange{Key: k, Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// Print writes the plan in the given format, table or json.
+func (p *Plan) Print(w io.Writer, format string) error {
+	switch format {
+	case PlanFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case PlanFormatTable, "":
+		return p.printTable(w)
+	default:
+		return fmt.Errorf("unsupported plan format %s, must be one of %s, %s", format, PlanFormatTable, PlanFormatJSON)
+	}
+}
+
+func (p *Plan) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "CLUSTER\t%s\n", p.ClusterName)
+	fmt.Fprintf(tw, "ACTION\t%s\n", p.Action)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "OPERATION\tTARGET")
+	rows := []struct {
+		op      string
+		targets []string
+	}{
+		{"join master", p.MastersToJoin},
+		{"delete master", p.MastersToDelete},
+		{"join node", p.NodesToJoin},
+		{"delete node", p.NodesToDelete},
+		{"install image", p.ImagesToInstall},
+		{"override image", p.ImagesToOverride},
+	}
+	for _, row := range rows {
+		for _, target := range row.targets {
+			fmt.Fprintf(tw, "%s\t%s\n", row.op, target)
+		}
+	}
+	for _, change := range p.EnvChanges {
+		switch {
+		case change.Old == "":
+			fmt.Fprintf(tw, "add env\t%s=%s\n", change.Key, change.New)
+		case change.New == "":
+			fmt.Fprintf(tw, "remove env\t%s\n", change.Key)
+		default:
+			fmt.Fprintf(tw, "change env\t%s=%s (was %s)\n", change.Key, change.New, change.Old)
+		}
+	}
+	if p.Action == PlanActionNone {
+		fmt.Fprintln(tw, "-\tno changes")
+	}
+	return tw.Flush()
+}
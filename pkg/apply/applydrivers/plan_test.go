@@ -0,0 +1,220 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func newTestCluster(masters, nodes []string, images, env []string) *v2.Cluster {
+	cluster := &v2.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v2.ClusterSpec{
+			Image: images,
+			Env:   env,
+			Hosts: []v2.Host{{IPS: masters, Roles: []string{v2.MASTER}}},
+		},
+	}
+	if len(nodes) > 0 {
+		cluster.Spec.Hosts = append(cluster.Spec.Hosts, v2.Host{IPS: nodes, Roles: []string{v2.NODE}})
+	}
+	return cluster
+}
+
+func TestApplierPlan(t *testing.T) {
+	current := newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22"},
+		[]string{"labring/kubernetes:v1.25.0", "labring/calico:v3.24.1"}, []string{"a=1", "b=2"})
+	current.CreationTimestamp = metav1.Now()
+
+	tests := []struct {
+		name    string
+		applier *Applier
+		want    *Plan
+	}{
+		{
+			name: "create",
+			applier: &Applier{
+				ClusterDesired: newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22"},
+					[]string{"labring/kubernetes:v1.25.0"}, nil),
+			},
+			want: &Plan{
+				ClusterName:     "default",
+				Action:          PlanActionCreate,
+				MastersToJoin:   []string{"192.168.0.2:22"},
+				NodesToJoin:     []string{"192.168.0.3:22"},
+				ImagesToInstall: []string{"labring/kubernetes:v1.25.0"},
+			},
+		},
+		{
+			name: "scale and install",
+			applier: &Applier{
+				ClusterCurrent: current,
+				ClusterDesired: newTestCluster([]string{"192.168.0.2:22", "192.168.0.4:22"}, nil,
+					[]string{"labring/kubernetes:v1.25.0", "labring/calico:v3.24.1", "labring/helm:v3.8.2"},
+					[]string{"a=1", "b=3", "c=4"}),
+				RunNewImages: []string{"labring/calico:v3.24.1", "labring/helm:v3.8.2"},
+			},
+			want: &Plan{
+				ClusterName:      "default",
+				Action:           PlanActionUpdate,
+				MastersToJoin:    []string{"192.168.0.4:22"},
+				NodesToDelete:    []string{"192.168.0.3:22"},
+				ImagesToInstall:  []string{"labring/calico:v3.24.1", "labring/helm:v3.8.2"},
+				ImagesToOverride: []string{"labring/calico:v3.24.1"},
+				EnvChanges:       []EnvChange{{Key: "b", Old: "2", New: "3"}, {Key: "c", New: "4"}},
+			},
+		},
+		{
+			name: "no changes",
+			applier: &Applier{
+				ClusterCurrent: current,
+				ClusterDesired: current.DeepCopy(),
+			},
+			want: &Plan{
+				ClusterName: "default",
+				Action:      PlanActionNone,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.applier.Plan()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplierPlanResume(t *testing.T) {
+	processor.Resume = true
+	defer func() {
+		processor.Resume = false
+	}()
+	newCurrent := func(cp *v2.Checkpoint) *v2.Cluster {
+		current := newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22"},
+			[]string{"labring/kubernetes:v1.25.0"}, nil)
+		current.CreationTimestamp = metav1.Now()
+		current.Status.Checkpoint = cp
+		return current
+	}
+
+	tests := []struct {
+		name    string
+		current *v2.Cluster
+		desired *v2.Cluster
+		want    *Plan
+	}{
+		{
+			name: "failed scaling",
+			current: newCurrent(&v2.Checkpoint{Processor: processor.ScaleProcessorName, Phase: v2.StepFailed,
+				NodesToJoin: []string{"192.168.0.4:22"}}),
+			desired: newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22", "192.168.0.5:22"},
+				[]string{"labring/kubernetes:v1.25.0"}, nil),
+			want: &Plan{
+				ClusterName: "default",
+				Action:      PlanActionUpdate,
+				NodesToJoin: []string{"192.168.0.5:22", "192.168.0.4:22"},
+			},
+		},
+		{
+			name: "failed install",
+			current: newCurrent(&v2.Checkpoint{Processor: processor.InstallProcessorName, Phase: v2.StepFailed,
+				Images: []string{"labring/helm:v3.8.2"}}),
+			desired: newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22"},
+				[]string{"labring/kubernetes:v1.25.0"}, nil),
+			want: &Plan{
+				ClusterName:     "default",
+				Action:          PlanActionUpdate,
+				ImagesToInstall: []string{"labring/helm:v3.8.2"},
+			},
+		},
+		{
+			name:    "failed creation",
+			current: newCurrent(&v2.Checkpoint{Processor: processor.CreateProcessorName, Phase: v2.StepFailed}),
+			desired: newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22"},
+				[]string{"labring/kubernetes:v1.25.0"}, nil),
+			want: &Plan{
+				ClusterName:     "default",
+				Action:          PlanActionCreate,
+				MastersToJoin:   []string{"192.168.0.2:22"},
+				NodesToJoin:     []string{"192.168.0.3:22"},
+				ImagesToInstall: []string{"labring/kubernetes:v1.25.0"},
+			},
+		},
+		{
+			name: "succeeded",
+			current: newCurrent(&v2.Checkpoint{Processor: processor.ScaleProcessorName, Phase: v2.StepSucceeded,
+				NodesToJoin: []string{"192.168.0.4:22"}}),
+			desired: newTestCluster([]string{"192.168.0.2:22"}, []string{"192.168.0.3:22"},
+				[]string{"labring/kubernetes:v1.25.0"}, nil),
+			want: &Plan{
+				ClusterName: "default",
+				Action:      PlanActionNone,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applier := &Applier{ClusterCurrent: tt.current, ClusterDesired: tt.desired}
+			if got := applier.Plan(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanPrint(t *testing.T) {
+	plan := &Plan{
+		ClusterName:   "default",
+		Action:        PlanActionUpdate,
+		NodesToDelete: []string{"192.168.0.3:22"},
+		EnvChanges:    []EnvChange{{Key: "a", Old: "1"}},
+	}
+	var buf bytes.Buffer
+	if err := plan.Print(&buf, PlanFormatTable); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"delete node", "192.168.0.3:22", "remove env"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Print() table output %q does not contain %q", buf.String(), s)
+		}
+	}
+
+	buf.Reset()
+	if err := plan.Print(&buf, PlanFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	got := &Plan{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, plan) {
+		t.Errorf("Print() json output = %+v, want %+v", got, plan)
+	}
+
+	if err := plan.Print(&buf, "yaml"); err == nil {
+		t.Error("Print() with unsupported format expected error")
+	}
+}
@@ -38,11 +38,15 @@ func (c *Cluster) RegisterFlags(fs *pflag.FlagSet, verb, action string) {
 }
 
 type SSH struct {
-	User       string
-	Password   string
-	Pk         string
-	PkPassword string
-	Port       uint16
+	User          string
+	Password      string
+	Pk            string
+	PkPassword    string
+	Port          uint16
+	ProxyJump     []string
+	Cert          string
+	Agent         bool
+	HostKeyPolicy string
 }
 
 func (s *SSH) RegisterFlags(fs *pflag.FlagSet) {
@@ -52,6 +56,12 @@ func (s *SSH) RegisterFlags(fs *pflag.FlagSet) {
 		"selects a file from which the identity (private key) for public key authentication is read")
 	fs.StringVar(&s.PkPassword, "pk-passwd", "", "passphrase for decrypting a PEM encoded private key")
 	fs.Uint16Var(&s.Port, "port", 22, "port to connect to on the remote host")
+	fs.StringSliceVar(&s.ProxyJump, "ssh-proxy-jump", nil,
+		"jump hosts to connect through, in the form of [user@]host[:port], multiple jump hosts are connected in order")
+	fs.StringVar(&s.Cert, "ssh-cert", "", "OpenSSH user certificate signed for the private key, defaults to <pk>-cert.pub if exists")
+	fs.BoolVar(&s.Agent, "ssh-agent", false, "authenticate with the keys in ssh-agent listening on SSH_AUTH_SOCK")
+	fs.StringVar(&s.HostKeyPolicy, "ssh-host-key-policy", "",
+		"how the host keys are verified, insecure, strict (against ~/.ssh/known_hosts) or tofu (trust on first use), defaults to insecure")
 }
 
 type RunArgs struct {
@@ -97,6 +107,7 @@ func (arg *ResetArgs) RegisterFlags(fs *pflag.FlagSet) {
 type ScaleArgs struct {
 	*Cluster
 	*SSH
+	*DryRunArgs
 }
 
 func (arg *ScaleArgs) RegisterFlags(fs *pflag.FlagSet, verb, action string) {
@@ -105,4 +116,22 @@ func (arg *ScaleArgs) RegisterFlags(fs *pflag.FlagSet, verb, action string) {
 	if arg.SSH != nil {
 		arg.SSH.RegisterFlags(fs)
 	}
+	if arg.DryRunArgs != nil {
+		arg.DryRunArgs.RegisterFlags(fs)
+	}
+}
+
+type DryRunArgs struct {
+	DryRun bool
+	Format string
+}
+
+func (arg *DryRunArgs) RegisterFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&arg.DryRun, "dry-run", false, "only print the plan of changes without touching any host")
+	fs.StringVar(&arg.Format, "dry-run-format", "table", "format of the printed plan, table or json")
+}
+
+// IsDryRun is nil safe.
+func (arg *DryRunArgs) IsDryRun() bool {
+	return arg != nil && arg.DryRun
 }
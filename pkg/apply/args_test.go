@@ -67,6 +67,11 @@ func TestParseSSHFlagsCorrect(t *testing.T) {
 			&SSH{},
 			&SSH{User: "root", Password: "s3cret", Port: 2222, Pk: path.Join(constants.GetHomeDir(), ".ssh", "id_rsa")},
 		},
+		{
+			[]string{"--ssh-proxy-jump", "admin@10.0.0.1,10.0.0.2:2222"},
+			&SSH{},
+			&SSH{Port: 22, Pk: path.Join(constants.GetHomeDir(), ".ssh", "id_rsa"), ProxyJump: []string{"admin@10.0.0.1", "10.0.0.2:2222"}},
+		},
 	}
 
 	for _, tt := range tests {
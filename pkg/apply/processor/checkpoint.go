@@ -0,0 +1,210 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/labring/sealos/pkg/checker"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/events"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+const (
+	CreateProcessorName  = "CreateProcessor"
+	DeleteProcessorName  = "DeleteProcessor"
+	InstallProcessorName = "InstallProcessor"
+	ScaleProcessorName   = "ScaleProcessor"
+)
+
+// Resume skips the steps and hosts that have succeeded in the last failed apply.
+var Resume bool
+
+// steps that only check or prepare the in-memory states used by the following
+// steps, they are never skipped when resuming.
+var alwaysRunSteps = sets.NewString(
+	"Check", "JoinCheck", "DeleteCheck", "SyncStatusAndCheck", "ConfirmOverrideApps",
+	"PreProcess", "PreProcessImage", "RunConfig",
+)
+
+// Step is a stage of a processor pipeline, Name identifies the step in the checkpoints
+// and events, so it must not be changed once released.
+type Step struct {
+	Name string
+	Run  func(cluster *v2.Cluster) error
+}
+
+// ResumableCheckpoint returns the checkpoint of the last apply if it's
+// failed by the given processor and Resume is set, otherwise nil.
+func ResumableCheckpoint(cluster *v2.Cluster, processor string) *v2.Checkpoint {
+	if !Resume || cluster == nil {
+		return nil
+	}
+	cp := cluster.Status.Checkpoint
+	if cp == nil || cp.Phase == v2.StepSucceeded || (processor != "" && cp.Processor != processor) {
+		return nil
+	}
+	return cp
+}
+
+// executePipeline runs the pipeline and records the result of every step into
+// the checkpoint of cluster, the Clusterfile is saved after each step so that
+// a later apply is able to resume from where it stopped.
+func executePipeline(cluster *v2.Cluster, cf clusterfile.Interface, checkpoint *v2.Checkpoint, pipeline []Step) error {
+	if last := ResumableCheckpoint(cluster, checkpoint.Processor); last != nil {
+		logger.Info("resuming %s from the last failed apply", checkpoint.Processor)
+		checkpoint.Steps = last.Steps
+	}
+	checkpoint.Phase = v2.StepRunning
+	cluster.Status.Checkpoint = checkpoint
+	hosts, images := targetsOf(cluster, checkpoint)
+
+	for _, f := range pipeline {
+		name := f.Name
+		if step := checkpoint.GetStep(name); step != nil && !alwaysRunSteps.Has(name) {
+			if step.Phase == v2.StepSucceeded && step.Covers(hosts, images) {
+				logger.Info("skip pipeline %s in %s, which has succeeded in the last apply", name, checkpoint.Processor)
+				events.Emit(events.Event{Type: events.TypeStep, Status: events.StatusSkipped,
+					Cluster: cluster.Name, Processor: checkpoint.Processor, Step: name})
+				continue
+			}
+			if !step.CoversImages(images) {
+				// the hosts succeeded are applied with less images, run on all of them again
+				step.Hosts = nil
+			}
+		}
+		step := checkpoint.GetOrAddStep(name)
+		step.Phase, step.Message = v2.StepRunning, ""
+		step.TargetHosts, step.Images = hosts, images
+		err := runStep(cluster, checkpoint.Processor, f)
+		// the step might be appended again, look it up after running
+		step = checkpoint.GetStep(name)
+		step.LastUpdateTime = metav1.Now()
+		if err != nil {
+			step.Phase, step.Message = v2.StepFailed, err.Error()
+			checkpoint.Phase = v2.StepFailed
+		} else {
+			step.Phase = v2.StepSucceeded
+		}
+		if !alwaysRunSteps.Has(name) {
+			saveCheckpoint(cluster, cf)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	checkpoint.Phase = v2.StepSucceeded
+	return nil
+}
+
+// targetsOf returns the hosts and images that the processor of checkpoint is
+// applied to, a step succeeded in the last apply is skipped only if it has been
+// applied to all of them.
+func targetsOf(cluster *v2.Cluster, checkpoint *v2.Checkpoint) (hosts, images []string) {
+	if checkpoint.Processor == ScaleProcessorName {
+		hosts = append(hosts, checkpoint.MastersToJoin...)
+		hosts = append(hosts, checkpoint.NodesToJoin...)
+		hosts = append(hosts, checkpoint.MastersToDelete...)
+		hosts = append(hosts, checkpoint.NodesToDelete...)
+	} else {
+		hosts = append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	}
+	if checkpoint.Processor == InstallProcessorName {
+		return hosts, checkpoint.Images
+	}
+	return hosts, cluster.Spec.Image
+}
+
+// runStep runs a pipeline step and emits the events of its progress.
+func runStep(cluster *v2.Cluster, processor string, f Step) error {
+	done := events.Start(events.Event{Type: events.TypeStep, Cluster: cluster.Name, Processor: processor, Step: f.Name})
+	err := f.Run(cluster)
+	done(err)
+	return err
+}
+
+// runOnPendingHosts runs fn on the hosts that the step hasn't succeeded on,
+// the result of every host is recorded into the step if possible.
+func runOnPendingHosts(cluster *v2.Cluster, name string, hosts []string, fn func(hosts []string) error) error {
+	if cluster.Status.Checkpoint == nil {
+		return fn(hosts)
+	}
+	step := cluster.Status.Checkpoint.GetOrAddStep(name)
+	var pending []string
+	for _, host := range hosts {
+		if !step.IsHostSucceeded(host) {
+			pending = append(pending, host)
+		}
+	}
+	if len(pending) < len(hosts) {
+		logger.Info("skip %d host(s) in pipeline %s, which have succeeded in the last apply", len(hosts)-len(pending), name)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	err := fn(pending)
+	hostErrs, _ := parallel.AsHostErrors(err)
+	for _, host := range pending {
+		switch {
+		case err == nil:
+			step.UpdateHost(host, v2.StepSucceeded, "")
+		case hostErrs == nil:
+			step.UpdateHost(host, v2.StepFailed, err.Error())
+		case hostErrs[host] != nil:
+			step.UpdateHost(host, v2.StepFailed, hostErrs[host].Error())
+		default:
+			step.UpdateHost(host, v2.StepSucceeded, "")
+		}
+	}
+	return err
+}
+
+// preflight runs the preflight checks on the hosts that haven't passed them in
+// the step, so the hosts provisioned by the last failed apply are not checked
+// again when resuming, whose ports are taken by the components installed.
+func preflight(cluster *v2.Cluster, step string, hosts []string) error {
+	return runOnPendingHosts(cluster, step, hosts, func(pending []string) error {
+		_, err := checker.RunCheckList([]checker.Interface{checker.NewPreflightChecker(pending)}, cluster, checker.PhasePre)
+		return err
+	})
+}
+
+// GetWriteBackObjects returns the objects to be saved into the Clusterfile.
+func GetWriteBackObjects(cluster *v2.Cluster, cf clusterfile.Interface) []interface{} {
+	obj := []interface{}{cluster}
+	if runtimeConfig := cf.GetRuntimeConfig(); runtimeConfig != nil {
+		if components := runtimeConfig.GetComponents(); len(components) > 0 {
+			obj = append(obj, components...)
+		}
+	}
+	if configs := cf.GetConfigs(); len(configs) > 0 {
+		for i := range configs {
+			obj = append(obj, configs[i])
+		}
+	}
+	return obj
+}
+
+func saveCheckpoint(cluster *v2.Cluster, cf clusterfile.Interface) {
+	if err := yaml.MarshalFile(constants.Clusterfile(cluster.Name), GetWriteBackObjects(cluster, cf)...); err != nil {
+		logger.Warn("failed to save checkpoint of cluster: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/parallel"
+)
+
+type fakeProcessor struct {
+	hosts     []string
+	failHosts map[string]bool
+	called    []string
+	mounted   []string
+	// bootstrapped are the hosts of the last Bootstrap
+	bootstrapped []string
+	joinErr      error
+}
+
+func (f *fakeProcessor) pipeline() []Step {
+	return []Step{{"Check", f.Check}, {"MountRootfs", f.MountRootfs}, {"Init", f.Init}}
+}
+
+func (f *fakeProcessor) Check(*v2.Cluster) error {
+	f.called = append(f.called, "Check")
+	return nil
+}
+
+func (f *fakeProcessor) MountRootfs(cluster *v2.Cluster) error {
+	return runOnPendingHosts(cluster, "MountRootfs", f.hosts, func(hosts []string) error {
+		f.mounted = hosts
+		return parallel.Run(hosts, func(host string) error {
+			if f.failHosts[host] {
+				return errors.New("failed to copy")
+			}
+			return nil
+		})
+	})
+}
+
+func (f *fakeProcessor) Init(*v2.Cluster) error {
+	f.called = append(f.called, "Init")
+	return nil
+}
+
+func (f *fakeProcessor) scalePipeline() []Step {
+	return []Step{{"MountRootfs", f.MountRootfs}, {"Bootstrap", f.Bootstrap}, {"Join", f.Join}}
+}
+
+func (f *fakeProcessor) Bootstrap(cluster *v2.Cluster) error {
+	return runOnPendingHosts(cluster, "Bootstrap", f.hosts, func(hosts []string) error {
+		f.bootstrapped = hosts
+		return nil
+	})
+}
+
+func (f *fakeProcessor) Join(*v2.Cluster) error {
+	f.called = append(f.called, "Join")
+	return f.joinErr
+}
+
+func TestExecutePipeline(t *testing.T) {
+	origin := constants.DefaultRuntimeRootDir
+	defer func() {
+		constants.DefaultRuntimeRootDir = origin
+		Resume = false
+	}()
+	constants.DefaultRuntimeRootDir = t.TempDir()
+
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(cluster.Name))
+	f := &fakeProcessor{
+		hosts:     []string{"192.168.0.2:22", "192.168.0.3:22"},
+		failHosts: map[string]bool{"192.168.0.3:22": true},
+	}
+	if err := executePipeline(cluster, cf, &v2.Checkpoint{Processor: CreateProcessorName}, f.pipeline()); err == nil {
+		t.Fatal("executePipeline() expected error")
+	}
+	cp := cluster.Status.Checkpoint
+	if cp.Phase != v2.StepFailed {
+		t.Errorf("checkpoint phase = %s, want %s", cp.Phase, v2.StepFailed)
+	}
+	step := cp.GetStep("MountRootfs")
+	if step == nil || step.Phase != v2.StepFailed {
+		t.Fatalf("MountRootfs step = %+v, want failed", step)
+	}
+	if !step.IsHostSucceeded("192.168.0.2:22") || step.IsHostSucceeded("192.168.0.3:22") {
+		t.Errorf("MountRootfs hosts = %+v, want only 192.168.0.3:22 failed", step.Hosts)
+	}
+	if cp.GetStep("Init") != nil {
+		t.Error("Init step should not be recorded after MountRootfs failed")
+	}
+
+	saved, err := clusterfile.GetClusterFromName(cluster.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status.Checkpoint == nil || saved.Status.Checkpoint.GetStep("MountRootfs") == nil {
+		t.Fatalf("checkpoint is not saved into Clusterfile: %+v", saved.Status.Checkpoint)
+	}
+
+	// resume from the saved cluster, only the failed host is retried
+	Resume = true
+	f.failHosts = nil
+	f.called = nil
+	if err = executePipeline(saved, cf, &v2.Checkpoint{Processor: CreateProcessorName}, f.pipeline()); err != nil {
+		t.Fatalf("executePipeline() error = %v", err)
+	}
+	if want := []string{"192.168.0.3:22"}; !reflect.DeepEqual(f.mounted, want) {
+		t.Errorf("retried hosts = %v, want %v", f.mounted, want)
+	}
+	if want := []string{"Check", "Init"}; !reflect.DeepEqual(f.called, want) {
+		t.Errorf("called steps = %v, want %v", f.called, want)
+	}
+	if saved.Status.Checkpoint.Phase != v2.StepSucceeded {
+		t.Errorf("checkpoint phase = %s, want %s", saved.Status.Checkpoint.Phase, v2.StepSucceeded)
+	}
+
+	// a succeeded checkpoint is never resumed
+	f.called = nil
+	if err = executePipeline(saved, cf, &v2.Checkpoint{Processor: CreateProcessorName}, f.pipeline()); err != nil {
+		t.Fatalf("executePipeline() error = %v", err)
+	}
+	if want := []string{"Check", "Init"}; !reflect.DeepEqual(f.called, want) {
+		t.Errorf("called steps = %v, want %v", f.called, want)
+	}
+	if want := f.hosts; !reflect.DeepEqual(f.mounted, want) {
+		t.Errorf("mounted hosts = %v, want %v", f.mounted, want)
+	}
+}
+
+func TestExecutePipelineResumeMoreTargets(t *testing.T) {
+	origin := constants.DefaultRuntimeRootDir
+	defer func() {
+		constants.DefaultRuntimeRootDir = origin
+		Resume = false
+	}()
+	constants.DefaultRuntimeRootDir = t.TempDir()
+
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	cluster.Spec.Image = []string{"labring/kubernetes:v1.25.0"}
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(cluster.Name))
+	f := &fakeProcessor{
+		hosts:   []string{"192.168.0.2:22"},
+		joinErr: errors.New("failed to join"),
+	}
+	if err := executePipeline(cluster, cf, &v2.Checkpoint{Processor: ScaleProcessorName, NodesToJoin: f.hosts}, f.scalePipeline()); err == nil {
+		t.Fatal("executePipeline() expected error")
+	}
+
+	// add another node when resuming, it's mounted and bootstrapped while the
+	// node succeeded is skipped
+	Resume = true
+	f.hosts = []string{"192.168.0.2:22", "192.168.0.3:22"}
+	f.joinErr = nil
+	f.mounted, f.bootstrapped = nil, nil
+	if err := executePipeline(cluster, cf, &v2.Checkpoint{Processor: ScaleProcessorName, NodesToJoin: f.hosts}, f.scalePipeline()); err != nil {
+		t.Fatalf("executePipeline() error = %v", err)
+	}
+	want := []string{"192.168.0.3:22"}
+	if !reflect.DeepEqual(f.mounted, want) {
+		t.Errorf("mounted hosts = %v, want %v", f.mounted, want)
+	}
+	if !reflect.DeepEqual(f.bootstrapped, want) {
+		t.Errorf("bootstrapped hosts = %v, want %v", f.bootstrapped, want)
+	}
+	step := cluster.Status.Checkpoint.GetStep("Bootstrap")
+	if !step.Covers(f.hosts, cluster.Spec.Image) {
+		t.Errorf("Bootstrap step = %+v, want applied to %v", step, f.hosts)
+	}
+
+	// install more images when resuming, all hosts are mounted again
+	Resume = false
+	cluster.Spec.Image = append(cluster.Spec.Image, "labring/helm:v3.8.2")
+	f.joinErr = errors.New("failed to join")
+	if err := executePipeline(cluster, cf, &v2.Checkpoint{Processor: InstallProcessorName, Images: []string{"labring/helm:v3.8.2"}}, f.scalePipeline()); err == nil {
+		t.Fatal("executePipeline() expected error")
+	}
+	Resume = true
+	f.joinErr = nil
+	f.mounted, f.bootstrapped = nil, nil
+	images := []string{"labring/helm:v3.8.2", "labring/cilium:v1.12.0"}
+	if err := executePipeline(cluster, cf, &v2.Checkpoint{Processor: InstallProcessorName, Images: images}, f.scalePipeline()); err != nil {
+		t.Fatalf("executePipeline() error = %v", err)
+	}
+	if !reflect.DeepEqual(f.mounted, f.hosts) {
+		t.Errorf("mounted hosts = %v, want %v", f.mounted, f.hosts)
+	}
+	if step = cluster.Status.Checkpoint.GetStep("MountRootfs"); !step.CoversImages(images) {
+		t.Errorf("MountRootfs step = %+v, want applied to %v", step, images)
+	}
+}
+
+func TestPreflightSkipsPassedHosts(t *testing.T) {
+	hosts := []string{"192.168.0.2:22", "192.168.0.3:22"}
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	cluster.Status.Checkpoint = &v2.Checkpoint{Processor: ScaleProcessorName}
+	step := cluster.Status.Checkpoint.GetOrAddStep("JoinCheck")
+	for _, host := range hosts {
+		step.UpdateHost(host, v2.StepSucceeded, "")
+	}
+	// the hosts can't be connected, so it fails if any of them is checked again
+	if err := preflight(cluster, "JoinCheck", hosts); err != nil {
+		t.Errorf("preflight() error = %v, want the passed hosts skipped", err)
+	}
+}
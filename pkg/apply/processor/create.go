@@ -18,8 +18,6 @@ import (
 	"context"
 	"fmt"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/pkg/bootstrap"
 	"github.com/labring/sealos/pkg/buildah"
 	"github.com/labring/sealos/pkg/checker"
@@ -33,6 +31,7 @@ import (
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/maps"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
@@ -49,30 +48,24 @@ func (c *CreateProcessor) Execute(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	for _, f := range pipeLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return executePipeline(cluster, c.ClusterFile, &v2.Checkpoint{Processor: CreateProcessorName}, pipeLine)
 }
 
-func (c *CreateProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
+func (c *CreateProcessor) GetPipeLine() ([]Step, error) {
+	var todoList []Step
 	todoList = append(todoList,
 		// c.GetPhasePluginFunc(plugin.PhaseOriginally),
-		c.Check,
-		c.PreProcess,
-		c.RunConfig,
-		c.MountRootfs,
-		c.MirrorRegistry,
-		c.Bootstrap,
+		Step{"Check", c.Check},
+		Step{"PreProcess", c.PreProcess},
+		Step{"RunConfig", c.RunConfig},
+		Step{"MountRootfs", c.MountRootfs},
+		Step{"MirrorRegistry", c.MirrorRegistry},
+		Step{"Bootstrap", c.Bootstrap},
 		// c.GetPhasePluginFunc(plugin.PhasePreInit),
-		c.Init,
-		c.Join,
+		Step{"Init", c.Init},
+		Step{"Join", c.Join},
 		// c.GetPhasePluginFunc(plugin.PhasePreGuest),
-		c.RunGuest,
+		Step{"RunGuest", c.RunGuest},
 		// c.GetPhasePluginFunc(plugin.PhasePostInstall),
 	)
 
@@ -85,7 +78,10 @@ func (c *CreateProcessor) Check(cluster *v2.Cluster) error {
 	// the order doesn't matter
 	ips = append(ips, cluster.GetMasterIPAndPortList()...)
 	ips = append(ips, cluster.GetNodeIPAndPortList()...)
-	return NewCheckError(checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre))
+	if _, err := checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre); err != nil {
+		return NewCheckError(err)
+	}
+	return NewCheckError(preflight(cluster, "Check", ips))
 }
 
 func (c *CreateProcessor) PreProcess(cluster *v2.Cluster) error {
@@ -114,15 +110,23 @@ func (c *CreateProcessor) preProcess(cluster *v2.Cluster) error {
 
 func (c *CreateProcessor) RunConfig(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline RunConfig in CreateProcessor.")
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, cManifest := range cluster.Status.Mounts {
-		manifest := cManifest
-		eg.Go(func() error {
-			cfg := config.NewConfiguration(manifest.ImageName, manifest.MountPoint, c.ClusterFile.GetConfigs())
-			return cfg.Dump()
-		})
+	return dumpConfigs(cluster.Status.Mounts, c.ClusterFile.GetConfigs())
+}
+
+// dumpConfigs writes the configs into the mounted images, which is local and
+// only limited by --max-parallel.
+func dumpConfigs(mounts []v2.MountImage, configs []v2.Config) error {
+	names := make([]string, 0, len(mounts))
+	byName := make(map[string]v2.MountImage, len(mounts))
+	for _, mount := range mounts {
+		names = append(names, mount.Name)
+		byName[mount.Name] = mount
 	}
-	return eg.Wait()
+	opts := parallel.Options{MaxParallel: parallel.DefaultOptions.MaxParallel}
+	return parallel.RunWithOptions(names, opts, func(name string) error {
+		mount := byName[name]
+		return config.NewConfiguration(mount.ImageName, mount.MountPoint, configs).Dump()
+	})
 }
 
 func (c *CreateProcessor) MountRootfs(cluster *v2.Cluster) error {
@@ -132,7 +136,9 @@ func (c *CreateProcessor) MountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.MountRootfs(cluster, hosts)
+	return runOnPendingHosts(cluster, "MountRootfs", hosts, func(hosts []string) error {
+		return fs.MountRootfs(cluster, hosts)
+	})
 }
 
 func (c *CreateProcessor) MirrorRegistry(cluster *v2.Cluster) error {
@@ -144,7 +150,9 @@ func (c *CreateProcessor) Bootstrap(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Bootstrap in CreateProcessor")
 	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
 	bs := bootstrap.New(cluster)
-	return bs.Apply(hosts...)
+	return runOnPendingHosts(cluster, "Bootstrap", hosts, func(hosts []string) error {
+		return bs.Apply(hosts...)
+	})
 }
 
 func (c *CreateProcessor) Init(_ *v2.Cluster) error {
@@ -47,22 +47,22 @@ func (d DeleteProcessor) Execute(cluster *v2.Cluster) (err error) {
 	}
 	// TODO if error is exec net process ???
 	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
+		if err = runStep(cluster, DeleteProcessorName, f); err != nil {
 			logger.Warn("failed to exec delete process, %s", err.Error())
 		}
 	}
 
 	return nil
 }
-func (d DeleteProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
+func (d DeleteProcessor) GetPipeLine() ([]Step, error) {
+	var todoList []Step
 	todoList = append(todoList,
-		d.PreProcess,
-		d.Reset,
-		d.UndoBootstrap,
-		d.UnMountRootfs,
-		d.UnMountImage,
-		d.CleanFS,
+		Step{"PreProcess", d.PreProcess},
+		Step{"Reset", d.Reset},
+		Step{"UndoBootstrap", d.UndoBootstrap},
+		Step{"UnMountRootfs", d.UnMountRootfs},
+		Step{"UnMountImage", d.UnMountImage},
+		Step{"CleanFS", d.CleanFS},
 	)
 	return todoList, nil
 }
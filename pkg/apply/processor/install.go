@@ -20,12 +20,10 @@ import (
 	"sort"
 	"strings"
 
-	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/labring/sealos/pkg/buildah"
 	"github.com/labring/sealos/pkg/clusterfile"
-	"github.com/labring/sealos/pkg/config"
 	"github.com/labring/sealos/pkg/filesystem/rootfs"
 	"github.com/labring/sealos/pkg/guest"
 	"github.com/labring/sealos/pkg/runtime"
@@ -49,6 +47,8 @@ type InstallProcessor struct {
 	NewImages        []string
 	ExtraEnvs        map[string]string // parsing from CLI arguments
 	imagesToOverride []string
+	// images mounted by the failed apply that is being resumed
+	resumedImages sets.String
 }
 
 func (c *InstallProcessor) Execute(cluster *v2.Cluster) error {
@@ -56,29 +56,25 @@ func (c *InstallProcessor) Execute(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
+	if cp := ResumableCheckpoint(cluster, InstallProcessorName); cp != nil {
+		c.resumedImages = sets.NewString(cp.Images...)
 	}
-
-	return nil
+	return executePipeline(cluster, c.ClusterFile, &v2.Checkpoint{Processor: InstallProcessorName, Images: c.NewImages}, pipLine)
 }
 
-func (c *InstallProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
+func (c *InstallProcessor) GetPipeLine() ([]Step, error) {
+	var todoList []Step
 	todoList = append(todoList,
-		c.SyncStatusAndCheck,
-		c.ConfirmOverrideApps,
-		c.PreProcess,
-		c.RunConfig,
-		c.MountRootfs,
-		c.MirrorRegistry,
-		c.UpgradeIfNeed,
+		Step{"SyncStatusAndCheck", c.SyncStatusAndCheck},
+		Step{"ConfirmOverrideApps", c.ConfirmOverrideApps},
+		Step{"PreProcess", c.PreProcess},
+		Step{"RunConfig", c.RunConfig},
+		Step{"MountRootfs", c.MountRootfs},
+		Step{"MirrorRegistry", c.MirrorRegistry},
+		Step{"UpgradeIfNeed", c.UpgradeIfNeed},
 		// i.GetPhasePluginFunc(plugin.PhasePreGuest),
-		c.RunGuest,
-		c.PostProcess,
+		Step{"RunGuest", c.RunGuest},
+		Step{"PostProcess", c.PostProcess},
 		// i.GetPhasePluginFunc(plugin.PhasePostInstall),
 	)
 	return todoList, nil
@@ -94,15 +90,26 @@ func (c *InstallProcessor) SyncStatusAndCheck(_ *v2.Cluster) error {
 	if err = SyncClusterStatus(current, c.Buildah, false); err != nil {
 		return err
 	}
-	imageList := sets.NewString(current.Spec.Image...)
-	for _, img := range c.NewImages {
-		if imageList.Has(img) {
+	for _, img := range GetImagesToOverride(current, c.NewImages) {
+		if !c.resumedImages.Has(img) {
 			c.imagesToOverride = append(c.imagesToOverride, img)
 		}
 	}
 	return nil
 }
 
+// GetImagesToOverride returns the images that have already been applied to the cluster.
+func GetImagesToOverride(current *v2.Cluster, images []string) []string {
+	var ret []string
+	imageList := sets.NewString(current.Spec.Image...)
+	for _, img := range images {
+		if imageList.Has(img) {
+			ret = append(ret, img)
+		}
+	}
+	return ret
+}
+
 func (c *InstallProcessor) ConfirmOverrideApps(_ *v2.Cluster) error {
 	logger.Info("Executing ConfirmOverrideApps Pipeline in InstallProcessor")
 
@@ -163,6 +170,10 @@ func (c *InstallProcessor) PreProcess(cluster *v2.Cluster) error {
 		index, mount := cluster.FindImage(img)
 		var ctrName string
 		if mount != nil {
+			if c.resumedImages.Has(img) {
+				c.NewMounts = append(c.NewMounts, *mount)
+				continue
+			}
 			if !ForceOverride {
 				continue
 			}
@@ -234,15 +245,7 @@ func (c *InstallProcessor) RunConfig(_ *v2.Cluster) error {
 	if len(c.NewMounts) == 0 {
 		return nil
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, cManifest := range c.NewMounts {
-		manifest := cManifest
-		eg.Go(func() error {
-			cfg := config.NewConfiguration(manifest.ImageName, manifest.MountPoint, c.ClusterFile.GetConfigs())
-			return cfg.Dump()
-		})
-	}
-	return eg.Wait()
+	return dumpConfigs(c.NewMounts, c.ClusterFile.GetConfigs())
 }
 
 func (c *InstallProcessor) MountRootfs(cluster *v2.Cluster) error {
@@ -255,7 +258,9 @@ func (c *InstallProcessor) MountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.MountRootfs(cluster, hosts)
+	return runOnPendingHosts(cluster, "MountRootfs", hosts, func(hosts []string) error {
+		return fs.MountRootfs(cluster, hosts)
+	})
 }
 
 func (c *InstallProcessor) MirrorRegistry(cluster *v2.Cluster) error {
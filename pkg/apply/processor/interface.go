@@ -155,13 +155,36 @@ func ConfirmDeleteNodes() error {
 func MirrorRegistry(cluster *v2.Cluster, mounts []v2.MountImage) error {
 	registries := cluster.GetRegistryIPAndPortList()
 	logger.Debug("registry nodes is: %+v", registries)
+	if err := MirrorRegistryTo(cluster, mounts, registries...); err != nil {
+		return err
+	}
+	return SyncRegistryReplicas(cluster)
+}
+
+// MirrorRegistryTo syncs the registries of mounts to the given hosts only.
+func MirrorRegistryTo(cluster *v2.Cluster, mounts []v2.MountImage, hosts ...string) error {
 	sshClient := ssh.NewCacheClientFromCluster(cluster, true)
 	execer, err := exec.New(sshClient)
 	if err != nil {
 		return err
 	}
 	syncer := registry.New(constants.NewPathResolver(cluster.GetName()), execer, mounts)
-	return syncer.Sync(context.Background(), registries...)
+	return syncer.Sync(context.Background(), hosts...)
+}
+
+// SyncRegistryReplicas replicates the images pushed to any replica of an HA
+// registry to the others through the first replica.
+func SyncRegistryReplicas(cluster *v2.Cluster) error {
+	if !cluster.IsRegistryHA() {
+		return nil
+	}
+	sshClient := ssh.NewCacheClientFromCluster(cluster, true)
+	execer, err := exec.New(sshClient)
+	if err != nil {
+		return err
+	}
+	syncer := registry.New(constants.NewPathResolver(cluster.GetName()), execer, nil)
+	return syncer.SyncReplicas(context.Background(), cluster.GetRegistryIPAndPortList()...)
 }
 
 func getIndexOfContainerInMounts(mounts []v2.MountImage, imageName string) int {
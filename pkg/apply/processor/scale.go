@@ -15,16 +15,14 @@
 package processor
 
 import (
-	"context"
 	"fmt"
 
-	"golang.org/x/sync/errgroup"
+	"golang.org/x/exp/slices"
 
 	"github.com/labring/sealos/pkg/bootstrap"
 	"github.com/labring/sealos/pkg/buildah"
 	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/clusterfile"
-	"github.com/labring/sealos/pkg/config"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/filesystem/rootfs"
 	"github.com/labring/sealos/pkg/guest"
@@ -33,6 +31,8 @@ import (
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	fileutil "github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
@@ -47,6 +47,8 @@ type ScaleProcessor struct {
 	NodesToDelete   []string
 	IsScaleUp       bool
 	Guest           guest.Interface
+	// nodes excluded from scaling since they failed
+	failedNodes []string
 }
 
 func (c *ScaleProcessor) Execute(cluster *v2.Cluster) error {
@@ -54,41 +56,42 @@ func (c *ScaleProcessor) Execute(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
+	checkpoint := &v2.Checkpoint{
+		Processor:       ScaleProcessorName,
+		MastersToJoin:   c.MastersToJoin,
+		MastersToDelete: c.MastersToDelete,
+		NodesToJoin:     c.NodesToJoin,
+		NodesToDelete:   c.NodesToDelete,
 	}
-
-	return nil
+	return executePipeline(cluster, c.ClusterFile, checkpoint, pipLine)
 }
 
-func (c *ScaleProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
-	var todoList []func(cluster *v2.Cluster) error
+func (c *ScaleProcessor) GetPipeLine() ([]Step, error) {
+	var todoList []Step
 	if c.IsScaleUp {
 		todoList = append(todoList,
-			c.JoinCheck,
-			c.PreProcess,
-			c.PreProcessImage,
-			c.RunConfig,
-			c.MountRootfs,
-			c.Bootstrap,
+			Step{"JoinCheck", c.JoinCheck},
+			Step{"PreProcess", c.PreProcess},
+			Step{"PreProcessImage", c.PreProcessImage},
+			Step{"RunConfig", c.RunConfig},
+			Step{"MountRootfs", c.MountRootfs},
+			Step{"Bootstrap", c.Bootstrap},
+			Step{"MirrorRegistry", c.MirrorRegistry},
 			//s.GetPhasePluginFunc(plugin.PhasePreJoin),
-			c.Join,
-			c.RunGuest,
+			Step{"Join", c.Join},
+			Step{"RunGuest", c.RunGuest},
 			//s.GetPhasePluginFunc(plugin.PhasePostJoin),
 		)
 		return todoList, nil
 	}
 
 	todoList = append(todoList,
-		c.DeleteCheck,
-		c.PreProcess,
-		c.Delete,
-		c.UndoBootstrap,
+		Step{"DeleteCheck", c.DeleteCheck},
+		Step{"PreProcess", c.PreProcess},
+		Step{"Delete", c.Delete},
+		Step{"UndoBootstrap", c.UndoBootstrap},
 		//c.ApplyCleanPlugin,
-		c.UnMountRootfs,
+		Step{"UnMountRootfs", c.UnMountRootfs},
 	)
 	return todoList, nil
 }
@@ -126,10 +129,30 @@ func (c *ScaleProcessor) Delete(cluster *v2.Cluster) error {
 	return nil
 }
 
+// MirrorRegistry seeds the new masters with the images of the cluster when
+// they are the replicas of an HA registry, then syncs all the replicas.
+func (c *ScaleProcessor) MirrorRegistry(cluster *v2.Cluster) error {
+	if !cluster.IsRegistryHA() || len(c.MastersToJoin) == 0 {
+		return nil
+	}
+	logger.Info("Executing pipeline MirrorRegistry in ScaleProcessor.")
+	if err := MirrorRegistryTo(cluster, cluster.Status.Mounts, c.MastersToJoin...); err != nil {
+		return err
+	}
+	return SyncRegistryReplicas(cluster)
+}
+
 func (c *ScaleProcessor) Join(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Join in ScaleProcessor.")
-	err := c.Runtime.ScaleUp(c.MastersToJoin, c.NodesToJoin)
-	if err != nil {
+	if len(c.MastersToJoin) > 0 {
+		if err := c.Runtime.ScaleUp(c.MastersToJoin, nil); err != nil {
+			return err
+		}
+	}
+	err := runOnPendingHosts(cluster, "Join", c.NodesToJoin, func(hosts []string) error {
+		return c.Runtime.ScaleUp(nil, hosts)
+	})
+	if err = c.tolerateFailedNodes(cluster, err); err != nil {
 		return err
 	}
 	if len(c.MastersToJoin) > 0 {
@@ -158,7 +181,14 @@ func (c *ScaleProcessor) JoinCheck(cluster *v2.Cluster) error {
 	ips = append(ips, cluster.GetMaster0IPAndPort())
 	ips = append(ips, c.MastersToJoin...)
 	ips = append(ips, c.NodesToJoin...)
-	return NewCheckError(checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre))
+	// master0 is running already, only the hosts to join are preflighted
+	var joining []string
+	joining = append(joining, c.MastersToJoin...)
+	joining = append(joining, c.NodesToJoin...)
+	if _, err := checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre); err != nil {
+		return NewCheckError(err)
+	}
+	return NewCheckError(preflight(cluster, "JoinCheck", joining))
 }
 
 func (c *ScaleProcessor) DeleteCheck(cluster *v2.Cluster) error {
@@ -167,7 +197,8 @@ func (c *ScaleProcessor) DeleteCheck(cluster *v2.Cluster) error {
 	ips = append(ips, cluster.GetMaster0IPAndPort())
 	//ips = append(ips, c.MastersToDelete...)
 	//ips = append(ips, c.NodesToDelete...)
-	return NewCheckError(checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre))
+	_, err := checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre)
+	return NewCheckError(err)
 }
 
 func (c *ScaleProcessor) PreProcess(cluster *v2.Cluster) error {
@@ -241,15 +272,7 @@ func (c *ScaleProcessor) PreProcessImage(cluster *v2.Cluster) error {
 
 func (c *ScaleProcessor) RunConfig(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline RunConfig in ScaleProcessor.")
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, cManifest := range cluster.Status.Mounts {
-		manifest := cManifest
-		eg.Go(func() error {
-			cfg := config.NewConfiguration(manifest.ImageName, manifest.MountPoint, c.ClusterFile.GetConfigs())
-			return cfg.Dump()
-		})
-	}
-	return eg.Wait()
+	return dumpConfigs(cluster.Status.Mounts, c.ClusterFile.GetConfigs())
 }
 
 func (c *ScaleProcessor) MountRootfs(cluster *v2.Cluster) error {
@@ -262,7 +285,10 @@ func (c *ScaleProcessor) MountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.MountRootfs(cluster, hosts)
+	err = runOnPendingHosts(cluster, "MountRootfs", hosts, func(hosts []string) error {
+		return fs.MountRootfs(cluster, hosts)
+	})
+	return c.tolerateFailedNodes(cluster, err)
 }
 
 func filterNoneApplicationMounts(images []v2.MountImage) []v2.MountImage {
@@ -279,7 +305,44 @@ func (c *ScaleProcessor) Bootstrap(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Bootstrap in ScaleProcessor")
 	hosts := append(c.MastersToJoin, c.NodesToJoin...)
 	bs := bootstrap.New(cluster)
-	return bs.Apply(hosts...)
+	err := runOnPendingHosts(cluster, "Bootstrap", hosts, func(hosts []string) error {
+		return bs.Apply(hosts...)
+	})
+	return c.tolerateFailedNodes(cluster, err)
+}
+
+// tolerateFailedNodes excludes the failed nodes from scaling if there are no more
+// than --max-failed-hosts of them, so that the other hosts are still joined.
+// Failed masters are never tolerated.
+func (c *ScaleProcessor) tolerateFailedNodes(cluster *v2.Cluster, err error) error {
+	hostErrs, ok := parallel.AsHostErrors(err)
+	if !ok {
+		return err
+	}
+	failed := hostErrs.Hosts()
+	if len(c.failedNodes)+len(failed) > parallel.DefaultOptions.MaxFailedHosts {
+		return err
+	}
+	for _, host := range failed {
+		if !slices.Contains(c.NodesToJoin, host) {
+			return err
+		}
+	}
+	logger.Warn("nodes %s are excluded from scaling, add them again after fixed: %v", failed, err)
+	c.failedNodes = append(c.failedNodes, failed...)
+	c.NodesToJoin = stringsutil.RemoveSubSlice(c.NodesToJoin, failed)
+	hosts := make([]v2.Host, 0, len(cluster.Spec.Hosts))
+	for _, host := range cluster.Spec.Hosts {
+		host.IPS = stringsutil.RemoveSubSlice(host.IPS, failed)
+		if len(host.IPS) > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	cluster.Spec.Hosts = hosts
+	if cp := cluster.Status.Checkpoint; cp != nil {
+		cp.NodesToJoin = c.NodesToJoin
+	}
+	return nil
 }
 
 func (c *ScaleProcessor) UndoBootstrap(_ *v2.Cluster) error {
@@ -0,0 +1,121 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/labring/sealos/pkg/runtime"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/parallel"
+)
+
+func TestTolerateFailedNodes(t *testing.T) {
+	origin := parallel.DefaultOptions
+	defer func() {
+		parallel.DefaultOptions = origin
+	}()
+	parallel.DefaultOptions.MaxFailedHosts = 1
+
+	cluster := &v2.Cluster{}
+	cluster.Spec.Hosts = []v2.Host{
+		{IPS: []string{"192.168.0.2:22", "192.168.0.3:22"}, Roles: []string{v2.MASTER}},
+		{IPS: []string{"192.168.0.4:22", "192.168.0.5:22"}, Roles: []string{v2.NODE}},
+	}
+	c := &ScaleProcessor{
+		MastersToJoin: []string{"192.168.0.3:22"},
+		NodesToJoin:   []string{"192.168.0.4:22", "192.168.0.5:22"},
+	}
+	failed := errors.New("failed")
+
+	if err := c.tolerateFailedNodes(cluster, parallel.HostErrors{"192.168.0.3:22": failed}); err == nil {
+		t.Error("failed masters should not be tolerated")
+	}
+	if err := c.tolerateFailedNodes(cluster, failed); err == nil {
+		t.Error("errors not related to hosts should not be tolerated")
+	}
+	if err := c.tolerateFailedNodes(cluster, parallel.HostErrors{"192.168.0.4:22": failed}); err != nil {
+		t.Fatalf("tolerateFailedNodes() error = %v", err)
+	}
+	if want := []string{"192.168.0.5:22"}; !reflect.DeepEqual(c.NodesToJoin, want) || !reflect.DeepEqual(cluster.GetNodeIPAndPortList(), want) {
+		t.Errorf("nodes to join = %v, cluster nodes = %v, want %v", c.NodesToJoin, cluster.GetNodeIPAndPortList(), want)
+	}
+	if err := c.tolerateFailedNodes(cluster, parallel.HostErrors{"192.168.0.5:22": failed}); err == nil {
+		t.Error("failed nodes more than max-failed-hosts should not be tolerated")
+	}
+}
+
+type fakeJoinRuntime struct {
+	runtime.Interface
+	failHosts map[string]bool
+	joined    []string
+	synced    []string
+}
+
+func (f *fakeJoinRuntime) ScaleUp(masters, nodes []string) error {
+	f.joined = append(f.joined, masters...)
+	return parallel.Run(nodes, func(node string) error {
+		if f.failHosts[node] {
+			return errors.New("failed to join")
+		}
+		return nil
+	})
+}
+
+func (f *fakeJoinRuntime) SyncNodeIPVS(_, nodes []string) error {
+	f.synced = nodes
+	return nil
+}
+
+func TestJoinToleratesFailedNodes(t *testing.T) {
+	origin := parallel.DefaultOptions
+	defer func() {
+		parallel.DefaultOptions = origin
+	}()
+	parallel.DefaultOptions.MaxFailedHosts = 1
+
+	cluster := &v2.Cluster{}
+	cluster.Spec.Hosts = []v2.Host{
+		{IPS: []string{"192.168.0.2:22"}, Roles: []string{v2.MASTER}},
+		{IPS: []string{"192.168.0.4:22", "192.168.0.5:22"}, Roles: []string{v2.NODE}},
+	}
+	cluster.Status.Checkpoint = &v2.Checkpoint{Processor: ScaleProcessorName}
+	rt := &fakeJoinRuntime{failHosts: map[string]bool{"192.168.0.5:22": true}}
+	c := &ScaleProcessor{
+		Runtime:     rt,
+		NodesToJoin: []string{"192.168.0.4:22", "192.168.0.5:22"},
+	}
+	if err := c.Join(cluster); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	want := []string{"192.168.0.4:22"}
+	if !reflect.DeepEqual(c.NodesToJoin, want) || !reflect.DeepEqual(rt.synced, want) {
+		t.Errorf("nodes to join = %v, synced = %v, want %v", c.NodesToJoin, rt.synced, want)
+	}
+	step := cluster.Status.Checkpoint.GetStep("Join")
+	if step == nil || !step.IsHostSucceeded("192.168.0.4:22") || step.IsHostSucceeded("192.168.0.5:22") {
+		t.Errorf("hosts of Join step are not recorded: %+v", step)
+	}
+
+	// one more failed node exceeds max-failed-hosts
+	rt.failHosts["192.168.0.4:22"] = true
+	c.NodesToJoin = []string{"192.168.0.4:22"}
+	cluster.Status.Checkpoint = nil
+	if err := c.Join(cluster); err == nil {
+		t.Error("Join() expected error when failed nodes are more than max-failed-hosts")
+	}
+}
@@ -103,8 +103,8 @@ func (r *ClusterArgs) runArgs(cmd *cobra.Command, args *RunArgs, imageList []str
 			return errors.New("master ip(s) must specified")
 		}
 	} else {
-		if r.cluster.Status.Phase != v2.ClusterSuccess {
-			return fmt.Errorf("cluster status is not %s", v2.ClusterSuccess)
+		if r.cluster.Status.Phase != v2.ClusterSuccess && !processor.Resume {
+			return fmt.Errorf("cluster status is not %s, use --resume to continue the last failed apply", v2.ClusterSuccess)
 		}
 	}
 
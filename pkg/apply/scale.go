@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/labring/sealos/pkg/apply/applydrivers"
+	"github.com/labring/sealos/pkg/apply/processor"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/exec"
@@ -51,7 +52,7 @@ func NewScaleApplierFromArgs(cmd *cobra.Command, scaleArgs *ScaleArgs) (applydri
 
 	curr := cluster.DeepCopy()
 
-	if scaleArgs.Cluster.Nodes == "" && scaleArgs.Cluster.Masters == "" {
+	if scaleArgs.Cluster.Nodes == "" && scaleArgs.Cluster.Masters == "" && !processor.Resume {
 		return nil, fmt.Errorf("the node or master parameter was not committed")
 	}
 	var err error
@@ -94,12 +95,49 @@ func getSSHFromCommand(cmd *cobra.Command) *v2.SSH {
 		ret.Port, _ = fs.GetUint16("port")
 		changed = true
 	}
+	if flagChanged(cmd, "ssh-proxy-jump") {
+		jumps, _ := fs.GetStringSlice("ssh-proxy-jump")
+		ret.ProxyJump = parseProxyJumps(jumps)
+		changed = true
+	}
+	if flagChanged(cmd, "ssh-cert") {
+		ret.Cert, _ = fs.GetString("ssh-cert")
+		changed = true
+	}
+	if flagChanged(cmd, "ssh-agent") {
+		ret.Agent, _ = fs.GetBool("ssh-agent")
+		changed = true
+	}
+	if flagChanged(cmd, "ssh-host-key-policy") {
+		policy, _ := fs.GetString("ssh-host-key-policy")
+		ret.HostKeyPolicy = v2.HostKeyPolicy(policy)
+		changed = true
+	}
 	if changed {
 		return ret
 	}
 	return nil
 }
 
+// parseProxyJumps parses the jump hosts in the form of [user@]host[:port],
+// the other credentials of the jump hosts are the same as the target host.
+func parseProxyJumps(jumps []string) []v2.ProxyJump {
+	var ret []v2.ProxyJump
+	for _, s := range jumps {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		pj := v2.ProxyJump{Host: s}
+		if i := strings.LastIndex(s, "@"); i >= 0 {
+			pj.Host = s[i+1:]
+			pj.SSH = &v2.SSH{User: s[:i]}
+		}
+		ret = append(ret, pj)
+	}
+	return ret
+}
+
 func flagChanged(cmd *cobra.Command, name string) bool {
 	if cmd != nil {
 		if fs := cmd.Flag(name); fs != nil && fs.Changed {
@@ -162,6 +200,10 @@ func verifyAndSetNodes(cmd *cobra.Command, cluster *v2.Cluster, scaleArgs *Scale
 			host, port := iputils.GetHostIPAndPortOrDefault(s, defaultPort)
 			addr := net.JoinHostPort(host, port)
 			if alreadyIn.Has(addr) {
+				// the host was recorded by the failed apply, which is joined again when resuming
+				if processor.Resume {
+					continue
+				}
 				return nil, fmt.Errorf("host %s already joined", addr)
 			}
 			if !slices.Contains(exclude, addr) {
@@ -172,14 +214,19 @@ func verifyAndSetNodes(cmd *cobra.Command, cluster *v2.Cluster, scaleArgs *Scale
 			global := cluster.Spec.SSH.DeepCopy()
 			ssh.OverSSHConfig(global, override)
 
-			sshClient := ssh.MustNewClient(global, true)
-			execer, err := exec.New(sshClient)
-			if err != nil {
-				return nil, err
+			roles := []string{role}
+			// the arch of hosts is not a part of plan, don't connect to them in dry run mode
+			if !scaleArgs.DryRunArgs.IsDryRun() {
+				sshClient := ssh.MustNewClient(global, true, ssh.WithKnownHostsFile(constants.KnownHostsFile(cluster.Name)))
+				execer, err := exec.New(sshClient)
+				if err != nil {
+					return nil, err
+				}
+				roles = append(roles, GetHostArch(execer, addrs[0]))
 			}
 			host := &v2.Host{
 				IPS:   addrs,
-				Roles: []string{role, GetHostArch(execer, addrs[0])},
+				Roles: roles,
 			}
 			if override != nil {
 				host.SSH = override
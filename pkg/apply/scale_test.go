@@ -17,6 +17,7 @@ limitations under the License.
 package apply
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -837,3 +838,14 @@ func TestJoin(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProxyJumps(t *testing.T) {
+	got := parseProxyJumps([]string{"admin@10.0.0.1", " ", "10.0.0.2:2222"})
+	want := []v2.ProxyJump{
+		{Host: "10.0.0.1", SSH: &v2.SSH{User: "admin"}},
+		{Host: "10.0.0.2:2222"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProxyJumps() = %+v, want %+v", got, want)
+	}
+}
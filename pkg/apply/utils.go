@@ -145,7 +145,8 @@ func CheckAndInitialize(cluster *v2.Cluster) error {
 	}
 
 	if len(cluster.Spec.Hosts) == 0 {
-		sshClient := ssh.MustNewClient(cluster.Spec.SSH.DeepCopy(), true)
+		sshClient := ssh.MustNewClient(cluster.Spec.SSH.DeepCopy(), true,
+			ssh.WithKnownHostsFile(constants.KnownHostsFile(cluster.Name)))
 		execer, err := exec.New(sshClient)
 		if err != nil {
 			return err
@@ -0,0 +1,262 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containers/common/libimage"
+
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/archive"
+	fileutil "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+	"github.com/labring/sealos/pkg/version"
+)
+
+const (
+	// Version is the layout version of backup archives, bump it when the layout changes.
+	Version          = "v1"
+	metadataFileName = "backup.yaml"
+	imagesDirName    = "images"
+)
+
+// Metadata describes a backup archive, it's stored beside the cluster dir in the archive.
+type Metadata struct {
+	Version       string          `json:"version"`
+	ClusterName   string          `json:"clusterName"`
+	SealosVersion string          `json:"sealosVersion"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Mounts        []v2.MountImage `json:"mounts,omitempty"`
+	// Images are the archives of the mounted images packed into the backup by
+	// image name, the paths are relative to the root of the backup.
+	Images map[string]string `json:"images,omitempty"`
+}
+
+// Create packs the local working dir of cluster, which contains the Clusterfile, pki, etc and
+// the runtime configs resolved by constants.PathResolver, and the mounted images into dst.
+// The contents of the images are saved into dst too if bdah is not nil.
+func Create(clusterName, dst string, bdah buildah.Interface) (*Metadata, error) {
+	pathResolver := constants.NewPathResolver(clusterName)
+	if !fileutil.IsExist(constants.Clusterfile(clusterName)) {
+		return nil, fmt.Errorf("cluster %s not found, Clusterfile %s does not exist",
+			clusterName, constants.Clusterfile(clusterName))
+	}
+	cluster, err := clusterfile.GetClusterFromFile(constants.Clusterfile(clusterName))
+	if err != nil {
+		return nil, err
+	}
+	md := &Metadata{
+		Version:       Version,
+		ClusterName:   clusterName,
+		SealosVersion: version.Get().GitVersion,
+		CreatedAt:     time.Now(),
+		Mounts:        cluster.Status.Mounts,
+	}
+
+	// the metadata must not be put into the cluster dir, otherwise it's packed twice
+	tmpDir, err := os.MkdirTemp("", "sealos-backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	paths := []string{pathResolver.RunRoot()}
+	if bdah != nil {
+		if md.Images, err = saveImages(bdah, md.Mounts, filepath.Join(tmpDir, imagesDirName)); err != nil {
+			return nil, err
+		}
+		paths = append(paths, filepath.Join(tmpDir, imagesDirName))
+	}
+	mdFile := filepath.Join(tmpDir, metadataFileName)
+	if err = yaml.MarshalFile(mdFile, md); err != nil {
+		return nil, err
+	}
+	paths = append(paths, mdFile)
+
+	dst, err = filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(pathResolver.RunRoot(), dst); err == nil && !strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("backup archive %s cannot be put into the working dir of cluster", dst)
+	}
+	// write to a temporary file first so that dst is never half written
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	tmpFile := dst + ".tmp"
+	if err = writeArchive(tmpFile, paths...); err != nil {
+		_ = os.Remove(tmpFile)
+		return nil, err
+	}
+	return md, os.Rename(tmpFile, dst)
+}
+
+func saveImages(bdah buildah.Interface, mounts []v2.MountImage, dir string) (map[string]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	images := make(map[string]string, len(mounts))
+	for i, mount := range mounts {
+		if _, ok := images[mount.ImageName]; ok {
+			continue
+		}
+		name := fmt.Sprintf("%d.tar", i)
+		logger.Info("save image %s into backup", mount.ImageName)
+		if err := bdah.Runtime().Save(context.Background(), []string{mount.ImageName}, buildah.OCIArchive,
+			filepath.Join(dir, name), &libimage.SaveOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to save image %s: %v", mount.ImageName, err)
+		}
+		images[mount.ImageName] = imagesDirName + "/" + name
+	}
+	return images, nil
+}
+
+func writeArchive(dst string, paths ...string) error {
+	rc, err := archive.NewArchive(true, true).TarOrGzip(paths...)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = io.Copy(f, rc); err != nil {
+		return fmt.Errorf("failed to write backup archive %s: %v", dst, err)
+	}
+	return f.Sync()
+}
+
+// Extract re-creates the working dir of cluster from the archive src, an existing working dir
+// is only replaced when force is true. If bdah is not nil, the mounted images are made
+// available locally before anything is replaced, so that Remount never pulls them.
+func Extract(src, clusterName string, force bool, bdah buildah.Interface) (*Metadata, error) {
+	if err := os.MkdirAll(constants.WorkDir(), 0755); err != nil {
+		return nil, err
+	}
+	// extract into the work dir so that the cluster dir can be renamed to the target
+	tmpDir, err := os.MkdirTemp(constants.WorkDir(), ".backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	f, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err = archive.NewArchive(true, true).UnTarOrGzip(f, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to extract backup archive %s: %v", src, err)
+	}
+
+	md := &Metadata{}
+	if err = yaml.UnmarshalFile(filepath.Join(tmpDir, metadataFileName), md); err != nil {
+		return nil, fmt.Errorf("invalid backup archive %s: %v", src, err)
+	}
+	if md.Version != Version {
+		return nil, fmt.Errorf("unsupported backup version %s, expected %s", md.Version, Version)
+	}
+	if md.ClusterName != clusterName {
+		return nil, fmt.Errorf("backup archive %s belongs to cluster %s, not %s", src, md.ClusterName, clusterName)
+	}
+
+	target := constants.ClusterDir(clusterName)
+	if fileutil.IsExist(target) && !force {
+		return nil, fmt.Errorf("working dir %s of cluster %s already exists", target, clusterName)
+	}
+	if bdah != nil {
+		if err = prepareImages(bdah, md, tmpDir); err != nil {
+			return nil, err
+		}
+	}
+	if fileutil.IsExist(target) {
+		logger.Warn("working dir %s of cluster %s will be replaced", target, clusterName)
+		if err = os.RemoveAll(target); err != nil {
+			return nil, err
+		}
+	}
+	if err = os.Rename(filepath.Join(tmpDir, clusterName), target); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// prepareImages loads the images packed in the backup, and pulls the others if they are
+// not present, which fails on offline hosts unless they are loaded in advance.
+func prepareImages(bdah buildah.Interface, md *Metadata, dir string) error {
+	for _, mount := range md.Mounts {
+		if file, ok := md.Images[mount.ImageName]; ok {
+			logger.Info("load image %s from backup", mount.ImageName)
+			if _, err := bdah.Load(filepath.Join(dir, filepath.FromSlash(file)), buildah.OCIArchive); err != nil {
+				return fmt.Errorf("failed to load image %s from backup: %v", mount.ImageName, err)
+			}
+			continue
+		}
+		if _, err := bdah.InspectImage(mount.ImageName); err == nil {
+			continue
+		}
+		if err := bdah.Pull([]string{mount.ImageName},
+			buildah.WithPullPolicyOption(buildah.PullIfMissing.String())); err != nil {
+			return fmt.Errorf("image %s is not in the backup and cannot be pulled: %v, "+
+				"load it by sealos load first, or create the backup with --with-images", mount.ImageName, err)
+		}
+	}
+	return nil
+}
+
+// Remount re-creates the working containers of the images recorded in the backup, which
+// are prepared by Extract, the Clusterfile is updated with the new mount points.
+func Remount(md *Metadata, bdah buildah.Interface) error {
+	clusterPath := constants.Clusterfile(md.ClusterName)
+	cf := clusterfile.NewClusterFile(clusterPath)
+	if err := cf.Process(); err != nil {
+		return err
+	}
+	cluster := cf.GetCluster()
+	mounts := make([]v2.MountImage, 0, len(md.Mounts))
+	for _, mount := range md.Mounts {
+		logger.Info("remount image %s", mount.ImageName)
+		info, err := bdah.Create(mount.Name, mount.ImageName)
+		if err != nil {
+			return err
+		}
+		mount.Name = info.Container
+		mount.MountPoint = info.MountPoint
+		mounts = append(mounts, mount)
+	}
+	cluster.Status.Mounts = mounts
+
+	obj := []interface{}{cluster}
+	for _, cfg := range cf.GetConfigs() {
+		obj = append(obj, cfg)
+	}
+	return yaml.MarshalFile(clusterPath, obj...)
+}
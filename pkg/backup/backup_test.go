@@ -0,0 +1,87 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+func TestCreateAndExtract(t *testing.T) {
+	origin := constants.DefaultRuntimeRootDir
+	defer func() {
+		constants.DefaultRuntimeRootDir = origin
+	}()
+	constants.DefaultRuntimeRootDir = t.TempDir()
+
+	const clusterName = "default"
+	cluster := &v2.Cluster{}
+	cluster.Name = clusterName
+	cluster.Spec.Image = []string{"labring/kubernetes:v1.25.0"}
+	cluster.Status.Mounts = []v2.MountImage{{
+		Name:       "default-xxx",
+		Type:       v2.RootfsImage,
+		ImageName:  "labring/kubernetes:v1.25.0",
+		MountPoint: "/var/lib/containers/storage/overlay/xxx/merged",
+	}}
+	if err := yaml.MarshalFile(constants.Clusterfile(clusterName), cluster); err != nil {
+		t.Fatal(err)
+	}
+	pathResolver := constants.NewPathResolver(clusterName)
+	caFile := filepath.Join(pathResolver.PkiPath(), "ca.crt")
+	if err := os.MkdirAll(pathResolver.PkiPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(caFile, []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if _, err := Create(clusterName, dst, nil); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := Create(clusterName, filepath.Join(pathResolver.RunRoot(), "backup.tar.gz"), nil); err == nil {
+		t.Error("Create() into the working dir expected error")
+	}
+
+	if _, err := Extract(dst, clusterName, false, nil); err == nil {
+		t.Error("Extract() onto an existing working dir without force expected error")
+	}
+	if _, err := Extract(dst, "other", true, nil); err == nil {
+		t.Error("Extract() with another cluster name expected error")
+	}
+	if err := os.RemoveAll(pathResolver.RunRoot()); err != nil {
+		t.Fatal(err)
+	}
+	md, err := Extract(dst, clusterName, false, nil)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if md.Version != Version || md.ClusterName != clusterName {
+		t.Errorf("Extract() got metadata %+v", md)
+	}
+	if !reflect.DeepEqual(md.Mounts, cluster.Status.Mounts) {
+		t.Errorf("Extract() got mounts %+v, want %+v", md.Mounts, cluster.Status.Mounts)
+	}
+	if data, err := os.ReadFile(caFile); err != nil || string(data) != "ca" {
+		t.Errorf("Extract() got %s = %q, %v", caFile, data, err)
+	}
+}
@@ -15,13 +15,11 @@
 package bootstrap
 
 import (
-	"context"
 	"fmt"
 
-	"golang.org/x/sync/errgroup"
-
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 type Phase string
@@ -66,17 +64,37 @@ func (bs *realBootstrap) Apply(hosts ...string) error {
 	appliers = append(appliers, bs.initializers...)
 	appliers = append(appliers, bs.postflights...)
 	logger.Debug("apply %+v on hosts %+v", appliers, hosts)
+	// the hosts failed on an applier are excluded from the following ones,
+	// so that the others are still bootstrapped completely.
+	failed := parallel.HostErrors{}
 	for i := range appliers {
 		applier := appliers[i]
-		if err := runParallel(hosts, func(host string) error {
+		err := runParallel(hosts, func(host string) error {
 			if !applier.Filter(bs.ctx, host) {
 				return nil
 			}
 			logger.Debug("apply %s on host %s", applier, host)
 			return applier.Apply(bs.ctx, host)
-		}); err != nil {
+		})
+		if err == nil {
+			continue
+		}
+		he, ok := parallel.AsHostErrors(err)
+		if !ok {
 			return err
 		}
+		remains := make([]string, 0, len(hosts))
+		for _, host := range hosts {
+			if he[host] != nil {
+				failed[host] = fmt.Errorf("%s: %w", applier, he[host])
+			} else {
+				remains = append(remains, host)
+			}
+		}
+		hosts = remains
+	}
+	if len(failed) > 0 {
+		return failed
 	}
 	return nil
 }
@@ -117,14 +135,7 @@ func (bs *realBootstrap) Delete(hosts ...string) error {
 }
 
 func runParallel(hosts []string, fn func(string) error) error {
-	eg, _ := errgroup.WithContext(context.Background())
-	for i := range hosts {
-		host := hosts[i]
-		eg.Go(func() error {
-			return fn(host)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(hosts, fn)
 }
 
 type defaultChecker struct{ common }
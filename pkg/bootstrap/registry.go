@@ -32,8 +32,7 @@ type registryApplier struct {
 
 func (*registryApplier) String() string { return "registry_applier" }
 func (*registryApplier) Filter(ctx Context, host string) bool {
-	registries := sets.NewString(ctx.GetCluster().GetRegistryIPAndPortList()...)
-	return registries.Has(host)
+	return isRegistryReplica(ctx, host)
 }
 
 func (a *registryApplier) Apply(ctx Context, host string) error {
@@ -62,16 +61,42 @@ type registryHostApplier struct{ common }
 func (*registryHostApplier) String() string { return "registry_host_applier" }
 
 func (*registryHostApplier) Undo(ctx Context, host string) error {
-	rc := helpers.GetRegistryInfo(ctx.GetExecer(), ctx.GetPathResolver().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
+	cluster := ctx.GetCluster()
+	rc := helpers.GetRegistryInfo(ctx.GetExecer(), ctx.GetPathResolver().RootFSPath(), cluster.GetRegistryIPAndPort())
+	if cluster.IsRegistryHA() && !isRegistryReplica(ctx, host) {
+		if err := ctx.GetRemoter().IPVSClean(host, helpers.ReplicaVIPAddress(cluster, rc), helpers.ReplicaLvscareOptions(false)...); err != nil {
+			logger.Warn("failed to clean registry ipvs rules on %s: %v", host, err)
+		}
+	}
 	return ctx.GetRemoter().HostsDelete(host, rc.Domain)
 }
 
 func (a *registryHostApplier) Apply(ctx Context, host string) error {
-	rc := helpers.GetRegistryInfo(ctx.GetExecer(), ctx.GetPathResolver().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
+	cluster := ctx.GetCluster()
+	rc := helpers.GetRegistryInfo(ctx.GetExecer(), ctx.GetPathResolver().RootFSPath(), cluster.GetRegistryIPAndPort())
 
-	if err := ctx.GetRemoter().HostsAdd(host, iputils.GetHostIP(rc.IP), rc.Domain); err != nil {
+	ip := iputils.GetHostIP(rc.IP)
+	if cluster.IsRegistryHA() {
+		if isRegistryReplica(ctx, host) {
+			// a replica pulls from itself
+			ip = iputils.GetHostIP(host)
+		} else {
+			// the rules are kept by the lvscare static pod synced with the
+			// ones of apiserver once kubelet is running
+			if err := ctx.GetRemoter().IPVSOnce(host, helpers.ReplicaVIPAddress(cluster, rc),
+				helpers.ReplicaAddresses(cluster, rc), helpers.ReplicaLvscareOptionsOn(ctx.GetRemoter(), host)...); err != nil {
+				return fmt.Errorf("failed to create registry ipvs rules: %v", err)
+			}
+			ip = cluster.GetRegistryVIP()
+		}
+	}
+	if err := ctx.GetRemoter().HostsAdd(host, ip, rc.Domain); err != nil {
 		return fmt.Errorf("failed to add hosts: %v", err)
 	}
 
 	return nil
 }
+
+func isRegistryReplica(ctx Context, host string) bool {
+	return sets.NewString(ctx.GetCluster().GetRegistryIPAndPortList()...).Has(host)
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/labring/sealos/pkg/buildah/internal/util"
+	"github.com/labring/sealos/pkg/utils/events"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -197,7 +198,9 @@ func doPull(c *cobra.Command, store storage.Store, systemContext *types.SystemCo
 	}
 	var ids []string
 	for _, imageName := range imageNames {
+		done := events.Start(events.Event{Type: events.TypeImage, Image: imageName})
 		id, err := buildah.Pull(getContext(), imageName, options)
+		done(err)
 		if err != nil {
 			return nil, err
 		}
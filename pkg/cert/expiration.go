@@ -0,0 +1,151 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// Expiration is the validity of a certificate or of the client certificate embedded in a kubeconfig.
+type Expiration struct {
+	Name     string    `json:"name"`
+	CAName   string    `json:"caName,omitempty"`
+	IsCA     bool      `json:"isCA,omitempty"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// HostExpiration is the expirations of the certificates on a host.
+type HostExpiration struct {
+	Host         string       `json:"host"`
+	Certificates []Expiration `json:"certificates"`
+}
+
+// ResidualTime returns the time left before the certificate expires, negative once expired.
+func (e Expiration) ResidualTime() time.Duration {
+	return time.Until(e.NotAfter)
+}
+
+// ListExpiration loads every certificate defined by CaList and List from the given directories.
+func ListExpiration(certPath, certEtcdPath string) ([]Expiration, error) {
+	var ret []Expiration
+	for _, ca := range CaList(certPath, certEtcdPath) {
+		e, err := LoadCertExpiration(pathForCert(ca.Path, ca.BaseName))
+		if err != nil {
+			return nil, err
+		}
+		e.Name = displayName(ca)
+		e.IsCA = true
+		ret = append(ret, *e)
+	}
+	for _, c := range List(certPath, certEtcdPath) {
+		e, err := LoadCertExpiration(pathForCert(c.Path, c.BaseName))
+		if err != nil {
+			return nil, err
+		}
+		e.Name = displayName(c)
+		e.CAName = c.CAName
+		ret = append(ret, *e)
+	}
+	return ret, nil
+}
+
+// LoadCertExpiration reads the first certificate of a PEM encoded file.
+func LoadCertExpiration(certFile string) (*Expiration, error) {
+	certs, err := certutil.CertsFromFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cert %s: %v", certFile, err)
+	}
+	return newExpiration(path.Base(certFile), certs[0]), nil
+}
+
+// LoadKubeConfigExpiration reads the client certificate of the current context of a kubeconfig file.
+func LoadKubeConfigExpiration(kubeConfigFile string) (*Expiration, error) {
+	config, err := clientcmd.LoadFromFile(kubeConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig %s: %v", kubeConfigFile, err)
+	}
+	ctx, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("failed to find current context in kubeconfig %s", kubeConfigFile)
+	}
+	authInfo, ok := config.AuthInfos[ctx.AuthInfo]
+	if !ok || len(authInfo.ClientCertificateData) == 0 {
+		return nil, fmt.Errorf("no embedded client certificate found in kubeconfig %s", kubeConfigFile)
+	}
+	certs, err := certutil.ParseCertsPEM(authInfo.ClientCertificateData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse client certificate of kubeconfig %s: %v", kubeConfigFile, err)
+	}
+	return newExpiration(path.Base(kubeConfigFile), certs[0]), nil
+}
+
+// PrintExpiration writes the expirations as a table, the ones closest to expiry first.
+func PrintExpiration(w io.Writer, list []Expiration) error {
+	sorted := make([]Expiration, len(list))
+	copy(sorted, list)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].NotAfter.Before(sorted[j].NotAfter)
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CERTIFICATE\tEXPIRES\tRESIDUAL TIME\tCERTIFICATE AUTHORITY")
+	for _, e := range sorted {
+		authority := e.CAName
+		if e.IsCA {
+			authority = "<self>"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, e.NotAfter.Format(time.RFC822), formatResidualTime(e.ResidualTime()), authority)
+	}
+	return tw.Flush()
+}
+
+func newExpiration(name string, cert *x509.Certificate) *Expiration {
+	return &Expiration{
+		Name:     name,
+		CAName:   cert.Issuer.CommonName,
+		IsCA:     cert.IsCA,
+		NotAfter: cert.NotAfter,
+	}
+}
+
+// etcd certs share the same base names with the kubernetes ones, so prefix them like kubeadm does.
+func displayName(cfg Config) string {
+	if cfg.DefaultPath == kubeDefaultCertEtcdPath {
+		return "etcd-" + cfg.BaseName
+	}
+	return cfg.BaseName
+}
+
+func formatResidualTime(d time.Duration) string {
+	if d <= 0 {
+		return "<expired>"
+	}
+	days := int(d.Hours() / 24)
+	if days >= 365 {
+		return fmt.Sprintf("%dy", days/365)
+	}
+	if days > 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
@@ -0,0 +1,152 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestListExpiration(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "pki")
+	etcdPath := filepath.Join(certPath, "etcd")
+	if err := GenerateCert(certPath, etcdPath, []string{"sealos.io"}, "172.27.139.11", "master1", "10.96.0.0/12", "cluster.local"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := ListExpiration(certPath, etcdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(CaList(certPath, etcdPath)) + len(List(certPath, etcdPath)); len(list) != want {
+		t.Fatalf("ListExpiration() returned %d certs, want %d", len(list), want)
+	}
+	for _, e := range list {
+		if e.ResidualTime() <= 0 {
+			t.Errorf("cert %s is already expired", e.Name)
+		}
+		if !e.IsCA && e.CAName == "" {
+			t.Errorf("cert %s has no certificate authority", e.Name)
+		}
+	}
+
+	if err = CreateJoinControlPlaneKubeConfigFiles(dir, Config{Path: certPath, BaseName: "ca"}, "master1", "https://apiserver.cluster.local:6443", "kubernetes"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := LoadKubeConfigExpiration(filepath.Join(dir, "admin.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.CAName != "kubernetes" {
+		t.Errorf("LoadKubeConfigExpiration() CAName = %s, want kubernetes", e.CAName)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = PrintExpiration(buf, append(list, *e)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"apiserver", "etcd-server", "front-proxy-ca", "admin.conf"} {
+		if !strings.Contains(buf.String(), name) {
+			t.Errorf("PrintExpiration() output does not contain %s", name)
+		}
+	}
+}
+
+func TestLoadExpirationErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "broken.crt")
+	noCert := filepath.Join(dir, "token.conf")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: kubernetes
+  cluster:
+    server: https://apiserver.cluster.local:6443
+users:
+- name: admin
+  user:
+    token: abc
+contexts:
+- name: admin@kubernetes
+  context:
+    cluster: kubernetes
+    user: admin
+current-context: admin@kubernetes
+`
+	if err := os.WriteFile(noCert, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		load func() (*Expiration, error)
+	}{
+		{name: "missing cert", load: func() (*Expiration, error) { return LoadCertExpiration(filepath.Join(dir, "missing.crt")) }},
+		{name: "not PEM cert", load: func() (*Expiration, error) { return LoadCertExpiration(notPEM) }},
+		{name: "missing kubeconfig", load: func() (*Expiration, error) { return LoadKubeConfigExpiration(filepath.Join(dir, "missing.conf")) }},
+		{name: "kubeconfig without client cert", load: func() (*Expiration, error) { return LoadKubeConfigExpiration(noCert) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if e, err := tt.load(); err == nil {
+				t.Errorf("load expiration = %+v, want error", e)
+			}
+		})
+	}
+}
+
+func TestPrintExpiration(t *testing.T) {
+	now := time.Now()
+	list := []Expiration{
+		{Name: "ca", IsCA: true, CAName: "kubernetes", NotAfter: now.Add(10 * 365 * 24 * time.Hour)},
+		{Name: "apiserver", CAName: "kubernetes", NotAfter: now.Add(200*24*time.Hour + time.Hour)},
+		{Name: "admin.conf", CAName: "kubernetes", NotAfter: now.Add(5*time.Hour + time.Minute)},
+		{Name: "etcd-server", CAName: "etcd-ca", NotAfter: now.Add(-time.Hour)},
+	}
+	buf := &bytes.Buffer{}
+	if err := PrintExpiration(buf, list); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := [][]string{
+		{"CERTIFICATE", "EXPIRES", "RESIDUAL", "TIME", "CERTIFICATE", "AUTHORITY"},
+		{"etcd-server", "<expired>", "etcd-ca"},
+		{"admin.conf", "5h", "kubernetes"},
+		{"apiserver", "200d", "kubernetes"},
+		{"ca", "9y", "<self>"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("PrintExpiration() printed %d lines, want %d:\n%s", len(lines), len(want), buf)
+	}
+	for i, fields := range want {
+		got := strings.Fields(lines[i])
+		for _, f := range fields {
+			if !slices.Contains(got, f) {
+				t.Errorf("line %d = %q, want %s in it", i, lines[i], f)
+			}
+		}
+		if i > 0 && got[0] != fields[0] {
+			t.Errorf("line %d is cert %s, want %s", i, got[0], fields[0])
+		}
+	}
+}
@@ -15,9 +15,13 @@
 package checker
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/events"
 )
 
 const (
@@ -26,15 +30,60 @@ const (
 )
 
 // Interface Define checkers when pre or post install, like checker node status, checker pod status...
+// The findings are returned as results, the error is returned if the checker
+// can't run at all.
 type Interface interface {
-	Check(cluster *v2.Cluster, phase string) error
+	Check(cluster *v2.Cluster, phase string) ([]Result, error)
 }
 
-func RunCheckList(list []Interface, cluster *v2.Cluster, phase string) error {
+// RunCheckList runs all the checkers and collects their results, a checker
+// failed to run is reported as a failed result. The error lists the checkers
+// that failed.
+func RunCheckList(list []Interface, cluster *v2.Cluster, phase string) ([]Result, error) {
+	var (
+		all    []Result
+		failed []string
+	)
 	for _, l := range list {
-		if err := l.Check(cluster, phase); err != nil {
-			return fmt.Errorf("failed to run checker: %v", err)
+		name := checkerName(l)
+		done := events.Start(events.Event{Type: events.TypeChecker, Cluster: cluster.Name, Checker: name, Phase: phase})
+		results, err := l.Check(cluster, phase)
+		if err == nil && WorstStatus(results) == StatusFailed {
+			err = failedError(results)
 		}
+		if err != nil && WorstStatus(results) != StatusFailed {
+			results = append(results, Result{Target: cluster.Name, Status: StatusFailed, Message: err.Error()})
+		}
+		done(err)
+		for i := range results {
+			results[i].Checker = name
+		}
+		all = append(all, results...)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return all, fmt.Errorf("failed to run checker: %s", strings.Join(failed, "; "))
+	}
+	return all, nil
+}
+
+func failedError(results []Result) error {
+	var msgs []string
+	for _, r := range results {
+		if r.Status == StatusFailed {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", r.Target, r.Message))
+		}
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// checkerName returns the type name of checker, e.g. NodeChecker.
+func checkerName(c Interface) string {
+	t := reflect.TypeOf(c)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
-	return nil
+	return t.Name()
 }
@@ -0,0 +1,70 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+type fakeChecker struct {
+	results []Result
+	err     error
+}
+
+func (f *fakeChecker) Check(_ *v2.Cluster, _ string) ([]Result, error) {
+	return f.results, f.err
+}
+
+func TestRunCheckList(t *testing.T) {
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	list := []Interface{
+		&fakeChecker{err: errors.New("no kubeconfig")},
+		&fakeChecker{results: []Result{
+			newResult("192.168.0.2", StatusOK, "ready"),
+			newResult("192.168.0.3", StatusFailed, "not ready"),
+		}},
+		&fakeChecker{results: []Result{newResult("kube-system", StatusWarning, "no endpoints")}},
+	}
+	results, err := RunCheckList(list, cluster, PhasePost)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"no kubeconfig", "192.168.0.3: not ready"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should contain %q", err, want)
+		}
+	}
+	// all the checkers run, the error of the first one is a failed result
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4: %v", len(results), results)
+	}
+	if r := results[0]; r.Checker != "fakeChecker" || r.Target != "default" || r.Status != StatusFailed {
+		t.Errorf("unexpected result of checker error: %+v", r)
+	}
+	if got := WorstStatus(results); got != StatusFailed {
+		t.Errorf("WorstStatus() = %s, want %s", got, StatusFailed)
+	}
+	if got := WorstStatus(results[3:]); got != StatusWarning {
+		t.Errorf("WorstStatus() = %s, want %s", got, StatusWarning)
+	}
+	if got := WorstStatus(nil); got != StatusOK {
+		t.Errorf("WorstStatus() = %s, want %s", got, StatusOK)
+	}
+}
@@ -18,9 +18,12 @@ package checker
 import (
 	"context"
 	"errors"
-	"os"
+	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
@@ -42,58 +45,79 @@ type ClusterStatus struct {
 	KubeletErr            string
 }
 
-func (n *ClusterChecker) Check(cluster *v2.Cluster, phase string) error {
+func (n *ClusterChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 
 	// checker if all the node is ready
 	data := constants.NewPathResolver(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	ke := kubernetes.NewKubeExpansion(c.Kubernetes())
 	nodes, err := c.Kubernetes().CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	healthyClient := kubernetes.NewKubeHealthy(c.Kubernetes(), 30*time.Second)
 	var NodeList []ClusterStatus
+	var results []Result
 	ctx := context.Background()
+	masters := cluster.GetMasterIPList()
 	for _, node := range nodes.Items {
 		ip, _ := getNodeStatus(node)
 		cStatus := ClusterStatus{
 			IP:   ip,
 			Node: node.Name,
 		}
-		apiPod, err := ke.FetchStaticPod(ctx, node.Name, kubernetes.KubeAPIServer)
-		if err != nil {
-			return err
+		isMaster := slices.Contains(masters, ip)
+		var unhealthy []string
+		fetch := func(component string) (string, error) {
+			pod, err := ke.FetchStaticPod(ctx, node.Name, component)
+			if err != nil {
+				// workers have no control plane
+				if kerrors.IsNotFound(err) && !isMaster {
+					return "", nil
+				}
+				if kerrors.IsNotFound(err) {
+					unhealthy = append(unhealthy, component+" not found")
+					return "NotFound", nil
+				}
+				return "", err
+			}
+			status := healthyClient.ForHealthyPod(pod)
+			if status != string(corev1.PodRunning) {
+				unhealthy = append(unhealthy, component+" "+status)
+			}
+			return status, nil
 		}
-		cStatus.KubeAPIServer = healthyClient.ForHealthyPod(apiPod)
-
-		controllerPod, err := ke.FetchStaticPod(ctx, node.Name, kubernetes.KubeControllerManager)
-		if err != nil {
-			return err
+		if cStatus.KubeAPIServer, err = fetch(kubernetes.KubeAPIServer); err != nil {
+			return nil, err
 		}
-		cStatus.KubeControllerManager = healthyClient.ForHealthyPod(controllerPod)
-
-		schedulerPod, err := ke.FetchStaticPod(ctx, node.Name, kubernetes.KubeScheduler)
-		if err != nil {
-			return err
+		if cStatus.KubeControllerManager, err = fetch(kubernetes.KubeControllerManager); err != nil {
+			return nil, err
+		}
+		if cStatus.KubeScheduler, err = fetch(kubernetes.KubeScheduler); err != nil {
+			return nil, err
 		}
-		cStatus.KubeScheduler = healthyClient.ForHealthyPod(schedulerPod)
 
 		if err = healthyClient.ForHealthyKubelet(5*time.Second, ip); err != nil {
 			cStatus.KubeletErr = err.Error()
+			unhealthy = append(unhealthy, "kubelet "+err.Error())
 		} else {
 			cStatus.KubeletErr = Nil
 		}
 		NodeList = append(NodeList, cStatus)
+		if len(unhealthy) > 0 {
+			results = append(results, newResult(ip, StatusFailed, "%s", strings.Join(unhealthy, "; ")))
+		} else {
+			results = append(results, newResult(ip, StatusOK, "node %s is healthy", node.Name))
+		}
 	}
 
-	return n.Output(NodeList)
+	return results, n.Output(NodeList)
 }
 
 func (n *ClusterChecker) Output(clusterStatus []ClusterStatus) error {
@@ -116,7 +140,7 @@ Cluster Status
 		}
 		return errors.New("convert cluster template failed")
 	}
-	return tpl.Execute(os.Stdout, map[string][]ClusterStatus{"ClusterStatusList": clusterStatus})
+	return tpl.Execute(Output, map[string][]ClusterStatus{"ClusterStatusList": clusterStatus})
 }
 
 func NewClusterChecker() Interface {
@@ -19,7 +19,6 @@ package checker
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/labring/image-cri-shim/pkg/types"
 
@@ -39,9 +38,9 @@ type CRIShimStatus struct {
 	Error     string
 }
 
-func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) error {
+func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	status := &CRIShimStatus{}
 	defer func() {
@@ -74,8 +73,11 @@ func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) error {
 		}
 	}
 
+	if status.Error != "" {
+		return []Result{newResult(types.DefaultImageCRIShimConfig, StatusFailed, "%s", status.Error)}, nil
+	}
 	status.Error = Nil
-	return nil
+	return []Result{newResult(types.DefaultImageCRIShimConfig, StatusOK, "config loaded")}, nil
 }
 
 func (n *CRIShimChecker) Output(status *CRIShimStatus) error {
@@ -100,7 +102,7 @@ CRIShim Service Status
 		}
 		return errors.New("convert cri-shim template failed")
 	}
-	return tpl.Execute(os.Stdout, status)
+	return tpl.Execute(Output, status)
 }
 
 func NewCRIShimChecker() Interface {
@@ -20,7 +20,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -58,9 +57,9 @@ type CRICtlStatus struct {
 	Error               string
 }
 
-func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
+func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	status := &CRICtlStatus{}
 	defer func() {
@@ -83,7 +82,7 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	crictlPath, err := execer.LookPath("crictl")
 	if err != nil {
 		status.Error = fmt.Errorf("error looking for path of crictl: %w", err).Error()
-		return nil
+		return []Result{newResult("crictl", StatusFailed, "%s", status.Error)}, nil
 	}
 
 	imageList, err := n.getCRICtlImageList(crictlPath)
@@ -110,7 +109,7 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	sshCtx := ssh.NewCacheClientFromCluster(cluster, false)
 	sshCtx, err = exec.New(sshCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	root := constants.NewPathResolver(cluster.Name).RootFSPath()
 	regInfo := helpers.GetRegistryInfo(sshCtx, root, cluster.GetRegistryIPAndPort())
@@ -126,8 +125,11 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 		status.Error = fmt.Errorf("pull shim image error: %w", err).Error()
 	}
 	status.ImageShimPullStatus = shimStatus
+	if status.Error != "" {
+		return []Result{newResult("crictl", StatusFailed, "%s", status.Error)}, nil
+	}
 	status.Error = Nil
-	return nil
+	return []Result{newResult("crictl", StatusOK, "%d images, %d containers", len(status.ImageList), len(status.ContainerList))}, nil
 }
 
 func (n *CRICtlChecker) Output(status *CRICtlStatus) error {
@@ -157,7 +159,7 @@ CRI Status
 		}
 		return errors.New("convert crictl template failed")
 	}
-	return tpl.Execute(os.Stdout, status)
+	return tpl.Execute(Output, status)
 }
 
 func NewCRICtlChecker() Interface {
@@ -16,7 +16,6 @@ package checker
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
 	"time"
 
@@ -31,10 +30,12 @@ type HostChecker struct {
 	IPs []string
 }
 
-func (a HostChecker) Check(cluster *v2.Cluster, _ string) error {
+func (a HostChecker) Check(cluster *v2.Cluster, _ string) ([]Result, error) {
 	var ipList []string
 	if len(cluster.GetMasterIPList())&1 == 0 {
-		return confirmNonOddMasters()
+		if err := confirmNonOddMasters(); err != nil {
+			return nil, err
+		}
 	}
 	if len(a.IPs) != 0 {
 		ipList = a.IPs
@@ -42,53 +43,61 @@ func (a HostChecker) Check(cluster *v2.Cluster, _ string) error {
 	sshClient := ssh.NewCacheClientFromCluster(cluster, false)
 	execer, err := exec.New(sshClient)
 	if err != nil {
-		return err
-	}
-	if err := checkHostnameUnique(execer, ipList); err != nil {
-		return err
+		return nil, err
 	}
-	return checkTimeSync(execer, ipList)
+	results := checkHostnameUnique(execer, ipList)
+	return append(results, checkTimeSync(execer, ipList)...), nil
 }
 
 func NewIPsHostChecker(ips []string) Interface {
 	return &HostChecker{IPs: ips}
 }
 
-func checkHostnameUnique(s exec.Interface, ipList []string) error {
+func checkHostnameUnique(s exec.Interface, ipList []string) []Result {
 	logger.Info("checker:hostname %v", ipList)
-	hostnameList := map[string]bool{}
+	var results []Result
+	hostnameList := map[string]string{}
 	for _, ip := range ipList {
 		hostname, err := s.CmdToString(ip, "hostname", "")
 		if err != nil {
-			return fmt.Errorf("failed to get host %s hostname, %v", ip, err)
+			results = append(results, newResult(ip, StatusFailed, "failed to get host %s hostname, %v", ip, err))
+			continue
 		}
 
-		if hostnameList[hostname] {
-			return fmt.Errorf("hostname cannot be repeated, please set different hostname")
+		if other, ok := hostnameList[hostname]; ok {
+			results = append(results, newResult(ip, StatusFailed,
+				"hostname %s is the same as %s, hostname cannot be repeated, please set different hostname", hostname, other))
+			continue
 		}
-		hostnameList[hostname] = true
+		hostnameList[hostname] = ip
+		results = append(results, newResult(ip, StatusOK, "hostname %s", hostname))
 	}
-	return nil
+	return results
 }
 
 // Check whether the node time is synchronized
-func checkTimeSync(s exec.Interface, ipList []string) error {
+func checkTimeSync(s exec.Interface, ipList []string) []Result {
 	logger.Info("checker:timeSync %v", ipList)
+	var results []Result
 	for _, ip := range ipList {
 		timestamp, err := s.CmdToString(ip, "date +%s", "")
 		if err != nil {
-			return fmt.Errorf("failed to get %s timestamp, %v", ip, err)
+			results = append(results, newResult(ip, StatusFailed, "failed to get %s timestamp, %v", ip, err))
+			continue
 		}
 		ts, err := strconv.Atoi(timestamp)
 		if err != nil {
-			return fmt.Errorf("failed to reverse timestamp %s, %v", timestamp, err)
+			results = append(results, newResult(ip, StatusFailed, "failed to reverse timestamp %s, %v", timestamp, err))
+			continue
 		}
 		timeDiff := time.Since(time.Unix(int64(ts), 0)).Minutes()
 		if timeDiff < -1 || timeDiff > 1 {
-			return fmt.Errorf("the time of %s node is not synchronized", ip)
+			results = append(results, newResult(ip, StatusFailed, "the time of %s node is not synchronized", ip))
+			continue
 		}
+		results = append(results, newResult(ip, StatusOK, "time is synchronized"))
 	}
-	return nil
+	return results
 }
 
 func confirmNonOddMasters() error {
@@ -19,7 +19,6 @@ package checker
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/labring/sealos/pkg/template"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
@@ -40,9 +39,9 @@ type InitSystemStatus struct {
 	ServiceList []systemStatus
 }
 
-func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) error {
+func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	status := &InitSystemStatus{}
 
@@ -55,20 +54,26 @@ func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) error {
 	initsystemvar, err := initsystem.GetInitSystem()
 	if err != nil {
 		status.Error = fmt.Errorf("get initsystem error: %w", err).Error()
-		return nil
+		return []Result{newResult("initsystem", StatusFailed, "%s", status.Error)}, nil
 	}
 
 	serviceNames := []string{"kubelet", "containerd", "cri-docker", "docker", "registry", "image-cri-shim"}
 	status.ServiceList = make([]systemStatus, 0)
+	var results []Result
 	for _, sn := range serviceNames {
+		serviceStatus, result := n.checkInitSystem(initsystemvar, sn)
 		status.ServiceList = append(status.ServiceList, systemStatus{
 			Name:   sn,
-			Status: n.checkInitSystem(initsystemvar, sn),
+			Status: serviceStatus,
 		})
+		// not all the services are installed, e.g. only one of the runtimes
+		if serviceStatus != "NotExists" {
+			results = append(results, newResult(sn, result, "%s", serviceStatus))
+		}
 	}
 
 	status.Error = Nil
-	return nil
+	return results, nil
 }
 
 func (n *InitSystemChecker) Output(status *InitSystemStatus) error {
@@ -89,25 +94,28 @@ Systemd Service Status
 		}
 		return errors.New("convert system service template failed")
 	}
-	return tpl.Execute(os.Stdout, status)
+	return tpl.Execute(Output, status)
 }
 
 func NewInitSystemChecker() Interface {
 	return &InitSystemChecker{}
 }
 
-func (n *InitSystemChecker) checkInitSystem(system initsystem.InitSystem, name string) (status string) {
+func (n *InitSystemChecker) checkInitSystem(system initsystem.InitSystem, name string) (status string, result Status) {
+	result = StatusOK
 	if !system.ServiceExists(name) {
 		status = "NotExists"
 	} else {
 		var enable, subStatus string
 		if !system.ServiceIsEnabled(name) {
 			enable = "Disable"
+			result = StatusWarning
 		} else {
 			enable = "Enable"
 		}
 		if !system.ServiceIsActive(name) {
 			subStatus = "NotActive"
+			result = StatusFailed
 		} else {
 			subStatus = "Active"
 		}
@@ -17,7 +17,6 @@ package checker
 import (
 	"context"
 	"errors"
-	"os"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,20 +43,21 @@ type NodeClusterStatus struct {
 	NotReadyNodeList []string
 }
 
-func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) error {
+func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	// checker if all the node is ready
 	data := constants.NewPathResolver(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	nodes, err := c.Kubernetes().CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	var results []Result
 	var notReadyNodeList []string
 	var readyCount uint32
 	var nodeCount uint32
@@ -67,8 +67,10 @@ func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) error {
 		if nodePhase != ReadyNodeStatus {
 			notReadyCount++
 			notReadyNodeList = append(notReadyNodeList, nodeIP)
+			results = append(results, newResult(nodeIP, StatusFailed, "node %s is %s", node.Name, NotReadyNodeStatus))
 		} else {
 			readyCount++
+			results = append(results, newResult(nodeIP, StatusOK, "node %s is %s", node.Name, ReadyNodeStatus))
 		}
 	}
 	nodeCount = notReadyCount + readyCount
@@ -78,7 +80,7 @@ func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) error {
 		NodeCount:        nodeCount,
 		NotReadyNodeList: notReadyNodeList,
 	}
-	return n.Output(nodeClusterStatus)
+	return results, n.Output(nodeClusterStatus)
 }
 
 func (n *NodeChecker) Output(nodeCLusterStatus NodeClusterStatus) error {
@@ -99,7 +101,7 @@ Cluster Node Status
 		}
 		return errors.New("convert node template failed")
 	}
-	return tpl.Execute(os.Stdout, nodeCLusterStatus)
+	return tpl.Execute(Output, nodeCLusterStatus)
 }
 
 func getNodeStatus(node corev1.Node) (IP string, Phase string) {
@@ -17,7 +17,7 @@ package checker
 import (
 	"context"
 	"errors"
-	"os"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,24 +43,25 @@ type PodNamespaceStatus struct {
 
 var PodNamespaceStatusList []PodNamespaceStatus
 
-func (n *PodChecker) Check(cluster *v2.Cluster, phase string) error {
+func (n *PodChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	// checker if all the node is ready
 	data := constants.NewPathResolver(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	n.client = c
 
 	nsList, err := n.client.Kubernetes().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var results []Result
 	for _, podNamespace := range nsList.Items {
 		var runningCount uint32
 		var notRunningCount uint32
@@ -68,11 +69,17 @@ func (n *PodChecker) Check(cluster *v2.Cluster, phase string) error {
 		var notRunningPodList []*corev1.Pod
 		namespacePodList, err := n.client.Kubernetes().CoreV1().Pods(podNamespace.Name).List(context.TODO(), metav1.ListOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
+		var notRunningPodNames []string
 		for _, pod := range namespacePodList.Items {
+			// pods of completed jobs are never ready
+			if pod.Status.Phase == corev1.PodSucceeded {
+				continue
+			}
 			if err := getPodReadyStatus(pod); err != nil {
+				notRunningPodNames = append(notRunningPodNames, pod.Name)
 				notRunningCount++
 				newPod := pod
 				notRunningPodList = append(notRunningPodList, &newPod)
@@ -89,8 +96,17 @@ func (n *PodChecker) Check(cluster *v2.Cluster, phase string) error {
 			NotRunningPodList: notRunningPodList,
 		}
 		PodNamespaceStatusList = append(PodNamespaceStatusList, podNamespaceStatus)
+		if podCount == 0 {
+			continue
+		}
+		if notRunningCount > 0 {
+			results = append(results, newResult(podNamespace.Name, StatusFailed, "%d/%d pods are not ready: %s",
+				notRunningCount, podCount, strings.Join(notRunningPodNames, ",")))
+		} else {
+			results = append(results, newResult(podNamespace.Name, StatusOK, "%d/%d pods are ready", runningCount, podCount))
+		}
 	}
-	return n.Output(PodNamespaceStatusList)
+	return results, n.Output(PodNamespaceStatusList)
 }
 
 func (n *PodChecker) Output(podNamespaceStatusList []PodNamespaceStatus) error {
@@ -113,7 +129,7 @@ func (n *PodChecker) Output(podNamespaceStatusList []PodNamespaceStatus) error {
 		}
 		return errors.New("convert pod template failed")
 	}
-	return tpl.Execute(os.Stdout, podNamespaceStatusList)
+	return tpl.Execute(Output, podNamespaceStatusList)
 }
 
 func getPodReadyStatus(pod corev1.Pod) error {
@@ -0,0 +1,212 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slices"
+
+	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+)
+
+type Severity string
+
+const (
+	// SeverityError fails the preflight unless it's ignored.
+	SeverityError Severity = "error"
+	// SeverityWarning is only reported.
+	SeverityWarning Severity = "warning"
+)
+
+// ignoreAll ignores the errors of all the preflight checks.
+const ignoreAll = "all"
+
+// Preflight is a check of a host before it's installed.
+type Preflight interface {
+	// Name is used in the results and by --ignore-preflight-errors.
+	Name() string
+	Severity() Severity
+	Check(ctx *PreflightContext) error
+}
+
+// PreflightContext is the host a preflight check runs on.
+type PreflightContext struct {
+	Cluster *v2.Cluster
+	Execer  exec.Interface
+	// Host is the address of host, with the ssh port.
+	Host     string
+	IsMaster bool
+}
+
+// Run runs the command on host and returns the output trimmed.
+func (ctx *PreflightContext) Run(cmd string) (string, error) {
+	out, err := ctx.Execer.Cmd(ctx.Host, cmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+var defaultPreflights []Preflight
+
+// RegisterPreflight adds the checks to the preflight of all the hosts.
+func RegisterPreflight(checks ...Preflight) {
+	defaultPreflights = append(defaultPreflights, checks...)
+}
+
+// IgnorePreflightErrors are the names of checks whose errors are reported as
+// warnings, "all" ignores all of them.
+var IgnorePreflightErrors []string
+
+func RegisterPreflightFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&IgnorePreflightErrors, "ignore-preflight-errors", nil,
+		"names of preflight checks whose errors are shown as warnings, e.g. Swap,Ports, 'all' ignores errors of all checks")
+}
+
+const (
+	resultPassed  = "passed"
+	resultFailed  = "failed"
+	resultIgnored = "ignored"
+)
+
+type PreflightResult struct {
+	Host     string
+	Name     string
+	Severity Severity
+	// Result is passed, failed or ignored.
+	Result  string
+	Message string
+}
+
+// PreflightChecker runs the preflight checks on hosts concurrently and prints
+// the results of every host.
+type PreflightChecker struct {
+	IPs     []string
+	Ignore  []string
+	Checks  []Preflight
+	Output  io.Writer
+	Results []PreflightResult
+}
+
+func NewPreflightChecker(ips []string) Interface {
+	return &PreflightChecker{IPs: ips, Ignore: IgnorePreflightErrors}
+}
+
+func (c *PreflightChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
+	if phase != PhasePre || len(c.IPs) == 0 {
+		return nil, nil
+	}
+	execer, err := exec.New(ssh.NewCacheClientFromCluster(cluster, false))
+	if err != nil {
+		return nil, err
+	}
+	err = c.run(cluster, execer)
+	results := make([]Result, 0, len(c.Results))
+	for _, r := range c.Results {
+		status := StatusOK
+		switch {
+		case r.Result == resultFailed && r.Severity == SeverityError:
+			status = StatusFailed
+		case r.Result != resultPassed:
+			status = StatusWarning
+		}
+		results = append(results, newResult(r.Host, status, "%s", strings.TrimSpace(r.Name+" "+r.Message)))
+	}
+	return results, err
+}
+
+func (c *PreflightChecker) run(cluster *v2.Cluster, execer exec.Interface) error {
+	checks := c.Checks
+	if checks == nil {
+		checks = defaultPreflights
+	}
+	logger.Info("checker:preflight %v", c.IPs)
+	var mu sync.Mutex
+	c.Results = nil
+	_ = parallel.Run(c.IPs, func(host string) error {
+		ctx := &PreflightContext{
+			Cluster:  cluster,
+			Execer:   execer,
+			Host:     host,
+			IsMaster: slices.Contains(cluster.GetMasterIPList(), iputils.GetHostIP(host)),
+		}
+		for _, check := range checks {
+			result := PreflightResult{Host: host, Name: check.Name(), Severity: check.Severity(), Result: resultPassed}
+			if err := check.Check(ctx); err != nil {
+				result.Result, result.Message = resultFailed, err.Error()
+				if result.Severity == SeverityError && c.ignored(check.Name()) {
+					result.Result = resultIgnored
+				}
+			}
+			mu.Lock()
+			c.Results = append(c.Results, result)
+			mu.Unlock()
+		}
+		return nil
+	})
+	sort.SliceStable(c.Results, func(i, j int) bool {
+		return slices.Index(c.IPs, c.Results[i].Host) < slices.Index(c.IPs, c.Results[j].Host)
+	})
+	out := c.Output
+	if out == nil {
+		out = Output
+	}
+	if err := PrintPreflightResults(out, c.Results); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, r := range c.Results {
+		if r.Result == resultFailed && r.Severity == SeverityError {
+			failed = append(failed, fmt.Sprintf("[%s] %s: %s", r.Host, r.Name, r.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d preflight check(s) failed, fix them or skip by --ignore-preflight-errors:\n%s",
+			len(failed), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+func (c *PreflightChecker) ignored(name string) bool {
+	for _, n := range c.Ignore {
+		if strings.EqualFold(n, name) || strings.EqualFold(n, ignoreAll) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintPreflightResults prints the results as a table.
+func PrintPreflightResults(w io.Writer, results []PreflightResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tCHECK\tSEVERITY\tRESULT\tMESSAGE")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Host, r.Name, r.Severity, r.Result, strings.ReplaceAll(r.Message, "\n", " "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,189 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/labring/sealos/pkg/constants"
+)
+
+func init() {
+	RegisterPreflight(
+		&swapCheck{},
+		&kernelModulesCheck{modules: []string{"br_netfilter", "ip_vs"}},
+		&portsCheck{},
+		&diskSpaceCheck{path: "/var/lib", min: resource.MustParse("10Gi")},
+		&cgroupDriverCheck{},
+		&selinuxCheck{},
+	)
+}
+
+type swapCheck struct{}
+
+func (*swapCheck) Name() string       { return "Swap" }
+func (*swapCheck) Severity() Severity { return SeverityError }
+
+func (*swapCheck) Check(ctx *PreflightContext) error {
+	out, err := ctx.Run("cat /proc/swaps")
+	if err != nil {
+		return err
+	}
+	// the first line is the header
+	if len(strings.Split(out, "\n")) > 1 {
+		return fmt.Errorf("swap is on, turn it off by swapoff -a and remove it from /etc/fstab")
+	}
+	return nil
+}
+
+type kernelModulesCheck struct {
+	modules []string
+}
+
+func (*kernelModulesCheck) Name() string       { return "KernelModules" }
+func (*kernelModulesCheck) Severity() Severity { return SeverityError }
+
+func (c *kernelModulesCheck) Check(ctx *PreflightContext) error {
+	// a module is fine if it's loaded, built in, or could be loaded
+	out, err := ctx.Run(fmt.Sprintf(`for m in %s; do [ -d /sys/module/$m ] || modprobe -n $m >/dev/null 2>&1 || echo $m; done`,
+		strings.Join(c.modules, " ")))
+	if err != nil {
+		return err
+	}
+	if missing := strings.Fields(out); len(missing) > 0 {
+		return fmt.Errorf("kernel modules %s are not available", strings.Join(missing, ","))
+	}
+	return nil
+}
+
+// portsCheck checks the ports used by kubernetes are not listened on.
+type portsCheck struct{}
+
+var (
+	masterPorts = []int{constants.DefaultAPIServerPort, 2379, 2380, 10250, 10257, 10259}
+	nodePorts   = []int{10250}
+)
+
+func (*portsCheck) Name() string       { return "Ports" }
+func (*portsCheck) Severity() Severity { return SeverityError }
+
+func (*portsCheck) Check(ctx *PreflightContext) error {
+	out, err := ctx.Run("cat /proc/net/tcp /proc/net/tcp6 2>/dev/null || true")
+	if err != nil {
+		return err
+	}
+	ports := nodePorts
+	if ctx.IsMaster {
+		ports = masterPorts
+	}
+	listening := listeningPorts(out)
+	var busy []string
+	for _, port := range ports {
+		if listening[port] {
+			busy = append(busy, strconv.Itoa(port))
+		}
+	}
+	if len(busy) > 0 {
+		return fmt.Errorf("ports %s are in use", strings.Join(busy, ","))
+	}
+	return nil
+}
+
+// listeningPorts returns the ports in LISTEN state of /proc/net/tcp.
+func listeningPorts(procNetTCP string) map[int]bool {
+	const stateListen = "0A"
+	ports := make(map[int]bool)
+	for _, line := range strings.Split(procNetTCP, "\n") {
+		// sl local_address rem_address st ...
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[3] != stateListen {
+			continue
+		}
+		i := strings.LastIndex(fields[1], ":")
+		if i < 0 {
+			continue
+		}
+		if port, err := strconv.ParseInt(fields[1][i+1:], 16, 32); err == nil {
+			ports[int(port)] = true
+		}
+	}
+	return ports
+}
+
+type diskSpaceCheck struct {
+	path string
+	min  resource.Quantity
+}
+
+func (*diskSpaceCheck) Name() string       { return "DiskSpace" }
+func (*diskSpaceCheck) Severity() Severity { return SeverityError }
+
+func (c *diskSpaceCheck) Check(ctx *PreflightContext) error {
+	out, err := ctx.Run(fmt.Sprintf("df -Pk %s | tail -n 1", c.path))
+	if err != nil {
+		return err
+	}
+	// Filesystem 1024-blocks Used Available Capacity Mounted-on
+	fields := strings.Fields(out)
+	if len(fields) < 4 {
+		return fmt.Errorf("unexpected output of df: %s", out)
+	}
+	kb, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return fmt.Errorf("unexpected output of df: %s", out)
+	}
+	if available := resource.NewQuantity(kb<<10, resource.BinarySI); available.Cmp(c.min) < 0 {
+		return fmt.Errorf("%s available on %s, at least %s is required", available, c.path, &c.min)
+	}
+	return nil
+}
+
+// cgroupDriverCheck warns if the installed docker uses cgroupfs driver on a
+// host run by systemd, the cgroups would be managed by two managers.
+type cgroupDriverCheck struct{}
+
+func (*cgroupDriverCheck) Name() string       { return "CgroupDriver" }
+func (*cgroupDriverCheck) Severity() Severity { return SeverityWarning }
+
+func (*cgroupDriverCheck) Check(ctx *PreflightContext) error {
+	out, err := ctx.Run(`if [ -d /run/systemd/system ] && command -v docker >/dev/null 2>&1; then docker info --format '{{.CgroupDriver}}' 2>/dev/null; fi; true`)
+	if err != nil {
+		return err
+	}
+	if out == "cgroupfs" {
+		return fmt.Errorf("docker uses cgroupfs cgroup driver on a host run by systemd, systemd driver is recommended")
+	}
+	return nil
+}
+
+type selinuxCheck struct{}
+
+func (*selinuxCheck) Name() string       { return "SELinux" }
+func (*selinuxCheck) Severity() Severity { return SeverityError }
+
+func (*selinuxCheck) Check(ctx *PreflightContext) error {
+	out, err := ctx.Run("getenforce 2>/dev/null || true")
+	if err != nil {
+		return err
+	}
+	if out == "Enforcing" {
+		return fmt.Errorf("SELinux is enforcing, set it to permissive by setenforce 0 and in /etc/selinux/config")
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/labring/sealos/pkg/exec"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+// fakeExecer returns the outputs of commands by host.
+type fakeExecer struct {
+	exec.Interface
+	outputs map[string]string
+}
+
+func (f *fakeExecer) Cmd(host, _ string) ([]byte, error) {
+	out, ok := f.outputs[host]
+	if !ok {
+		return nil, errors.New("unreachable")
+	}
+	return []byte(out), nil
+}
+
+type fakePreflight struct {
+	name     string
+	severity Severity
+}
+
+func (f *fakePreflight) Name() string       { return f.name }
+func (f *fakePreflight) Severity() Severity { return f.severity }
+
+func (f *fakePreflight) Check(ctx *PreflightContext) error {
+	out, err := ctx.Run("true")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(out, f.name) {
+		return errors.New(f.name + " failed")
+	}
+	return nil
+}
+
+func TestPreflightChecker(t *testing.T) {
+	cluster := &v2.Cluster{Spec: v2.ClusterSpec{Hosts: []v2.Host{
+		{IPS: []string{"192.168.0.2:22"}, Roles: []string{v2.MASTER}},
+		{IPS: []string{"192.168.0.3:22"}, Roles: []string{v2.NODE}},
+	}}}
+	execer := &fakeExecer{outputs: map[string]string{
+		"192.168.0.2:22": "ok",
+		"192.168.0.3:22": "Swap SELinux CgroupDriver",
+	}}
+	checks := []Preflight{
+		&fakePreflight{name: "Swap", severity: SeverityError},
+		&fakePreflight{name: "SELinux", severity: SeverityError},
+		&fakePreflight{name: "CgroupDriver", severity: SeverityWarning},
+	}
+	tests := []struct {
+		name    string
+		ignore  []string
+		wantErr bool
+		want    map[string]string
+	}{
+		{
+			name:    "errors fail",
+			wantErr: true,
+			want:    map[string]string{"Swap": resultFailed, "SELinux": resultFailed, "CgroupDriver": resultFailed},
+		},
+		{
+			name:    "ignore some",
+			ignore:  []string{"swap"},
+			wantErr: true,
+			want:    map[string]string{"Swap": resultIgnored, "SELinux": resultFailed, "CgroupDriver": resultFailed},
+		},
+		{
+			name:   "ignore all",
+			ignore: []string{"all"},
+			want:   map[string]string{"Swap": resultIgnored, "SELinux": resultIgnored, "CgroupDriver": resultFailed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			c := &PreflightChecker{IPs: []string{"192.168.0.2:22", "192.168.0.3:22"}, Ignore: tt.ignore, Checks: checks, Output: out}
+			if err := c.run(cluster, execer); (err != nil) != tt.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(c.Results) != 6 {
+				t.Fatalf("got %d results, want 6", len(c.Results))
+			}
+			for i, r := range c.Results {
+				if i < 3 {
+					if r.Host != "192.168.0.2:22" || r.Result != resultPassed {
+						t.Errorf("result of master = %+v, want passed", r)
+					}
+					continue
+				}
+				if r.Result != tt.want[r.Name] {
+					t.Errorf("result of %s = %s, want %s", r.Name, r.Result, tt.want[r.Name])
+				}
+			}
+			if !strings.HasPrefix(out.String(), "HOST") {
+				t.Errorf("unexpected output:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestListeningPorts(t *testing.T) {
+	procNetTCP := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:192B 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:094B 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0000000000000000 100 0 0 10 0
+   2: 0200A8C0:0016 0300A8C0:D431 01 00000000:00000000 02:000A7214 00000000     0        0 3 4 0000000000000000 20 4 30 10 -1
+  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:280A 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4 1 0000000000000000 100 0 0 10 0`
+	got := listeningPorts(procNetTCP)
+	for _, port := range []int{6443, 2379, 10250} {
+		if !got[port] {
+			t.Errorf("port %d is not listening", port)
+		}
+	}
+	if got[22] || len(got) != 3 {
+		t.Errorf("listeningPorts() = %v", got)
+	}
+}
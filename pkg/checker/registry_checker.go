@@ -19,7 +19,6 @@ package checker
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/labring/sreg/pkg/registry/crane"
 
@@ -55,14 +54,14 @@ type RegistryStatus struct {
 	Error          string
 }
 
-func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
+func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	localAddr, _ := iputils.ListLocalHostAddrs()
 	if !iputils.IsLocalIP(cluster.GetRegistryIP(), localAddr) {
 		logger.Info("current registry ip is %s,not local addr,skip check.", cluster.GetRegistryIP())
-		return nil
+		return nil, nil
 	}
 	status := &RegistryStatus{}
 	defer func() {
@@ -91,7 +90,7 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 	sshCtx := ssh.NewCacheClientFromCluster(cluster, false)
 	execer, err := exec.New(sshCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	root := constants.NewPathResolver(cluster.Name).RootFSPath()
 	regInfo := helpers.GetRegistryInfo(execer, root, cluster.GetRegistryIPAndPort())
@@ -104,11 +103,14 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 	_, err = crane.NewRegistry(status.RegistryDomain, cfg)
 	if err != nil {
 		status.Error = fmt.Errorf("get registry interface error: %w", err).Error()
-		return nil
+		return []Result{newResult(status.RegistryDomain, StatusFailed, "%s", status.Error)}, nil
 	}
 	status.Ping = "ok"
+	if status.Error != "" {
+		return []Result{newResult(status.RegistryDomain, StatusFailed, "%s", status.Error)}, nil
+	}
 	status.Error = Nil
-	return nil
+	return []Result{newResult(status.RegistryDomain, StatusOK, "ping ok")}, nil
 }
 
 func (n *RegistryChecker) Output(status *RegistryStatus) error {
@@ -131,7 +133,7 @@ func (n *RegistryChecker) Output(status *RegistryStatus) error {
 		}
 		return errors.New("convert registry template failed")
 	}
-	return tpl.Execute(os.Stdout, status)
+	return tpl.Execute(Output, status)
 }
 
 func NewRegistryChecker() Interface {
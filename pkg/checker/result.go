@@ -0,0 +1,80 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusWarning Status = "warning"
+	StatusFailed  Status = "failed"
+)
+
+// severity orders the statuses, the worst one is the status of results.
+func (s Status) severity() int {
+	switch s {
+	case StatusOK:
+		return 0
+	case StatusWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Result is a finding of a checker on a target, e.g. a host, node or service.
+type Result struct {
+	Checker string `json:"checker"`
+	Target  string `json:"target"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func newResult(target string, status Status, format string, args ...interface{}) Result {
+	return Result{Target: target, Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// WorstStatus returns the worst status of results, ok if there is none.
+func WorstStatus(results []Result) Status {
+	status := StatusOK
+	for _, r := range results {
+		if r.Status.severity() > status.severity() {
+			status = r.Status
+		}
+	}
+	return status
+}
+
+// Output is where the checkers print their detailed reports, it's discarded
+// when the results are printed in a machine-readable format.
+var Output io.Writer = os.Stdout
+
+// PrintResults prints the results as a table.
+func PrintResults(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "CHECKER\tTARGET\tSTATUS\tMESSAGE")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Checker, r.Target, r.Status, strings.ReplaceAll(r.Message, "\n", " "))
+	}
+	return tw.Flush()
+}
@@ -17,7 +17,7 @@ package checker
 import (
 	"context"
 	"errors"
-	"os"
+	"strings"
 
 	"github.com/labring/sealos/pkg/template"
 
@@ -46,15 +46,15 @@ type SvcClusterStatus struct {
 	SvcNamespaceStatusList []*SvcNamespaceStatus
 }
 
-func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) error {
+func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) ([]Result, error) {
 	if phase != PhasePost {
-		return nil
+		return nil, nil
 	}
 	// checker if all the node is ready
 	data := constants.NewPathResolver(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	n.client = c
@@ -63,13 +63,11 @@ func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) error {
 
 	nsList, err := n.client.Kubernetes().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var svcNamespaceStatusList []*SvcNamespaceStatus
-	if err != nil {
-		return err
-	}
+	var results []Result
 	for _, svcNamespace := range nsList.Items {
 		namespaceSVCList, err := n.client.Kubernetes().CoreV1().Services(svcNamespace.Name).List(context.TODO(), metav1.ListOptions{})
 		if err != nil {
@@ -99,8 +97,18 @@ func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) error {
 			UnhealthServiceList: unhaelthService,
 		}
 		svcNamespaceStatusList = append(svcNamespaceStatusList, &svcNamespaceStatus)
+		if serviceCount == 0 {
+			continue
+		}
+		// a service may have no endpoints on purpose, e.g. scaled to zero
+		if len(unhaelthService) > 0 {
+			results = append(results, newResult(svcNamespace.Name, StatusWarning, "%d/%d services have no endpoints: %s",
+				len(unhaelthService), serviceCount, strings.Join(unhaelthService, ",")))
+		} else {
+			results = append(results, newResult(svcNamespace.Name, StatusOK, "%d/%d services have endpoints", endpointCount, serviceCount))
+		}
 	}
-	return n.Output(svcNamespaceStatusList)
+	return results, n.Output(svcNamespaceStatusList)
 }
 
 func (n *SvcChecker) Output(svcNamespaceStatusList []*SvcNamespaceStatus) error {
@@ -121,7 +129,7 @@ func (n *SvcChecker) Output(svcNamespaceStatusList []*SvcNamespaceStatus) error
 		}
 		return errors.New("convert svc template failed")
 	}
-	return tpl.Execute(os.Stdout, svcNamespaceStatusList)
+	return tpl.Execute(Output, svcNamespaceStatusList)
 }
 
 func IsExistEndpoint(endpointList *corev1.EndpointsList, serviceName string) bool {
@@ -0,0 +1,161 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/drain"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// DrainOptions controls how pods are evicted from a node before it's upgraded or removed.
+type DrainOptions struct {
+	// Enabled drains the node, otherwise the node is only cordoned when needed.
+	Enabled bool
+	// Timeout is the time to wait before giving up, zero means infinite.
+	Timeout time.Duration
+	// Force also deletes pods that are not managed by a controller.
+	Force bool
+	// DeleteEmptyDirData continues even if there are pods using emptyDir.
+	DeleteEmptyDirData bool
+	// GracePeriodSeconds is the period given to each pod to terminate, negative means the pod's default.
+	GracePeriodSeconds int
+	// IgnoreErrors removes a node even if it fails to drain.
+	IgnoreErrors bool
+}
+
+var (
+	defaultDrainOptions = DrainOptions{
+		Enabled:            true,
+		Timeout:            5 * time.Minute,
+		Force:              false,
+		DeleteEmptyDirData: false,
+		GracePeriodSeconds: -1,
+	}
+	skipDrain bool
+)
+
+// RegisterDrainFlags registers the drain flags shared by the commands that upgrade or remove nodes.
+func RegisterDrainFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&defaultDrainOptions.Enabled, "drain", defaultDrainOptions.Enabled, "evict pods from nodes before upgrading or removing them")
+	fs.DurationVar(&defaultDrainOptions.Timeout, "drain-timeout", defaultDrainOptions.Timeout, "the length of time to wait before giving up draining a node, zero means infinite")
+	fs.BoolVar(&skipDrain, "skip-drain", false, "skip draining nodes, same as --drain=false")
+	fs.BoolVar(&defaultDrainOptions.Force, "drain-force", defaultDrainOptions.Force, "continue draining even if there are pods not managed by a controller, they are deleted and never recreated")
+	fs.BoolVar(&defaultDrainOptions.DeleteEmptyDirData, "delete-emptydir-data", defaultDrainOptions.DeleteEmptyDirData, "continue draining even if there are pods using emptyDir, the local data is deleted with the pods")
+}
+
+// IgnoreDrainErrors makes the nodes removed even if they fail to drain, it's
+// set by --force of the commands removing nodes.
+func IgnoreDrainErrors(ignore bool) {
+	defaultDrainOptions.IgnoreErrors = ignore
+}
+
+// CheckDrainError returns the error of draining a node before removing it,
+// unless the errors are ignored.
+func CheckDrainError(node string, err error, opts DrainOptions) error {
+	if err == nil {
+		return nil
+	}
+	if opts.IgnoreErrors {
+		logger.Warn("failed to drain %s, remove it anyway: %v", node, err)
+		return nil
+	}
+	return fmt.Errorf("failed to drain %s, pass --force to remove it anyway or --skip-drain to skip draining: %v", node, err)
+}
+
+// GetDrainOptions returns the drain options set by the command line flags.
+func GetDrainOptions() DrainOptions {
+	opts := defaultDrainOptions
+	if skipDrain {
+		opts.Enabled = false
+	}
+	return opts
+}
+
+// DrainNode cordons the node and evicts all its pods except the ones managed by DaemonSets,
+// the eviction API is used so that PodDisruptionBudgets are respected.
+func DrainNode(ctx context.Context, client clientset.Interface, nodeName string, opts DrainOptions) error {
+	logger.Info("start to drain node %s", nodeName)
+	helper := &drain.Helper{
+		Ctx:                 ctx,
+		Client:              client,
+		Force:               opts.Force,
+		GracePeriodSeconds:  opts.GracePeriodSeconds,
+		IgnoreAllDaemonSets: true,
+		Timeout:             opts.Timeout,
+		DeleteEmptyDirData:  opts.DeleteEmptyDirData,
+		Out:                 &logWriter{logFunc: logger.Debug},
+		ErrOut:              &logWriter{logFunc: logger.Warn},
+		OnPodDeletedOrEvicted: func(pod *v1.Pod, usingEviction bool) {
+			verb := "deleted"
+			if usingEviction {
+				verb = "evicted"
+			}
+			logger.Info("pod %s/%s %s from node %s", pod.Namespace, pod.Name, verb, nodeName)
+		},
+	}
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.Info("node %s is not found, skip draining", nodeName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = drain.RunCordonOrUncordon(helper, node, true); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", nodeName, err)
+	}
+	// the pods of a node that is down never terminate, evicting them would
+	// only wait until the timeout
+	if !isNodeReady(node) {
+		logger.Warn("node %s is not ready, skip evicting its pods", nodeName)
+		return nil
+	}
+	if err = drain.RunNodeDrain(helper, nodeName); err != nil {
+		return fmt.Errorf("failed to drain node %s: %v", nodeName, err)
+	}
+	logger.Info("succeeded in draining node %s", nodeName)
+	return nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+type logWriter struct {
+	logFunc func(f interface{}, v ...interface{})
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	if msg := strings.TrimSpace(string(p)); msg != "" {
+		w.logFunc(msg)
+	}
+	return len(p), nil
+}
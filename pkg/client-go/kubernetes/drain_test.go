@@ -0,0 +1,131 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDrainNode(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}},
+	}
+	daemonSetPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-proxy-xxx",
+			Namespace: metav1.NamespaceSystem,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "kube-proxy", Controller: func() *bool { b := true; return &b }()},
+			},
+		},
+		Spec: v1.PodSpec{NodeName: "node1"},
+	}
+	daemonSet := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: metav1.NamespaceSystem}}
+	client := fake.NewSimpleClientset(node, daemonSet, daemonSetPod)
+	if err := DrainNode(context.Background(), client, "node1", DrainOptions{Enabled: true, Timeout: time.Second, GracePeriodSeconds: -1}); err != nil {
+		t.Fatalf("DrainNode() error = %v", err)
+	}
+	got, err := client.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Spec.Unschedulable {
+		t.Errorf("node1 is not cordoned after draining")
+	}
+}
+
+func TestDrainNodeNotReadyOrMissing(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: metav1.NamespaceDefault},
+		Spec:       v1.PodSpec{NodeName: "node1"},
+	}
+	client := fake.NewSimpleClientset(node, pod)
+	opts := DrainOptions{Enabled: true, Timeout: time.Second, GracePeriodSeconds: -1}
+	// the unmanaged pod would fail the drain without --drain-force
+	if err := DrainNode(context.Background(), client, "node1", opts); err != nil {
+		t.Fatalf("DrainNode() of not ready node error = %v", err)
+	}
+	got, err := client.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Spec.Unschedulable {
+		t.Errorf("node1 is not cordoned")
+	}
+	if err = DrainNode(context.Background(), client, "node2", opts); err != nil {
+		t.Errorf("DrainNode() of missing node error = %v", err)
+	}
+}
+
+func TestGetDrainOptions(t *testing.T) {
+	defer func(opts DrainOptions) {
+		defaultDrainOptions, skipDrain = opts, false
+	}(defaultDrainOptions)
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterDrainFlags(fs)
+	if opts := GetDrainOptions(); !opts.Enabled || opts.Force || opts.DeleteEmptyDirData {
+		t.Errorf("GetDrainOptions() = %+v, want enabled without force or deleting emptyDir data", opts)
+	}
+	if err := fs.Parse([]string{"--skip-drain", "--drain-timeout=1m", "--drain-force", "--delete-emptydir-data"}); err != nil {
+		t.Fatal(err)
+	}
+	opts := GetDrainOptions()
+	if opts.Enabled {
+		t.Errorf("draining should be disabled by --skip-drain")
+	}
+	if opts.Timeout != time.Minute {
+		t.Errorf("GetDrainOptions() timeout = %v, want 1m", opts.Timeout)
+	}
+	if !opts.Force || !opts.DeleteEmptyDirData {
+		t.Errorf("GetDrainOptions() = %+v, want force and deleting emptyDir data", opts)
+	}
+}
+
+func TestCheckDrainError(t *testing.T) {
+	drainErr := errors.New("cannot evict pod as it would violate the pod's disruption budget")
+	tests := []struct {
+		name    string
+		err     error
+		opts    DrainOptions
+		wantErr bool
+	}{
+		{name: "drained", err: nil},
+		{name: "failed", err: drainErr, wantErr: true},
+		{name: "failed by force", err: drainErr, opts: DrainOptions{IgnoreErrors: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDrainError("192.168.0.2", tt.err, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckDrainError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), drainErr.Error()) {
+				t.Errorf("CheckDrainError() error = %v, want the drain error", err)
+			}
+		})
+	}
+}
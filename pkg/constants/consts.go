@@ -15,7 +15,8 @@
 package constants
 
 const (
-	DefaultClusterFileName = "Clusterfile"
+	DefaultClusterFileName    = "Clusterfile"
+	DefaultKnownHostsFileName = "known_hosts"
 )
 
 const TemplateSuffix = ".tmpl"
@@ -31,6 +32,12 @@ const (
 	DefaultHostsPath        = "/etc/hosts"
 )
 
+// the lvscare balancing registry replicas in HA mode
+const (
+	LvsCareRegistryStaticPodName = "kube-sealos-lvscare-registry"
+	LvsCareRegistryIface         = "lvscare-reg"
+)
+
 const (
 	DefaultAPIServerDomain = "apiserver.cluster.local"
 	DefaultDNSDomain       = "cluster.local"
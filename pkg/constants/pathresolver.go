@@ -64,6 +64,11 @@ func Clusterfile(clusterName string) string {
 	return filepath.Join(DefaultRuntimeRootDir, clusterName, DefaultClusterFileName)
 }
 
+// KnownHostsFile returns the file that the trusted host keys of cluster are recorded in.
+func KnownHostsFile(clusterName string) string {
+	return filepath.Join(DefaultRuntimeRootDir, clusterName, DefaultKnownHostsFileName)
+}
+
 func GetRuntimeRootDir(name string) string {
 	if v, ok := os.LookupEnv(strings.ToUpper(name) + "_RUNTIME_ROOT"); ok {
 		return v
@@ -27,6 +27,7 @@ import (
 
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/unshare"
+	"github.com/labring/sealos/pkg/utils/events"
 	fileutil "github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -61,8 +62,10 @@ func (w *wrap) Ping(host string) error {
 
 func (w *wrap) Cmd(host string, command string) ([]byte, error) {
 	if w.isLocal(host) {
+		done := events.Start(events.Event{Type: events.TypeCommand, Host: host, Command: command})
 		// nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 		b, err := exec.Command("/bin/bash", "-c", command).CombinedOutput()
+		done(err)
 		return b, err
 	}
 	return w.inner.Cmd(host, command)
@@ -71,11 +74,14 @@ func (w *wrap) Cmd(host string, command string) ([]byte, error) {
 func (w *wrap) CmdAsyncWithContext(ctx context.Context, host string, commands ...string) error {
 	if w.isLocal(host) {
 		for i := range commands {
+			done := events.Start(events.Event{Type: events.TypeCommand, Host: host, Command: commands[i]})
 			// nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 			cmd := exec.CommandContext(ctx, "/bin/bash", "-c", commands[i])
-			cmd.Stdout = os.Stdout
+			cmd.Stdout = logger.ConsoleOutput()
 			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err != nil {
+			err := cmd.Run()
+			done(err)
+			if err != nil {
 				return err
 			}
 		}
@@ -29,4 +29,6 @@ type Mounter interface {
 
 type RegistrySyncer interface {
 	Sync(context.Context, ...string) error
+	// SyncReplicas replicates the images among the registry replicas.
+	SyncReplicas(context.Context, ...string) error
 }
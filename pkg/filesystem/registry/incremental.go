@@ -0,0 +1,301 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/docker/go-units"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/utils/hash"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// syncStats counts the bytes of blobs sent to hosts and the bytes skipped
+// because the hosts have them already.
+type syncStats struct {
+	transferred atomic.Int64
+	skipped     atomic.Int64
+}
+
+func (s *syncStats) String() string {
+	return fmt.Sprintf("%s transferred, %s skipped",
+		units.BytesSize(float64(s.transferred.Load())), units.BytesSize(float64(s.skipped.Load())))
+}
+
+// copyRegistry copies all the images of registry src to registry dst, an
+// image whose manifest is in dst already is skipped, otherwise only the blobs
+// missing in dst are pushed. The tags in dst are never replaced if keepExisting.
+func copyRegistry(ctx context.Context, src, dst string, keepExisting bool, stats *syncStats) error {
+	srcRegistry, err := name.NewRegistry(src, name.Insecure)
+	if err != nil {
+		return err
+	}
+	repos, err := remote.Catalog(ctx, srcRegistry)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories of %s: %v", src, err)
+	}
+	for _, repo := range repos {
+		tags, err := remote.List(srcRegistry.Repo(repo), remote.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to list tags of %s: %v", repo, err)
+		}
+		for _, tag := range tags {
+			srcRef := srcRegistry.Repo(repo).Tag(tag)
+			dstRef, err := name.NewTag(fmt.Sprintf("%s/%s:%s", dst, repo, tag), name.Insecure)
+			if err != nil {
+				return err
+			}
+			if keepExisting {
+				if _, err = remote.Head(dstRef, remote.WithContext(ctx)); err == nil {
+					continue
+				}
+			}
+			// the error of an image doesn't stop the others, as the old sync did
+			if err = copyImage(ctx, srcRef, dstRef, stats); err != nil {
+				logger.Warn("failed to copy image %s: %v", srcRef, err)
+			}
+		}
+	}
+	return nil
+}
+
+func copyImage(ctx context.Context, srcRef, dstRef name.Tag, stats *syncStats) error {
+	desc, err := remote.Get(srcRef, remote.WithContext(ctx),
+		remote.WithPlatform(v1.Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}))
+	if err != nil {
+		return err
+	}
+	blobs := map[v1.Hash]int64{}
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+		if err = indexBlobs(idx, blobs); err != nil {
+			// not all the platforms are in the local registry, copy the one of
+			// current system only
+			logger.Debug("failed to get all the images of %s, copying the one of current platform: %v", srcRef, err)
+			return copySystemImage(ctx, desc, dstRef, stats)
+		}
+		if dstDesc, err := remote.Head(dstRef, remote.WithContext(ctx)); err == nil && dstDesc.Digest == desc.Digest {
+			stats.skipped.Add(sumSizes(blobs))
+			return nil
+		}
+		countBlobs(ctx, dstRef.Context(), blobs, stats)
+		return remote.WriteIndex(dstRef, idx, remote.WithContext(ctx))
+	}
+	return copySystemImage(ctx, desc, dstRef, stats)
+}
+
+func copySystemImage(ctx context.Context, desc *remote.Descriptor, dstRef name.Tag, stats *syncStats) error {
+	img, err := desc.Image()
+	if err != nil {
+		return err
+	}
+	blobs := map[v1.Hash]int64{}
+	if err = imageBlobs(img, blobs); err != nil {
+		return err
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	if dstDesc, err := remote.Head(dstRef, remote.WithContext(ctx)); err == nil && dstDesc.Digest == digest {
+		stats.skipped.Add(sumSizes(blobs))
+		return nil
+	}
+	countBlobs(ctx, dstRef.Context(), blobs, stats)
+	return remote.Write(dstRef, img, remote.WithContext(ctx))
+}
+
+func indexBlobs(idx v1.ImageIndex, blobs map[v1.Hash]int64) error {
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return err
+	}
+	for _, child := range manifest.Manifests {
+		if child.MediaType.IsIndex() {
+			childIdx, err := idx.ImageIndex(child.Digest)
+			if err != nil {
+				return err
+			}
+			if err = indexBlobs(childIdx, blobs); err != nil {
+				return err
+			}
+			continue
+		}
+		img, err := idx.Image(child.Digest)
+		if err != nil {
+			return err
+		}
+		if err = imageBlobs(img, blobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func imageBlobs(img v1.Image, blobs map[v1.Hash]int64) error {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+	blobs[manifest.Config.Digest] = manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		blobs[layer.Digest] = layer.Size
+	}
+	return nil
+}
+
+func sumSizes(blobs map[v1.Hash]int64) int64 {
+	var sum int64
+	for _, size := range blobs {
+		sum += size
+	}
+	return sum
+}
+
+// countBlobs queries dst for the blobs, the existing ones are counted as
+// skipped, they are not pushed again by remote.Write.
+func countBlobs(ctx context.Context, repo name.Repository, blobs map[v1.Hash]int64, stats *syncStats) {
+	for digest, size := range blobs {
+		if blobExists(ctx, repo, digest) {
+			stats.skipped.Add(size)
+		} else {
+			stats.transferred.Add(size)
+		}
+	}
+}
+
+func blobExists(ctx context.Context, repo name.Repository, digest v1.Hash) bool {
+	url := fmt.Sprintf("%s://%s/v2/%s/blobs/%s", repo.Scheme(), repo.RegistryStr(), repo.RepositoryStr(), digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// the blobs of registry storage are stored by their digests, a blob that
+// exists with the same size is the same blob.
+const registryBlobsDir = "./docker/registry/v2/blobs/"
+
+const (
+	listRemoteBlobsCmd = `cd %s 2>/dev/null || exit 0; find . -type f -path '%s*' -exec wc -c {} +`
+	listRemoteFilesCmd = `cd %s 2>/dev/null || exit 0; find . -type f ! -path '%s*' -exec sha256sum {} +`
+)
+
+// remoteFiles is the registry storage on a host, the sizes of blobs and the
+// sha256 digests of the other files by their relative paths.
+type remoteFiles struct {
+	blobSizes map[string]int64
+	digests   map[string]string
+}
+
+func listRemoteFiles(execer exec.Interface, host, dir string) (*remoteFiles, error) {
+	blobs, err := execer.Cmd(host, fmt.Sprintf(listRemoteBlobsCmd, dir, registryBlobsDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs of %s on %s: %v", dir, host, err)
+	}
+	files, err := execer.Cmd(host, fmt.Sprintf(listRemoteFilesCmd, dir, registryBlobsDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s on %s: %v", dir, host, err)
+	}
+	return parseRemoteFiles(string(blobs), string(files)), nil
+}
+
+// parseRemoteFiles parses the outputs of wc -c and sha256sum.
+func parseRemoteFiles(blobs, files string) *remoteFiles {
+	rf := &remoteFiles{blobSizes: map[string]int64{}, digests: map[string]string{}}
+	for _, line := range strings.Split(blobs, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] == "total" {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			rf.blobSizes[fields[1]] = size
+		}
+	}
+	for _, line := range strings.Split(files, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		rf.digests[fields[1]] = fields[0]
+	}
+	return rf
+}
+
+// matches returns true if the remote file is the same as the local one.
+func (rf *remoteFiles) matches(rel string, local string, size int64) bool {
+	if strings.HasPrefix(rel, registryBlobsDir) {
+		remoteSize, ok := rf.blobSizes[rel]
+		return ok && remoteSize == size
+	}
+	digest, ok := rf.digests[rel]
+	return ok && digest == hash.FileDigest(local)
+}
+
+// copyDirIncremental copies the files of localDir missing or different on
+// host to remoteDir.
+func copyDirIncremental(execer exec.Interface, host, localDir, remoteDir string, stats *syncStats) error {
+	rf, err := listRemoteFiles(execer, host, remoteDir)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(localDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		rel = "./" + filepath.ToSlash(rel)
+		if rf.matches(rel, path, info.Size()) {
+			stats.skipped.Add(info.Size())
+			return nil
+		}
+		if err = execer.Copy(host, path, filepath.Join(remoteDir, rel)); err != nil {
+			return fmt.Errorf("failed to copy %s to %s: %v", path, host, err)
+		}
+		stats.transferred.Add(info.Size())
+		return nil
+	})
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/labring/sealos/pkg/exec"
+)
+
+// fakeExecer answers the listing commands and records the copied files.
+type fakeExecer struct {
+	exec.Interface
+	blobs, files string
+	copied       []string
+}
+
+func (f *fakeExecer) Cmd(_, cmd string) ([]byte, error) {
+	if strings.Contains(cmd, "sha256sum") {
+		return []byte(f.files), nil
+	}
+	return []byte(f.blobs), nil
+}
+
+func (f *fakeExecer) Copy(_, _, remotePath string) error {
+	f.copied = append(f.copied, remotePath)
+	return nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyDirIncremental(t *testing.T) {
+	dir := t.TempDir()
+	blobs := "docker/registry/v2/blobs/sha256/ab/"
+	writeFile(t, filepath.Join(dir, blobs, "ab01/data"), "layer-1")
+	writeFile(t, filepath.Join(dir, blobs, "ab02/data"), "layer-2")
+	writeFile(t, filepath.Join(dir, blobs, "ab03/data"), "layer-3")
+	links := "docker/registry/v2/repositories/library/app/_manifests/tags/"
+	writeFile(t, filepath.Join(dir, links, "v1/current/link"), "sha256:ab01")
+	writeFile(t, filepath.Join(dir, links, "v2/current/link"), "sha256:ab02")
+
+	execer := &fakeExecer{
+		// ab02 is an interrupted copy
+		blobs: "7 ./docker/registry/v2/blobs/sha256/ab/ab01/data\n" +
+			"3 ./docker/registry/v2/blobs/sha256/ab/ab02/data\n" +
+			"10 total\n",
+		files: "0bbdebecd09e436c48784bedec83d023c8d03a5a3490441611e63ee059c15f37  ./" + links + "v1/current/link\n" +
+			"0000000000000000000000000000000000000000000000000000000000000000  ./" + links + "v2/current/link\n",
+	}
+	stats := &syncStats{}
+	if err := copyDirIncremental(execer, "192.168.0.2", dir, "/var/lib/registry", stats); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/var/lib/registry/" + blobs + "ab02/data",
+		"/var/lib/registry/" + blobs + "ab03/data",
+		"/var/lib/registry/" + links + "v2/current/link",
+	}
+	sort.Strings(execer.copied)
+	if len(execer.copied) != len(want) {
+		t.Fatalf("copied %v, want %v", execer.copied, want)
+	}
+	for i := range want {
+		if execer.copied[i] != want[i] {
+			t.Errorf("copied %s, want %s", execer.copied[i], want[i])
+		}
+	}
+	if got := stats.transferred.Load(); got != 7+7+11 {
+		t.Errorf("transferred %d bytes, want %d", got, 7+7+11)
+	}
+	if got := stats.skipped.Load(); got != 7+11 {
+		t.Errorf("skipped %d bytes, want %d", got, 7+11)
+	}
+}
+
+func TestCopyRegistryKeepExisting(t *testing.T) {
+	newRegistry := func() string {
+		server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+		t.Cleanup(server.Close)
+		return strings.TrimPrefix(server.URL, "http://")
+	}
+	push := func(host, ref string) v1.Hash {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tag, err := name.NewTag(host+"/"+ref, name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = remote.Write(tag, img); err != nil {
+			t.Fatal(err)
+		}
+		digest, _ := img.Digest()
+		return digest
+	}
+	digestOf := func(host, ref string) v1.Hash {
+		tag, err := name.NewTag(host+"/"+ref, name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc, err := remote.Head(tag)
+		if err != nil {
+			t.Fatalf("image %s/%s: %v", host, ref, err)
+		}
+		return desc.Digest
+	}
+
+	tests := []struct {
+		name         string
+		keepExisting bool
+	}{
+		{name: "replace existing tags"},
+		{name: "keep existing tags", keepExisting: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := newRegistry(), newRegistry()
+			srcApp := push(src, "library/app:v1")
+			srcPushed := push(src, "library/pushed:v1")
+			dstApp := push(dst, "library/app:v1")
+			if err := copyRegistry(context.Background(), src, dst, tt.keepExisting, &syncStats{}); err != nil {
+				t.Fatal(err)
+			}
+			if got := digestOf(dst, "library/pushed:v1"); got != srcPushed {
+				t.Errorf("digest of missing image = %s, want %s", got, srcPushed)
+			}
+			want := srcApp
+			if tt.keepExisting {
+				want = dstApp
+			}
+			if got := digestOf(dst, "library/app:v1"); got != want {
+				t.Errorf("digest of existing image = %s, want %s", got, want)
+			}
+		})
+	}
+}
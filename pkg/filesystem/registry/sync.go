@@ -23,21 +23,17 @@ import (
 	"strings"
 	"time"
 
-	"github.com/containers/image/v5/copy"
-	"github.com/containers/image/v5/types"
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sreg/pkg/registry/handler"
 	"github.com/labring/sreg/pkg/registry/sync"
 
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/filesystem"
-	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/file"
 	httputils "github.com/labring/sealos/pkg/utils/http"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 const (
@@ -70,67 +66,101 @@ func (s *impl) Sync(ctx context.Context, hosts ...string) error {
 		return nil
 	}
 	logger.Info("trying default http mode to sync images to hosts %v", hosts)
-	// run `sealctl registry serve` to start a temporary registry
-	for i := range hosts {
-		cmdCtx, cancel := context.WithCancel(ctx)
-		// defer cancel async commands
-		defer cancel()
-		go func(ctx context.Context, host string) {
-			logger.Debug("running temporary registry on host %s", host)
-			if err := s.execer.CmdAsyncWithContext(ctx, host, getRegistryServeCommand(s.pathResolver, defaultTemporaryPort)); err != nil {
-				// ignore expected signal killed error when context cancel
-				if !strings.Contains(err.Error(), "signal: killed") && !strings.Contains(err.Error(), "context canceled") {
-					logger.Error(err)
-				}
-			}
-		}(cmdCtx, hosts[i])
-	}
+	total := &syncStats{}
+	err := parallel.Run(hosts, func(host string) error {
+		stats, err := s.syncToHost(ctx, host)
+		total.transferred.Add(stats.transferred.Load())
+		total.skipped.Add(stats.skipped.Load())
+		return err
+	})
+	logger.Info("synced images to hosts %v: %s", hosts, total)
+	return err
+}
 
-	type syncOption struct {
-		target string
-		typ    int
+// SyncReplicas replicates the images among the registry replicas through the
+// first one, the primary. The images only on the other replicas, e.g. pushed
+// to them directly, are copied to the primary without replacing its tags, then
+// the primary is copied to the others.
+func (s *impl) SyncReplicas(ctx context.Context, replicas ...string) error {
+	if len(replicas) < 2 {
+		return nil
+	}
+	// the temporary registries are stopped once synced
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	targets := make([]string, len(replicas))
+	for i, host := range replicas {
+		target, err := s.serveTemporaryRegistry(ctx, host)
+		if err != nil {
+			return fmt.Errorf("cannot connect to temporary registry of replica %s: %v", host, err)
+		}
+		targets[i] = target
+	}
+	logger.Info("syncing registry replicas %v through the primary %s", replicas, replicas[0])
+	primary, stats := targets[0], &syncStats{}
+	for i, target := range targets[1:] {
+		if err := copyRegistry(ctx, target, primary, true, stats); err != nil {
+			return fmt.Errorf("failed to copy images of replica %s to the primary: %v", replicas[i+1], err)
+		}
 	}
+	err := parallel.Run(targets[1:], func(target string) error {
+		return copyRegistry(ctx, primary, target, false, stats)
+	})
+	logger.Info("synced registry replicas %v: %s", replicas, stats)
+	return err
+}
 
-	syncOptionChan := make(chan *syncOption, len(hosts))
+// serveTemporaryRegistry runs `sealctl registry serve` on host to serve the
+// registry dir until ctx is done, and returns the address of it.
+func (s *impl) serveTemporaryRegistry(ctx context.Context, host string) (string, error) {
 	go func() {
-		for i := range hosts {
-			go func(target string) {
-				probeCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-				defer cancel()
-				ep := sync.ParseRegistryAddress(trimPortStr(target), defaultTemporaryPort)
-				if err := httputils.WaitUntilEndpointAlive(probeCtx, "http://"+ep); err != nil {
-					logger.Warn("cannot connect to remote temporary registry %s: %v, fallback using ssh mode instead", ep, err)
-					syncOptionChan <- &syncOption{target: target, typ: sshMode}
-				} else {
-					syncOptionChan <- &syncOption{target: ep, typ: httpMode}
-				}
-			}(hosts[i])
+		logger.Debug("running temporary registry on host %s", host)
+		if err := s.execer.CmdAsyncWithContext(ctx, host, getRegistryServeCommand(s.pathResolver, defaultTemporaryPort)); err != nil {
+			// ignore expected signal killed error when context cancel
+			if !strings.Contains(err.Error(), "signal: killed") && !strings.Contains(err.Error(), "context canceled") {
+				logger.Error(err)
+			}
 		}
 	}()
+	target := sync.ParseRegistryAddress(trimPortStr(host), defaultTemporaryPort)
+	probeCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	return target, httputils.WaitUntilEndpointAlive(probeCtx, "http://"+target)
+}
 
-	eg, _ := errgroup.WithContext(ctx)
-	for i := 0; i < len(hosts); i++ {
-		opt, ok := <-syncOptionChan
-		if !ok {
-			break
+func (s *impl) syncToHost(ctx context.Context, host string) (*syncStats, error) {
+	cmdCtx, cancel := context.WithCancel(ctx)
+	// cancel the async command once synced
+	defer cancel()
+	typ := httpMode
+	target, err := s.serveTemporaryRegistry(cmdCtx, host)
+	if err != nil {
+		logger.Warn("cannot connect to remote temporary registry %s: %v, fallback using ssh mode instead", target, err)
+		typ, target = sshMode, host
+	}
+
+	total := &syncStats{}
+	for j := range s.mounts {
+		registryDir := filepath.Join(s.mounts[j].MountPoint, constants.RegistryDirName)
+		if !file.IsDir(registryDir) {
+			continue
 		}
-		for j := range s.mounts {
-			registryDir := filepath.Join(s.mounts[j].MountPoint, constants.RegistryDirName)
-			if !file.IsDir(registryDir) {
-				continue
-			}
-			eg.Go(func() (err error) {
-				switch opt.typ {
-				case httpMode:
-					err = syncViaHTTP(ctx, opt.target, registryDir)
-				case sshMode:
-					err = syncViaSSH(ctx, s, opt.target, registryDir)
-				}
-				return
-			})
+		stats := &syncStats{}
+		var err error
+		switch typ {
+		case httpMode:
+			err = syncViaHTTP(ctx, target, registryDir, stats)
+		case sshMode:
+			err = syncViaSSH(ctx, s, target, registryDir, stats)
+		}
+		logger.Debug("synced %s to %s: %s", registryDir, target, stats)
+		total.transferred.Add(stats.transferred.Load())
+		total.skipped.Add(stats.skipped.Load())
+		if err != nil {
+			return total, err
 		}
 	}
-	return eg.Wait()
+	return total, nil
 }
 
 func trimPortStr(s string) string {
@@ -146,15 +176,12 @@ func getRegistryServeCommand(pathResolver constants.PathResolver, port string) s
 	)
 }
 
-func syncViaSSH(_ context.Context, s *impl, target string, localDir string) error {
-	return ssh.CopyDir(s.execer, target, localDir, s.pathResolver.RootFSRegistryPath(), nil)
+// syncViaSSH copies the files missing or different on target only.
+func syncViaSSH(_ context.Context, s *impl, target string, localDir string, stats *syncStats) error {
+	return copyDirIncremental(s.execer, target, localDir, s.pathResolver.RootFSRegistryPath(), stats)
 }
 
-func syncViaHTTP(ctx context.Context, target string, localDir string) error {
-	sys := &types.SystemContext{
-		DockerInsecureSkipTLSVerify: types.OptionalBoolTrue,
-	}
-
+func syncViaHTTP(ctx context.Context, target string, localDir string, stats *syncStats) error {
 	config, err := handler.NewConfig(localDir, 0)
 	if err != nil {
 		return err
@@ -172,20 +199,7 @@ func syncViaHTTP(ctx context.Context, target string, localDir string) error {
 	if err = httputils.WaitUntilEndpointAlive(probeCtx, "http://"+src); err != nil {
 		return err
 	}
-	opts := &sync.Options{
-		SystemContext: sys,
-		Source:        src,
-		Target:        target,
-		SelectionOptions: []copy.ImageListSelection{
-			copy.CopyAllImages, copy.CopySystemImage,
-		},
-		OmitError: true,
-	}
-
-	if err = sync.ToRegistry(ctx, opts); err != nil && !strings.Contains(err.Error(), "manifest unknown") {
-		return err
-	}
-	return nil
+	return copyRegistry(ctx, src, target, false, stats)
 }
 
 func New(pathResolver constants.PathResolver, execer exec.Interface, mounts []v2.MountImage) filesystem.RegistrySyncer {
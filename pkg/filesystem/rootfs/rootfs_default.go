@@ -36,6 +36,7 @@ import (
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/maps"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 )
 
@@ -114,31 +115,28 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 	}
 	rootfsEnvs := v2.MergeEnvWithBuiltinKeys(rootfs.Env, *rootfs)
 
-	for idx := range ipList {
-		ip := ipList[idx]
-		eg.Go(func() error {
-			var renderingRequired bool
-			for i := range f.mounts {
-				if f.mounts[i].IsRootFs() || f.mounts[i].IsPatch() {
-					renderingRequired = true
-					// contents in rootfs/patch type images cannot be replicated asynchronously
-					if err := copyFn(f.mounts[i], ip, target); err != nil {
-						return err
-					}
+	// every host is mounted independently, the failed ones are reported by a HostErrors
+	if err := parallel.Run(ipList, func(ip string) error {
+		var renderingRequired bool
+		for i := range f.mounts {
+			if f.mounts[i].IsRootFs() || f.mounts[i].IsPatch() {
+				renderingRequired = true
+				// contents in rootfs/patch type images cannot be replicated asynchronously
+				if err := copyFn(f.mounts[i], ip, target); err != nil {
+					return err
 				}
 			}
-			if !renderingRequired {
-				return nil
-			}
-			envs := envProcessor.Getenv(ip)
-			envs = maps.Merge(rootfsEnvs, envs)
-			envs[v2.ImageRunModeEnvSysKey] = strings.Join(cluster.GetRolesByIP(ip), ",")
-			renderCommand := getRenderCommand(pathResolver.RootFSSealctlPath(), target)
+		}
+		if !renderingRequired {
+			return nil
+		}
+		envs := envProcessor.Getenv(ip)
+		envs = maps.Merge(rootfsEnvs, envs)
+		envs[v2.ImageRunModeEnvSysKey] = strings.Join(cluster.GetRolesByIP(ip), ",")
+		renderCommand := getRenderCommand(pathResolver.RootFSSealctlPath(), target)
 
-			return execer.CmdAsync(ip, stringsutil.RenderShellWithEnv(renderCommand, envs))
-		})
-	}
-	if err := eg.Wait(); err != nil {
+		return execer.CmdAsync(ip, stringsutil.RenderShellWithEnv(renderCommand, envs))
+	}); err != nil {
 		return err
 	}
 
@@ -176,20 +174,15 @@ func (f *defaultRootfs) unmountRootfs(cluster *v2.Cluster, ipList []string) erro
 	clusterRootfsDir := constants.NewPathResolver(cluster.Name).Root()
 	rmRootfs := fmt.Sprintf("rm -rf %s", clusterRootfsDir)
 	deleteHomeDirCmd := fmt.Sprintf("rm -rf %s", constants.ClusterDir(cluster.Name))
-	eg, _ := errgroup.WithContext(context.Background())
 	sshClient := ssh.NewCacheClientFromCluster(cluster, true)
 	execer, err := exec.New(sshClient)
 	if err != nil {
 		return err
 	}
 
-	for _, IP := range ipList {
-		ip := IP
-		eg.Go(func() error {
-			return execer.CmdAsync(ip, rmRootfs, deleteHomeDirCmd)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(ipList, func(ip string) error {
+		return execer.CmdAsync(ip, rmRootfs, deleteHomeDirCmd)
+	})
 }
 
 func renderTemplatesWithEnv(mountDir string, ipList []string, p env.Interface, envs map[string]string) error {
@@ -15,17 +15,15 @@
 package guest
 
 import (
-	"context"
 	"strings"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/fork/golang/expansion"
 	"github.com/labring/sealos/pkg/env"
 	"github.com/labring/sealos/pkg/exec"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/maps"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 )
 
@@ -51,18 +49,13 @@ func (d *Default) Apply(cluster *v2.Cluster, mounts []v2.MountImage, targetHosts
 	for i, m := range mounts {
 		switch {
 		case m.IsRootFs(), m.IsPatch():
-			eg, ctx := errgroup.WithContext(context.Background())
-			for j := range targetHosts {
-				node := targetHosts[j]
+			if err := parallel.Run(targetHosts, func(node string) error {
 				envs := maps.Merge(m.Env, envGetter.Getenv(node))
 				cmds := formalizeImageCommands(cluster, i, m, envs)
-				eg.Go(func() error {
-					return execer.CmdAsyncWithContext(ctx, node,
-						stringsutil.RenderShellWithEnv(strings.Join(cmds, "; "), envs),
-					)
-				})
-			}
-			if err := eg.Wait(); err != nil {
+				return execer.CmdAsync(node,
+					stringsutil.RenderShellWithEnv(strings.Join(cmds, "; "), envs),
+				)
+			}); err != nil {
 				return err
 			}
 		case m.IsApplication():
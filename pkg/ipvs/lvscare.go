@@ -22,6 +22,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/labring/sealos/pkg/constants"
@@ -32,7 +33,9 @@ const (
 	LvsCareCommand = "/usr/bin/lvscare"
 )
 
-func LvsStaticPodYaml(vip string, masters []string, image, name string, options []string) (string, error) {
+// LvsStaticPodYaml returns the static pod of lvscare, which serves /metrics
+// and /healthz on metricsPort of node if it's not 0.
+func LvsStaticPodYaml(vip string, masters []string, image, name string, metricsPort int, options []string) (string, error) {
 	if vip == "" || len(masters) == 0 {
 		return "", fmt.Errorf("vip and mster not allow empty")
 	}
@@ -44,18 +47,39 @@ func LvsStaticPodYaml(vip string, masters []string, image, name string, options
 		args = append(args, "--rs")
 		args = append(args, m)
 	}
+	if metricsPort > 0 {
+		args = append(args, "--metrics-bind-address", fmt.Sprintf("0.0.0.0:%d", metricsPort))
+	}
 	if len(options) > 0 {
 		args = append(args, options...)
 	}
 	flag := true
-	pod := componentPod(v1.Container{
+	container := v1.Container{
 		Name:            name,
 		Image:           image,
 		Command:         []string{LvsCareCommand},
 		Args:            args,
 		ImagePullPolicy: v1.PullIfNotPresent,
 		SecurityContext: &v1.SecurityContext{Privileged: &flag},
-	})
+	}
+	if metricsPort > 0 {
+		container.Ports = []v1.ContainerPort{
+			{Name: "metrics", ContainerPort: int32(metricsPort), Protocol: v1.ProtocolTCP},
+		}
+		container.LivenessProbe = &v1.Probe{
+			ProbeHandler: v1.ProbeHandler{
+				HTTPGet: &v1.HTTPGetAction{
+					Host: "127.0.0.1",
+					Path: "/healthz",
+					Port: intstr.FromInt(metricsPort),
+				},
+			},
+			InitialDelaySeconds: 10,
+			PeriodSeconds:       10,
+			FailureThreshold:    3,
+		}
+	}
+	pod := componentPod(container)
 	yaml, err := PodToYaml(pod)
 	if err != nil {
 		return "", err
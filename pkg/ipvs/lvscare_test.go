@@ -64,14 +64,69 @@ spec:
       type: ""
     name: lib-modules
 status: {}
+`,
+	`apiVersion: v1
+kind: Pod
+metadata:
+  creationTimestamp: null
+  name: kube-sealos-lvscare
+  namespace: kube-system
+spec:
+  containers:
+  - args:
+    - care
+    - --vs
+    - 10.10.10.10
+    - --health-path
+    - /healthz
+    - --health-schem
+    - https
+    - --rs
+    - 116.31.96.134:6443
+    - --metrics-bind-address
+    - 0.0.0.0:10253
+    - --aa
+    command:
+    - /usr/bin/lvscare
+    image: fanux/lvscare:latest
+    imagePullPolicy: IfNotPresent
+    livenessProbe:
+      failureThreshold: 3
+      httpGet:
+        host: 127.0.0.1
+        path: /healthz
+        port: 10253
+      initialDelaySeconds: 10
+      periodSeconds: 10
+    name: kube-sealos-lvscare
+    ports:
+    - containerPort: 10253
+      name: metrics
+      protocol: TCP
+    resources: {}
+    securityContext:
+      privileged: true
+    volumeMounts:
+    - mountPath: /lib/modules
+      name: lib-modules
+      readOnly: true
+  hostNetwork: true
+  priorityClassName: system-node-critical
+  volumes:
+  - hostPath:
+      path: /lib/modules
+      type: ""
+    name: lib-modules
+status: {}
 `,
 }
 
 func TestLvsStaticPodYaml(t *testing.T) {
 	type args struct {
-		vip     string
-		masters []string
-		image   string
+		vip         string
+		masters     []string
+		image       string
+		metricsPort int
 	}
 	tests := []struct {
 		name string
@@ -84,13 +139,24 @@ func TestLvsStaticPodYaml(t *testing.T) {
 				"10.10.10.10",
 				[]string{"116.31.96.134:6443", "116.31.96.135:6443", "116.31.96.136:6443"},
 				"fanux/lvscare:latest",
+				0,
 			},
 			want[0],
 		},
+		{
+			"test generate lvscare static pod with metrics port",
+			args{
+				"10.10.10.10",
+				[]string{"116.31.96.134:6443"},
+				"fanux/lvscare:latest",
+				10253,
+			},
+			want[1],
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got, _ := LvsStaticPodYaml(tt.args.vip, tt.args.masters, tt.args.image, constants.LvsCareStaticPodName, []string{"--aa"}); got != tt.want {
+			if got, _ := LvsStaticPodYaml(tt.args.vip, tt.args.masters, tt.args.image, constants.LvsCareStaticPodName, tt.args.metricsPort, []string{"--aa"}); got != tt.want {
 				t.Errorf("LvsStaticPodYaml() = %v, want %v", got, tt.want)
 			}
 		})
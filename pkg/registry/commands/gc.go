@@ -0,0 +1,52 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/registry/gc"
+)
+
+func NewRegistryGCCmd(examplePrefix string) *cobra.Command {
+	opts := gc.Options{}
+
+	var registryGCCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "delete images not in use from registry and free the disk space",
+		Long: `Delete the images of registry which are neither used by pods nor
+in the cluster images, then collect the blobs of them on every registry host.`,
+		Example: fmt.Sprintf(`
+  %[1]s gc --dry-run
+  %[1]s gc --keep-last 3`, examplePrefix),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := opts.Validate()
+			if err != nil {
+				return err
+			}
+			if err := opts.Run(cmd.Context(), cluster); err != nil {
+				return fmt.Errorf("registry gc error: %v", err)
+			}
+			return nil
+		},
+	}
+	opts.RegisterFlags(registryGCCmd.Flags())
+	return registryGCCmd
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/filesystem/registry"
+	"github.com/labring/sealos/pkg/ssh"
+	fileutil "github.com/labring/sealos/pkg/utils/file"
+)
+
+func NewRegistryReplicateCmd(examplePrefix string) *cobra.Command {
+	var clusterName string
+
+	var registryReplicateCmd = &cobra.Command{
+		Use:   "replicate",
+		Short: "sync the images among the replicas of HA registry",
+		Long: `Copy the images only on some replicas of HA registry to the first one,
+the primary, then copy the images of the primary to the other replicas.`,
+		Example: fmt.Sprintf(`
+  %[1]s replicate
+  %[1]s replicate -c default`, examplePrefix),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clusterPath := constants.Clusterfile(clusterName)
+			if !fileutil.IsExist(clusterPath) {
+				return fmt.Errorf("cluster %s not exist", clusterName)
+			}
+			clusterFile := clusterfile.NewClusterFile(clusterPath)
+			if err := clusterFile.Process(); err != nil {
+				return fmt.Errorf("cluster %s process error: %+v", clusterName, err)
+			}
+			cluster := clusterFile.GetCluster()
+			if !cluster.IsRegistryHA() {
+				return errors.New("registry of the cluster is not HA")
+			}
+			execer, err := exec.New(ssh.NewCacheClientFromCluster(cluster, true))
+			if err != nil {
+				return err
+			}
+			syncer := registry.New(constants.NewPathResolver(cluster.GetName()), execer, nil)
+			if err := syncer.SyncReplicas(cmd.Context(), cluster.GetRegistryIPAndPortList()...); err != nil {
+				return fmt.Errorf("registry replicate error: %v", err)
+			}
+			return nil
+		},
+	}
+	registryReplicateCmd.Flags().StringVarP(&clusterName, "cluster-name", "c", "default", "cluster name")
+	return registryReplicateCmd
+}
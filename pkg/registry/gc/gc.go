@@ -0,0 +1,209 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/pflag"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/registry/helpers"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	fileutil "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	diskUsageCmd = "du -sb %s | cut -f1"
+	removeCmd    = "cd %s/" + repositoriesDir + " && rm -rf %s"
+	// the registry is stopped while the blobs are collected, otherwise the
+	// blobs of an image being pushed could be deleted
+	garbageCollectCmd = "systemctl stop registry && { registry garbage-collect %s; rc=$?; systemctl start registry; exit $rc; }"
+)
+
+type Options struct {
+	ClusterName string
+	ConfigPath  string
+	KeepLast    int
+	DryRun      bool
+	Force       bool
+	execer      exec.Interface
+}
+
+func (o *Options) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.ClusterName, "cluster-name", "c", "default", "cluster name")
+	fs.StringVar(&o.ConfigPath, "config", "/etc/registry/registry_config.yml", "registry config file path on the registry hosts")
+	fs.IntVar(&o.KeepLast, "keep-last", 0, "number of the latest tags kept in every repository even if they are not in use")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "print the images to delete without deleting them")
+	fs.BoolVar(&o.Force, "force", false, "delete the images without confirmation")
+}
+
+func (o *Options) Validate() (*v1beta1.Cluster, error) {
+	if o.ClusterName == "" {
+		return nil, errors.New("cluster name is empty")
+	}
+	if o.KeepLast < 0 {
+		return nil, errors.New("keep-last must not be negative")
+	}
+	clusterPath := constants.Clusterfile(o.ClusterName)
+	if !fileutil.IsExist(clusterPath) {
+		return nil, fmt.Errorf("cluster %s not exist", o.ClusterName)
+	}
+	clusterFile := clusterfile.NewClusterFile(clusterPath)
+	if err := clusterFile.Process(); err != nil {
+		return nil, fmt.Errorf("cluster %s process error: %+v", o.ClusterName, err)
+	}
+	return clusterFile.GetCluster(), nil
+}
+
+// Run deletes the images of registry not used by pods and cluster images,
+// then collects the blobs of them on every registry host.
+func (o *Options) Run(ctx context.Context, cluster *v1beta1.Cluster) error {
+	if o.execer == nil {
+		execer, err := exec.New(ssh.NewCacheClientFromCluster(cluster, true))
+		if err != nil {
+			return err
+		}
+		o.execer = execer
+	}
+	refs := newReferences()
+	if err := refs.addMounts(cluster.Status.Mounts); err != nil {
+		return err
+	}
+	// without the pods, images in use would be deleted
+	if err := refs.addPods(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to get images in use: %v", err)
+	}
+	root := constants.NewPathResolver(cluster.Name).RootFSPath()
+	rc := helpers.GetRegistryInfo(o.execer, root, cluster.GetRegistryIPAndPort())
+
+	var freed int64
+	for _, host := range cluster.GetRegistryIPAndPortList() {
+		n, err := o.runOnHost(host, rc.Data, refs)
+		if err != nil {
+			return fmt.Errorf("failed to collect garbage of registry on %s: %v", host, err)
+		}
+		freed += n
+	}
+	if !o.DryRun {
+		logger.Info("registry garbage collection finished, %s freed", units.BytesSize(float64(freed)))
+	}
+	return nil
+}
+
+func (o *Options) runOnHost(host, dataDir string, refs *references) (int64, error) {
+	out, err := o.execer.Cmd(host, fmt.Sprintf(listTagsCmd, dataDir))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list images: %v", err)
+	}
+	tags := parseTagLinks(string(out))
+	plan := &prunePlan{Tags: planTags(tags, refs, o.KeepLast)}
+	if len(plan.Tags) > 0 {
+		manifests, err := o.catManifests(host, dataDir, tags, plan.Tags)
+		if err != nil {
+			return 0, err
+		}
+		plan.Revisions = planRevisions(tags, plan.Tags, refs, manifests)
+	}
+	for _, t := range plan.Tags {
+		if o.DryRun {
+			logger.Info("[dry-run] %s: would delete %s (%s)", host, t, t.Digest)
+		} else {
+			logger.Info("%s: deleting %s (%s)", host, t, t.Digest)
+		}
+	}
+	if o.DryRun {
+		logger.Info("[dry-run] %s: %d of %d images would be deleted", host, len(plan.Tags), len(tags))
+		return 0, nil
+	}
+	if len(plan.Tags) > 0 {
+		if !o.Force {
+			prompt := fmt.Sprintf("are you sure to delete %d images of registry on %s?", len(plan.Tags), host)
+			if yes, err := confirm.Confirm(prompt, "you have canceled to delete images of registry !"); err != nil || !yes {
+				return 0, err
+			}
+		}
+		paths := make([]string, 0, len(plan.Tags))
+		for _, p := range plan.removePaths() {
+			paths = append(paths, "'"+p+"'")
+		}
+		if err = o.execer.CmdAsync(host, fmt.Sprintf(removeCmd, dataDir, strings.Join(paths, " "))); err != nil {
+			return 0, fmt.Errorf("failed to delete manifests: %v", err)
+		}
+	}
+
+	before, err := o.diskUsage(host, dataDir)
+	if err != nil {
+		return 0, err
+	}
+	if err = o.execer.CmdAsync(host, fmt.Sprintf(garbageCollectCmd, o.ConfigPath)); err != nil {
+		return 0, fmt.Errorf("failed to collect blobs: %v", err)
+	}
+	after, err := o.diskUsage(host, dataDir)
+	if err != nil {
+		return 0, err
+	}
+	freed := before - after
+	if freed < 0 {
+		freed = 0
+	}
+	logger.Info("%s: deleted %d images, %s freed", host, len(plan.Tags), units.BytesSize(float64(freed)))
+	return freed, nil
+}
+
+// catManifests reads the manifests of the tags of the repositories with deleted tags.
+func (o *Options) catManifests(host, dataDir string, tags, deleted []tagLink) (map[string][]byte, error) {
+	repos := map[string]bool{}
+	for _, t := range deleted {
+		repos[t.Repo] = true
+	}
+	set := map[string]bool{}
+	for _, t := range tags {
+		if repos[t.Repo] && strings.HasPrefix(t.Digest, "sha256:") {
+			set[t.Digest] = true
+		}
+	}
+	digests := make([]string, 0, len(set))
+	for d := range set {
+		digests = append(digests, d)
+	}
+	sort.Strings(digests)
+	out, err := o.execer.Cmd(host, fmt.Sprintf(catManifestsCmd, dataDir, strings.Join(digests, " ")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %v", err)
+	}
+	return parseManifests(string(out)), nil
+}
+
+func (o *Options) diskUsage(host, dir string) (int64, error) {
+	out, err := o.execer.Cmd(host, fmt.Sprintf(diskUsageCmd, dir))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get disk usage of %s: %v", dir, err)
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
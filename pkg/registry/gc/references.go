@@ -0,0 +1,124 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// references are the images in use, the registry keeps them.
+type references struct {
+	// tags are repo:tag, the domain of image is dropped as image-cri-shim
+	// pulls every image from sealos.hub by its repository.
+	tags    map[string]bool
+	digests map[string]bool
+}
+
+func newReferences() *references {
+	return &references{tags: map[string]bool{}, digests: map[string]bool{}}
+}
+
+func (r *references) has(t tagLink) bool {
+	return r.tags[t.String()] || r.digests[t.Digest]
+}
+
+func (r *references) addTag(t tagLink) {
+	r.tags[t.String()] = true
+	if t.Digest != "" {
+		r.digests[t.Digest] = true
+	}
+}
+
+// addImage adds an image of pod spec or an image ID of pod status.
+func (r *references) addImage(image string) {
+	// e.g. docker-pullable://nginx@sha256:...
+	if i := strings.Index(image, "://"); i >= 0 {
+		image = image[i+3:]
+	}
+	if image == "" {
+		return
+	}
+	if strings.HasPrefix(image, "sha256:") {
+		r.digests[image] = true
+		return
+	}
+	ref, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		logger.Debug("skip invalid image %s: %v", image, err)
+		return
+	}
+	switch ref := ref.(type) {
+	case name.Digest:
+		r.digests[ref.DigestStr()] = true
+	case name.Tag:
+		r.tags[ref.RepositoryStr()+":"+ref.TagStr()] = true
+	}
+}
+
+// addPods adds the images of all the pods in cluster.
+func (r *references) addPods(ctx context.Context, cluster *v2.Cluster) error {
+	data := constants.NewPathResolver(cluster.Name)
+	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
+	if err != nil {
+		return err
+	}
+	pods, err := c.Kubernetes().CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	for _, pod := range pods.Items {
+		var containers []corev1.Container
+		containers = append(containers, pod.Spec.InitContainers...)
+		containers = append(containers, pod.Spec.Containers...)
+		for _, c := range containers {
+			r.addImage(c.Image)
+		}
+		var statuses []corev1.ContainerStatus
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, s := range statuses {
+			r.addImage(s.ImageID)
+		}
+	}
+	return nil
+}
+
+// addMounts adds the images in the registries of the cluster images.
+func (r *references) addMounts(mounts []v2.MountImage) error {
+	for _, m := range mounts {
+		tags, err := localTagLinks(filepath.Join(m.MountPoint, constants.RegistryDirName))
+		if err != nil {
+			return fmt.Errorf("failed to list images of %s: %v", m.ImageName, err)
+		}
+		for _, t := range tags {
+			r.addTag(t)
+		}
+	}
+	return nil
+}
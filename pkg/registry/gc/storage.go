@@ -0,0 +1,259 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the layout of the filesystem storage of distribution registry
+const (
+	repositoriesDir = "docker/registry/v2/repositories"
+	blobsDir        = "docker/registry/v2/blobs"
+	tagsPath        = "/_manifests/tags/"
+	tagLinkSuffix   = "/current/link"
+)
+
+const (
+	// listTagsCmd prints the modification time, path and digest of every tag link.
+	listTagsCmd = `cd %s/` + repositoriesDir + ` 2>/dev/null || exit 0; ` +
+		`find . -path '*` + tagsPath + `*` + tagLinkSuffix + `' -type f | ` +
+		`while read -r f; do echo "$(stat -c %%Y "$f") $f $(cat "$f")"; done`
+	// catManifestsCmd prints every manifest after a line of its digest.
+	catManifestsCmd = `cd %s/` + blobsDir + ` && for d in %s; do h=${d#sha256:}; echo "==> $d"; cat sha256/$(echo $h | cut -c1-2)/$h/data; echo; done`
+)
+
+// tagLink is a tag of a repository in registry storage.
+type tagLink struct {
+	Repo   string
+	Tag    string
+	Digest string
+	// ModTime is when the tag was pushed, in unix seconds.
+	ModTime int64
+}
+
+func (t tagLink) String() string {
+	return fmt.Sprintf("%s:%s", t.Repo, t.Tag)
+}
+
+// parseTagLinkPath returns the repository and tag of a tag link path relative
+// to the repositories dir, e.g. ./library/nginx/_manifests/tags/1.25/current/link.
+func parseTagLinkPath(rel string) (repo, tag string, ok bool) {
+	rel = strings.TrimPrefix(filepath.ToSlash(rel), "./")
+	i := strings.Index(rel, tagsPath)
+	if i <= 0 || !strings.HasSuffix(rel, tagLinkSuffix) {
+		return "", "", false
+	}
+	tag = strings.TrimSuffix(rel[i+len(tagsPath):], tagLinkSuffix)
+	if tag == "" || strings.Contains(tag, "/") {
+		return "", "", false
+	}
+	return rel[:i], tag, true
+}
+
+// parseTagLinks parses the output of listTagsCmd.
+func parseTagLinks(out string) []tagLink {
+	var tags []tagLink
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		modTime, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		repo, tag, ok := parseTagLinkPath(fields[1])
+		if !ok {
+			continue
+		}
+		tags = append(tags, tagLink{Repo: repo, Tag: tag, Digest: fields[2], ModTime: modTime})
+	}
+	return tags
+}
+
+// localTagLinks returns the tags of the registry storage in dir.
+func localTagLinks(dir string) ([]tagLink, error) {
+	root := filepath.Join(dir, repositoriesDir)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var tags []tagLink
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		repo, tag, ok := parseTagLinkPath(rel)
+		if !ok {
+			return nil
+		}
+		digest, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, tagLink{Repo: repo, Tag: tag, Digest: strings.TrimSpace(string(digest))})
+		return nil
+	})
+	return tags, err
+}
+
+// prunePlan is what to remove from the registry storage.
+type prunePlan struct {
+	// Tags are the tags deleted.
+	Tags []tagLink
+	// Revisions are the manifests deleted by repository, their blobs are
+	// freed by the blob garbage collection.
+	Revisions map[string][]string
+}
+
+// planTags returns the tags to delete, a tag is kept if it's referenced or
+// one of the keepLast latest tags of its repository.
+func planTags(tags []tagLink, refs *references, keepLast int) []tagLink {
+	byRepo := map[string][]tagLink{}
+	for _, t := range tags {
+		byRepo[t.Repo] = append(byRepo[t.Repo], t)
+	}
+	var deleted []tagLink
+	for _, repoTags := range byRepo {
+		sort.Slice(repoTags, func(i, j int) bool {
+			if repoTags[i].ModTime != repoTags[j].ModTime {
+				return repoTags[i].ModTime > repoTags[j].ModTime
+			}
+			return repoTags[i].Tag > repoTags[j].Tag
+		})
+		for i, t := range repoTags {
+			if i < keepLast || refs.has(t) {
+				continue
+			}
+			deleted = append(deleted, t)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		return deleted[i].String() < deleted[j].String()
+	})
+	return deleted
+}
+
+// planRevisions returns the manifests of the deleted tags which are not
+// used by the kept ones, children of image indexes included, manifests are
+// the manifests by digest of a repository.
+func planRevisions(tags, deleted []tagLink, refs *references, manifests map[string][]byte) map[string][]string {
+	isDeleted := map[string]bool{}
+	for _, t := range deleted {
+		isDeleted[t.String()] = true
+	}
+	kept := map[string]map[string]bool{}
+	for _, t := range tags {
+		if isDeleted[t.String()] {
+			continue
+		}
+		if kept[t.Repo] == nil {
+			kept[t.Repo] = map[string]bool{}
+		}
+		for _, d := range withChildren(t.Digest, manifests) {
+			kept[t.Repo][d] = true
+		}
+	}
+	revisions := map[string][]string{}
+	seen := map[string]bool{}
+	for _, t := range deleted {
+		for _, d := range withChildren(t.Digest, manifests) {
+			key := t.Repo + "@" + d
+			if seen[key] || kept[t.Repo][d] || refs.digests[d] {
+				continue
+			}
+			seen[key] = true
+			revisions[t.Repo] = append(revisions[t.Repo], d)
+		}
+	}
+	return revisions
+}
+
+// withChildren returns digest and the manifests of it if it's an image index.
+func withChildren(digest string, manifests map[string][]byte) []string {
+	digests := []string{digest}
+	var index struct {
+		Manifests []struct {
+			Digest string `json:"digest"`
+		} `json:"manifests"`
+	}
+	if err := json.Unmarshal(manifests[digest], &index); err != nil {
+		return digests
+	}
+	for _, m := range index.Manifests {
+		digests = append(digests, withChildren(m.Digest, manifests)...)
+	}
+	return digests
+}
+
+// parseManifests parses the output of catManifestsCmd.
+func parseManifests(out string) map[string][]byte {
+	manifests := map[string][]byte{}
+	var digest string
+	var content strings.Builder
+	flush := func() {
+		if digest != "" {
+			manifests[digest] = []byte(content.String())
+		}
+		content.Reset()
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "==> ") {
+			flush()
+			digest = strings.TrimPrefix(line, "==> ")
+			continue
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+	flush()
+	return manifests
+}
+
+// removePaths returns the paths of the plan relative to the repositories dir.
+func (p *prunePlan) removePaths() []string {
+	var paths []string
+	for _, t := range p.Tags {
+		paths = append(paths, t.Repo+strings.TrimSuffix(tagsPath, "/")+"/"+t.Tag)
+	}
+	repos := make([]string, 0, len(p.Revisions))
+	for repo := range p.Revisions {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		for _, d := range p.Revisions[repo] {
+			algo, hex, ok := strings.Cut(d, ":")
+			if !ok {
+				continue
+			}
+			paths = append(paths, fmt.Sprintf("%s/_manifests/revisions/%s/%s", repo, algo, hex))
+		}
+	}
+	return paths
+}
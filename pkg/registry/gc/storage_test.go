@@ -0,0 +1,108 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagLinks(t *testing.T) {
+	out := `1697000000 ./library/nginx/_manifests/tags/1.25/current/link sha256:aaa
+1697000100 ./labring/helm/_manifests/tags/v3.8.2/current/link sha256:bbb
+1697000100 ./labring/helm/_manifests/tags/v3.8.2/index/sha256/bbb/link sha256:bbb
+broken line
+`
+	want := []tagLink{
+		{Repo: "library/nginx", Tag: "1.25", Digest: "sha256:aaa", ModTime: 1697000000},
+		{Repo: "labring/helm", Tag: "v3.8.2", Digest: "sha256:bbb", ModTime: 1697000100},
+	}
+	if got := parseTagLinks(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTagLinks() = %v, want %v", got, want)
+	}
+}
+
+func TestAddImage(t *testing.T) {
+	refs := newReferences()
+	for _, image := range []string{
+		"nginx:1.25",
+		"sealos.hub:5000/labring/helm:v3.8.2",
+		"registry.k8s.io/pause",
+		"docker-pullable://docker.io/library/busybox@sha256:0000000000000000000000000000000000000000000000000000000000000001",
+		"sha256:0000000000000000000000000000000000000000000000000000000000000002",
+	} {
+		refs.addImage(image)
+	}
+	wantTags := map[string]bool{"library/nginx:1.25": true, "labring/helm:v3.8.2": true, "pause:latest": true}
+	if !reflect.DeepEqual(refs.tags, wantTags) {
+		t.Errorf("tags = %v, want %v", refs.tags, wantTags)
+	}
+	if len(refs.digests) != 2 {
+		t.Errorf("digests = %v, want 2 digests", refs.digests)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tags := []tagLink{
+		{Repo: "app", Tag: "v1", Digest: "sha256:idx1", ModTime: 1},
+		{Repo: "app", Tag: "v2", Digest: "sha256:idx2", ModTime: 2},
+		{Repo: "app", Tag: "v3", Digest: "sha256:m3", ModTime: 3},
+		{Repo: "app", Tag: "v4", Digest: "sha256:m4", ModTime: 4},
+		{Repo: "pause", Tag: "3.9", Digest: "sha256:p", ModTime: 1},
+	}
+	refs := newReferences()
+	refs.addImage("pause:3.9")
+	refs.digests["sha256:m3"] = true
+	manifests := parseManifests(`==> sha256:idx1
+{"manifests":[{"digest":"sha256:amd64"},{"digest":"sha256:arm64-old"}]}
+==> sha256:idx2
+{"manifests":[{"digest":"sha256:amd64"},{"digest":"sha256:arm64"}]}
+`)
+
+	deleted := planTags(tags, refs, 1)
+	want := []tagLink{tags[0], tags[1]}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Fatalf("planTags() = %v, want %v", deleted, want)
+	}
+	// the referenced v3 and the latest v4 are kept
+	revisions := planRevisions(tags, deleted, refs, manifests)
+	wantRevisions := map[string][]string{"app": {"sha256:idx1", "sha256:amd64", "sha256:arm64-old", "sha256:idx2", "sha256:arm64"}}
+	if !reflect.DeepEqual(revisions, wantRevisions) {
+		t.Errorf("planRevisions() = %v, want %v", revisions, wantRevisions)
+	}
+
+	// a child shared with a kept index is kept
+	deleted = planTags(tags, refs, 3)
+	if !reflect.DeepEqual(deleted, []tagLink{tags[0]}) {
+		t.Fatalf("planTags() = %v, want %v", deleted, tags[:1])
+	}
+	revisions = planRevisions(tags, deleted, refs, manifests)
+	wantRevisions = map[string][]string{"app": {"sha256:idx1", "sha256:arm64-old"}}
+	if !reflect.DeepEqual(revisions, wantRevisions) {
+		t.Errorf("planRevisions() = %v, want %v", revisions, wantRevisions)
+	}
+
+	plan := &prunePlan{Tags: deleted, Revisions: revisions}
+	wantPaths := []string{
+		"app/_manifests/tags/v1",
+		"app/_manifests/revisions/sha256/idx1",
+		"app/_manifests/revisions/sha256/arm64-old",
+	}
+	if got := plan.removePaths(); !reflect.DeepEqual(got, wantPaths) {
+		t.Errorf("removePaths() = %v, want %v", got, wantPaths)
+	}
+}
@@ -16,6 +16,7 @@ package helpers
 
 import (
 	"fmt"
+	"net"
 	"path"
 
 	"github.com/labring/image-cri-shim/pkg/types"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -78,3 +80,48 @@ func GetImageCRIShimInfo(execer exec.Interface, config, defaultIP string) *types
 	logger.Debug("show registry info, addr: %s,  auth: %s", readConfig.Address, readConfig.Auth)
 	return readConfig
 }
+
+// lvscare probes the registry replicas by /v2/, which is 401 without auth.
+var replicaProbeOptions = []string{"--health-path", "/v2/", "--health-schem", "http", "--health-status", "401"}
+
+// persistenceTimeoutFlag is the flag of lvscare sticking the connections of a
+// client to one real server, which the lvscare of old cluster images lacks.
+const persistenceTimeoutFlag = "persistence-timeout"
+
+// ReplicaLvscareOptions are the options of lvscare balancing the registry
+// replicas, it has its own dummy interface apart from the one of apiserver.
+// If persistent, the connections of a client stick to a replica, otherwise the
+// blobs and manifest of an image pushed through the virtual IP are split
+// across them.
+func ReplicaLvscareOptions(persistent bool) []string {
+	options := []string{"--iface", constants.LvsCareRegistryIface}
+	if persistent {
+		options = append(options, "--"+persistenceTimeoutFlag, "10m")
+	}
+	return append(options, replicaProbeOptions...)
+}
+
+// ReplicaLvscareOptionsOn returns the ReplicaLvscareOptions supported by the
+// sealctl and lvscare of the cluster image on host.
+func ReplicaLvscareOptionsOn(remoter *ssh.Remote, host string) []string {
+	persistent := remoter.IPVSSupports(host, persistenceTimeoutFlag)
+	if !persistent {
+		logger.Warn("lvscare of the cluster image on %s doesn't support --%s, images pushed to the registry virtual IP may be split across replicas",
+			host, persistenceTimeoutFlag)
+	}
+	return ReplicaLvscareOptions(persistent)
+}
+
+// ReplicaAddresses returns the addresses of registry replicas in HA mode.
+func ReplicaAddresses(cluster *v1beta1.Cluster, rc *v1beta1.RegistryConfig) []string {
+	var addrs []string
+	for _, ip := range cluster.GetRegistryIPList() {
+		addrs = append(addrs, net.JoinHostPort(ip, rc.Port))
+	}
+	return addrs
+}
+
+// ReplicaVIPAddress returns the virtual address of the registry replicas.
+func ReplicaVIPAddress(cluster *v1beta1.Cluster, rc *v1beta1.RegistryConfig) string {
+	return net.JoinHostPort(cluster.GetRegistryVIP(), rc.Port)
+}
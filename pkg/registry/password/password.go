@@ -19,6 +19,8 @@ package password
 import (
 	"fmt"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/labring/image-cri-shim/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -35,6 +37,9 @@ import (
 
 type RegistryType string
 
+// imageShimReloadWait is how long to wait for image-cri-shim to handle SIGHUP.
+var imageShimReloadWait = time.Second
+
 const (
 	RegistryTypeDocker     RegistryType = "docker"
 	RegistryTypeContainerd RegistryType = "containerd"
@@ -90,12 +95,35 @@ func (m *upgrade) UpdateImageShimConfig(rc *types.Config, target, host string) e
 		if err = m.SSHInterface.Copy(host, configPath, target); err != nil {
 			return err
 		}
-		if err = m.SSHInterface.CmdAsync(host, "systemctl restart image-cri-shim"); err != nil {
-			return err
-		}
+		return m.reloadImageShim(host)
 	}
 	return nil
 }
+
+// reloadImageShim sends SIGHUP to image-cri-shim to reload the config without
+// restart. The versions not supporting reload exit on SIGHUP, so it's restarted
+// if the process is changed or not running afterwards.
+func (m *upgrade) reloadImageShim(host string) error {
+	pid := m.imageShimPID(host)
+	if pid != "" && pid != "0" {
+		if err := m.SSHInterface.CmdAsync(host, "systemctl kill -s HUP image-cri-shim"); err == nil {
+			time.Sleep(imageShimReloadWait)
+			if m.imageShimPID(host) == pid {
+				return nil
+			}
+		}
+	}
+	logger.Info("image-cri-shim on %s is not reloaded, restarting it", host)
+	return m.SSHInterface.CmdAsync(host, "systemctl restart image-cri-shim")
+}
+
+func (m *upgrade) imageShimPID(host string) string {
+	out, err := m.SSHInterface.CmdToString(host, "systemctl show -p MainPID --value image-cri-shim", "")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
 func (m *upgrade) UpdateRegistryPasswd(rc *v1beta1.RegistryConfig, target, host string, registryType RegistryType) error {
 	htpasswdPath, err := m.mk.configLocalHtpasswd(path.Join(constants.ClusterDir(m.Cluster), constants.EtcDirName), rc)
 	if err != nil {
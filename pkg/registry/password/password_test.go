@@ -0,0 +1,115 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/labring/sealos/pkg/exec"
+)
+
+// fakeSystemd returns the PIDs of image-cri-shim in order and records the
+// commands run.
+type fakeSystemd struct {
+	exec.Interface
+	pids    []string
+	hupErr  error
+	history []string
+}
+
+func (f *fakeSystemd) CmdToString(_, cmd, _ string) (string, error) {
+	f.history = append(f.history, cmd)
+	if len(f.pids) == 0 {
+		return "", errors.New("no such unit")
+	}
+	pid := f.pids[0]
+	f.pids = f.pids[1:]
+	return pid + "\n", nil
+}
+
+func (f *fakeSystemd) CmdAsync(_ string, cmds ...string) error {
+	f.history = append(f.history, cmds...)
+	if cmds[0] == "systemctl kill -s HUP image-cri-shim" {
+		return f.hupErr
+	}
+	return nil
+}
+
+func TestReloadImageShim(t *testing.T) {
+	imageShimReloadWait = 0
+	tests := []struct {
+		name        string
+		pids        []string
+		hupErr      error
+		wantHUP     bool
+		wantRestart bool
+	}{
+		{
+			name:    "reloaded by SIGHUP",
+			pids:    []string{"100", "100"},
+			wantHUP: true,
+		},
+		{
+			name:        "exited on SIGHUP",
+			pids:        []string{"100", "0"},
+			wantHUP:     true,
+			wantRestart: true,
+		},
+		{
+			name:        "restarted by systemd after exiting",
+			pids:        []string{"100", "101"},
+			wantHUP:     true,
+			wantRestart: true,
+		},
+		{
+			name:        "failed to send SIGHUP",
+			pids:        []string{"100"},
+			hupErr:      errors.New("failed"),
+			wantHUP:     true,
+			wantRestart: true,
+		},
+		{
+			name:        "not running",
+			pids:        []string{"0"},
+			wantRestart: true,
+		},
+		{
+			name:        "unknown PID",
+			wantRestart: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSystemd{pids: tt.pids, hupErr: tt.hupErr}
+			m := &upgrade{SSHInterface: fake}
+			if err := m.reloadImageShim("192.168.0.2"); err != nil {
+				t.Fatal(err)
+			}
+			var hup, restart bool
+			for _, cmd := range fake.history {
+				switch cmd {
+				case "systemctl kill -s HUP image-cri-shim":
+					hup = true
+				case "systemctl restart image-cri-shim":
+					restart = true
+				}
+			}
+			if hup != tt.wantHUP || restart != tt.wantRestart {
+				t.Errorf("got SIGHUP %v restart %v, want %v and %v, commands: %v", hup, restart, tt.wantHUP, tt.wantRestart, fake.history)
+			}
+		})
+	}
+}
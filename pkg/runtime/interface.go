@@ -14,6 +14,8 @@
 
 package runtime
 
+import "github.com/labring/sealos/pkg/cert"
+
 type Interface interface {
 	Ruler
 	Init() error
@@ -29,10 +31,20 @@ type Ruler interface {
 }
 
 type CertManager interface {
+	// Renew re-issues all leaf certificates and kubeconfigs of the control plane from the existing CAs.
 	Renew() error
+	// CheckExpiration returns the expiry dates of the control plane certificates on every master.
+	CheckExpiration() ([]cert.HostExpiration, error)
 	UpdateCertSANs(certSANs []string) error
 }
 
+type EtcdManager interface {
+	// SaveSnapshot takes an etcd snapshot on the given master and fetches it to the local file dst.
+	SaveSnapshot(master, dst string) error
+	// RestoreSnapshot restores the local etcd snapshot file src on every master.
+	RestoreSnapshot(src string) error
+}
+
 type Config interface {
 	GetComponents() []any
 }
@@ -15,20 +15,18 @@
 package k3s
 
 import (
-	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/pkg/utils/iputils"
 
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	"github.com/labring/sealos/pkg/utils/rand"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
@@ -114,12 +112,10 @@ func (k *K3s) joinNodes(nodes []string) error {
 	if _, err := k.writeJoinConfigWithCallbacks(agentMode, removeServerFlagsInAgentConfig); err != nil {
 		return err
 	}
-	for i := range nodes {
-		if err := k.joinNode(nodes[i]); err != nil {
-			return err
-		}
-	}
-	return nil
+	// nodes are joined one by one, the failed ones are reported by a HostErrors and the
+	// remaining are skipped once more than --max-failed-hosts nodes failed
+	opts := parallel.Options{MaxParallel: 1, BatchSize: 1, MaxFailedHosts: parallel.DefaultOptions.MaxFailedHosts}
+	return parallel.RunWithOptions(nodes, opts, k.joinNode)
 }
 
 func (k *K3s) getAPIServerPort() int {
@@ -239,17 +235,12 @@ func (k *K3s) copyKubeConfigFileToNodes(hosts ...string) error {
 	if err = file.WriteFile(src, []byte(newData)); err != nil {
 		return errors.WithMessage(err, "write admin.config file failed")
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range hosts {
-		node := node
-		eg.Go(func() error {
-			home, err := k.execer.CmdToString(node, "echo $HOME", "")
-			if err != nil {
-				return err
-			}
-			dst := filepath.Join(home, ".kube", "config")
-			return k.execer.Copy(node, src, dst)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(hosts, func(node string) error {
+		home, err := k.execer.CmdToString(node, "echo $HOME", "")
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(home, ".kube", "config")
+		return k.execer.Copy(node, src, dst)
+	})
 }
@@ -0,0 +1,127 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3s
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	rotateCertificateCmd = "k3s certificate rotate"
+	readyzCmd            = "k3s kubectl get --raw /readyz"
+)
+
+var defaultTLSDir = filepath.Join(defaultDataDir, "server", "tls")
+
+func (k *K3s) UpdateCertSANs(_ []string) error {
+	return errors.New("not implement")
+}
+
+// Renew rotates the certificates of every server one at a time, then restarts the agents
+// so that they request new client certificates.
+func (k *K3s) Renew() error {
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		if err := k.runPipelines(fmt.Sprintf("renew certs of master %s", master),
+			func() error { return k.remoteUtil.InitSystem(master).ServiceStop("k3s") },
+			func() error { return k.execer.CmdAsync(master, rotateCertificateCmd) },
+			func() error { return k.remoteUtil.InitSystem(master).ServiceStart("k3s") },
+			func() error { return k.waitServerReady(master) },
+		); err != nil {
+			return err
+		}
+	}
+	for _, node := range k.cluster.GetNodeIPAndPortList() {
+		logger.Info("restart k3s service on %s", node)
+		if err := k.remoteUtil.InitSystem(node).ServiceRestart("k3s"); err != nil {
+			return fmt.Errorf("failed to restart k3s on node %s: %v", node, err)
+		}
+	}
+	return k.runPipelines("refresh admin kubeconfig",
+		func() error { return os.RemoveAll(k.pathResolver.AdminFile()) },
+		k.pullKubeConfigFromMaster0,
+		func() error { return k.copyKubeConfigFileToNodes(k.cluster.GetMasterIPAndPortList()...) },
+	)
+}
+
+func (k *K3s) waitServerReady(host string) error {
+	return k.waitUntil(host, readyzCmd, "ok", fmt.Sprintf("wait for k3s server %s ready", host))
+}
+
+func (k *K3s) CheckExpiration() ([]cert.HostExpiration, error) {
+	var ret []cert.HostExpiration
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		list, err := k.fetchCertExpiration(master)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check cert expiration of master %s: %v", master, err)
+		}
+		ret = append(ret, cert.HostExpiration{Host: master, Certificates: list})
+	}
+	return ret, nil
+}
+
+func (k *K3s) fetchCertExpiration(master string) ([]cert.Expiration, error) {
+	if err := os.MkdirAll(k.pathResolver.TmpPath(), 0755); err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(k.pathResolver.TmpPath(), "certs-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	out, err := k.execer.CmdToString(master,
+		fmt.Sprintf("ls %s/*.crt %s/etcd/*.crt 2>/dev/null", defaultTLSDir, defaultTLSDir), ",")
+	if err != nil {
+		return nil, err
+	}
+	var list []cert.Expiration
+	for _, src := range strings.Split(out, ",") {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		// etcd certs share the same names with the server ones
+		name := filepath.Base(src)
+		if filepath.Dir(src) == filepath.Join(defaultTLSDir, "etcd") {
+			name = "etcd-" + name
+		}
+		dst := filepath.Join(tmpDir, name)
+		if err = k.execer.Fetch(master, src, dst); err != nil {
+			return nil, err
+		}
+		e, err := cert.LoadCertExpiration(dst)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	dst := filepath.Join(tmpDir, filepath.Base(defaultKubeConfigPath))
+	if err = k.execer.Fetch(master, defaultKubeConfigPath, dst); err != nil {
+		return nil, err
+	}
+	e, err := cert.LoadKubeConfigExpiration(dst)
+	if err != nil {
+		return nil, err
+	}
+	return append(list, *e), nil
+}
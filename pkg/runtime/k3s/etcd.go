@@ -0,0 +1,93 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3s
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+)
+
+const (
+	etcdSnapshotSaveCmd    = "rm -rf %[1]s && k3s etcd-snapshot save --dir %[1]s --name sealos"
+	etcdSnapshotListCmd    = "ls -1t %s | head -n 1"
+	etcdClusterResetCmd    = "k3s server --cluster-reset --cluster-reset-restore-path=%s"
+	etcdRemoveDBCmd        = "rm -rf %s"
+	etcdSnapshotRemoteName = "sealos-snapshot.db"
+)
+
+var (
+	defaultDBDir       = filepath.Join(defaultDataDir, "server", "db")
+	etcdSnapshotTmpDir = filepath.Join(defaultDataDir, "server", "sealos-snapshots")
+)
+
+// SaveSnapshot takes an on-demand snapshot of the embedded etcd on master and fetches it to the local file dst.
+func (k *K3s) SaveSnapshot(master, dst string) error {
+	logger.Info("start to save etcd snapshot on %s", master)
+	if err := k.execer.CmdAsync(master, fmt.Sprintf(etcdSnapshotSaveCmd, etcdSnapshotTmpDir)); err != nil {
+		return fmt.Errorf("failed to save etcd snapshot: %v", err)
+	}
+	defer func() {
+		if err := k.execer.CmdAsync(master, "rm -rf "+etcdSnapshotTmpDir); err != nil {
+			logger.Warn("failed to remove etcd snapshot on %s: %v", master, err)
+		}
+	}()
+	name, err := k.execer.CmdToString(master, fmt.Sprintf(etcdSnapshotListCmd, etcdSnapshotTmpDir), "")
+	if err != nil {
+		return fmt.Errorf("failed to find etcd snapshot: %v", err)
+	}
+	return ssh.FetchWithChecksum(k.execer, master, filepath.Join(etcdSnapshotTmpDir, strings.TrimSpace(name)), dst)
+}
+
+// RestoreSnapshot resets the cluster on master0 with the snapshot, then rejoins the other servers
+// with empty databases so that they sync from master0.
+func (k *K3s) RestoreSnapshot(src string) error {
+	masters := k.cluster.GetMasterIPAndPortList()
+	master0 := k.cluster.GetMaster0IPAndPort()
+	snapshot := filepath.Join(defaultDataDir, "server", etcdSnapshotRemoteName)
+	if err := ssh.CopyWithChecksum(k.execer, master0, src, snapshot); err != nil {
+		return err
+	}
+	if err := parallel.Run(masters, func(master string) error {
+		return k.remoteUtil.InitSystem(master).ServiceStop("k3s")
+	}); err != nil {
+		return fmt.Errorf("failed to stop k3s servers: %v", err)
+	}
+	if err := k.runPipelines(fmt.Sprintf("restore etcd snapshot on %s", master0),
+		func() error { return k.execer.CmdAsync(master0, fmt.Sprintf(etcdClusterResetCmd, snapshot)) },
+		func() error { return k.execer.CmdAsync(master0, "rm -f "+snapshot) },
+		func() error { return k.remoteUtil.InitSystem(master0).ServiceStart("k3s") },
+		func() error { return k.waitServerReady(master0) },
+	); err != nil {
+		return err
+	}
+	for _, master := range masters {
+		if master == master0 {
+			continue
+		}
+		if err := k.runPipelines(fmt.Sprintf("rejoin master %s", master),
+			func() error { return k.execer.CmdAsync(master, fmt.Sprintf(etcdRemoveDBCmd, defaultDBDir)) },
+			func() error { return k.remoteUtil.InitSystem(master).ServiceStart("k3s") },
+			func() error { return k.waitServerReady(master) },
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,17 +15,16 @@
 package k3s
 
 import (
-	"context"
 	"fmt"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	"github.com/labring/sealos/pkg/utils/strings"
 
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/env"
 	"github.com/labring/sealos/pkg/exec"
+	"github.com/labring/sealos/pkg/registry/helpers"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -107,7 +106,7 @@ func (k *K3s) ScaleDown(masters []string, nodes []string) error {
 
 func (k *K3s) Upgrade(version string) error {
 	logger.Info("trying to upgrade to version %s", version)
-	return nil
+	return k.upgrade(version)
 }
 
 func (k *K3s) GetRawConfig() ([]byte, error) {
@@ -129,19 +128,25 @@ func (k *K3s) SyncNodeIPVS(mastersIPList, nodeIPList []string) error {
 		masters = append(masters, fmt.Sprintf("%s:%d", iputils.GetHostIP(master), apiPort))
 	}
 	image := k.cluster.GetLvscareImage()
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range nodeIPList {
-		node := node
-		eg.Go(func() error {
-			logger.Info("start to sync lvscare static pod to node: %s master: %+v", node, masters)
-			err := k.remoteUtil.StaticPod(node, k.getVipAndPort(), constants.LvsCareStaticPodName, image, masters, k3sEtcStaticPod, "--health-status", "401")
+	var rc *v2.RegistryConfig
+	if k.cluster.IsRegistryHA() {
+		rc = helpers.GetRegistryInfo(k.execer, k.pathResolver.RootFSPath(), k.cluster.GetRegistryIPAndPort())
+	}
+	return parallel.Run(nodeIPList, func(node string) error {
+		logger.Info("start to sync lvscare static pod to node: %s master: %+v", node, masters)
+		err := k.remoteUtil.StaticPod(node, k.getVipAndPort(), constants.LvsCareStaticPodName, image, masters, k3sEtcStaticPod, k.cluster.GetLvscareMetricsPort(), "--health-status", "401")
+		if err != nil {
+			return fmt.Errorf("update lvscare static pod failed %s %v", node, err)
+		}
+		if rc != nil {
+			err = k.remoteUtil.StaticPod(node, helpers.ReplicaVIPAddress(k.cluster, rc), constants.LvsCareRegistryStaticPodName, image,
+				helpers.ReplicaAddresses(k.cluster, rc), k3sEtcStaticPod, 0, helpers.ReplicaLvscareOptionsOn(k.remoteUtil, node)...)
 			if err != nil {
-				return fmt.Errorf("update lvscare static pod failed %s %v", node, err)
+				return fmt.Errorf("update registry lvscare static pod failed %s %v", node, err)
 			}
-			return nil
-		})
-	}
-	return eg.Wait()
+		}
+		return nil
+	})
 }
 
 func (k *K3s) runPipelines(phase string, pipelines ...func() error) error {
@@ -18,40 +18,30 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/utils/iputils"
 
+	"github.com/labring/sealos/pkg/utils/parallel"
 	"github.com/labring/sealos/pkg/utils/strings"
 
 	"golang.org/x/exp/slices"
 
 	"github.com/labring/sealos/pkg/utils/logger"
-
-	"golang.org/x/sync/errgroup"
 )
 
 func (k *K3s) resetNodes(nodes []string) error {
-	eg, _ := errgroup.WithContext(context.Background())
-	for i := range nodes {
-		node := nodes[i]
-		eg.Go(func() error {
-			return k.resetNode(node)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(nodes, func(node string) error {
+		return k.resetNode(node)
+	})
 }
 
 func (k *K3s) removeNodes(nodes []string) error {
-	eg, _ := errgroup.WithContext(context.Background())
-	for i := range nodes {
-		node := nodes[i]
-		eg.Go(func() error {
-			if err := k.deleteNode(node); err != nil {
-				return err
-			}
-			return k.resetNode(node)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(nodes, func(node string) error {
+		if err := k.deleteNode(node); err != nil {
+			return err
+		}
+		return k.resetNode(node)
+	})
 }
 
 func (k *K3s) resetNode(host string) error {
@@ -78,7 +68,9 @@ func (k *K3s) deleteNode(node string) error {
 		masterIPs = strings.RemoveFromSlice(k.cluster.GetMasterIPList(), node)
 	}
 	if len(masterIPs) > 0 {
-		// TODO: do we need draining first?
+		if err := kubernetes.CheckDrainError(node, k.drainNode(node), kubernetes.GetDrainOptions()); err != nil {
+			return err
+		}
 		if err := k.removeNode(node); err != nil {
 			logger.Warn(fmt.Errorf("delete nodes %s failed %v", node, err))
 		}
@@ -86,11 +78,41 @@ func (k *K3s) deleteNode(node string) error {
 	return nil
 }
 
+func (k *K3s) getNodeName(ip string) (string, error) {
+	nodeName, err := k.execer.CmdToString(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf("kubectl get nodes -o wide | awk '$6==\"%s\" {print $1}'", iputils.GetHostIP(ip)), "")
+	if err != nil {
+		return "", fmt.Errorf("cannot get node with ip address %s: %v", ip, err)
+	}
+	return nodeName, nil
+}
+
+// drainNode evicts the pods of the node before it's removed from the cluster, skipped if draining is disabled.
+func (k *K3s) drainNode(ip string) error {
+	opts := kubernetes.GetDrainOptions()
+	if !opts.Enabled {
+		return nil
+	}
+	nodeName, err := k.getNodeName(ip)
+	if err != nil {
+		return err
+	}
+	client, err := k.getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return kubernetes.DrainNode(context.Background(), client.Kubernetes(), nodeName, opts)
+}
+
+func (k *K3s) getKubeInterface() (kubernetes.Client, error) {
+	apiServer := fmt.Sprintf("https://%s:%d", k.cluster.GetMaster0IP(), k.getAPIServerPort())
+	return kubernetes.NewKubernetesClient(k.pathResolver.AdminFile(), apiServer)
+}
+
 func (k *K3s) removeNode(ip string) error {
 	logger.Info("start to remove node from k3s %s", ip)
-	nodeName, err := k.execer.CmdToString(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf("kubectl get nodes -o wide | awk '$6==\"%s\" {print $1}'", iputils.GetHostIP(ip)), "")
+	nodeName, err := k.getNodeName(ip)
 	if err != nil {
-		return fmt.Errorf("cannot get node with ip address %s: %v", ip, err)
+		return err
 	}
 	logger.Debug("found node name is %s, we will delete it", nodeName)
 	return k.execer.CmdAsync(k.cluster.GetMaster0IPAndPort(), fmt.Sprintf("kubectl delete node %s --ignore-not-found=true", nodeName))
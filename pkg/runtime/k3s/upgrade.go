@@ -0,0 +1,159 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	installK3sCmd   = "cp -rf %s/k3s /usr/bin"
+	cordonNodeCmd   = "kubectl cordon %s"
+	uncordonNodeCmd = "kubectl uncordon %s"
+	nodeReadyCmd    = `kubectl get node %s -o jsonpath='{.status.conditions[?(@.type=="Ready")].status}'`
+	etcdHealthCmd   = "k3s kubectl get --raw /readyz/etcd"
+)
+
+func (k *K3s) upgrade(version string) error {
+	currVersion := k.getVersionFromImage()
+	if currVersion == version {
+		logger.Info("skip upgrade because of same version")
+		return nil
+	}
+	v0, err := semver.NewVersion(currVersion)
+	if err != nil {
+		return err
+	}
+	v1, err := semver.NewVersion(version)
+	if err != nil {
+		return err
+	}
+	if v0.GreaterThan(v1) {
+		return fmt.Errorf("cannot apply an older version %s than %s", version, currVersion)
+	}
+	if v0.Minor()+1 < v1.Minor() {
+		return fmt.Errorf("cannot be upgraded across more than one major releases, %s -> %s", currVersion, version)
+	}
+	return k.upgradeCluster(version)
+}
+
+// upgradeCluster upgrades servers one by one then agents, every node keeps cordoned
+// until it's back to Ready with the new k3s binary.
+func (k *K3s) upgradeCluster(version string) error {
+	logger.Info("start to upgrade servers to %s", version)
+	for _, master := range k.cluster.GetMasterIPAndPortList() {
+		if err := k.upgradeNode(master, true); err != nil {
+			return err
+		}
+	}
+	logger.Info("start to upgrade agents to %s", version)
+	for _, node := range k.cluster.GetNodeIPAndPortList() {
+		if err := k.upgradeNode(node, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *K3s) upgradeNode(host string, isServer bool) error {
+	nodeName, err := k.remoteUtil.Hostname(host)
+	if err != nil {
+		return err
+	}
+	//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
+	nodeName = strings.ToLower(nodeName)
+	pipelines := []func() error{
+		func() error { return k.drainOrCordonNode(host, nodeName) },
+		func() error { return k.remoteUtil.InitSystem(host).ServiceStop("k3s") },
+		func() error {
+			return k.execer.CmdAsync(host, fmt.Sprintf(installK3sCmd, k.pathResolver.RootFSBinPath()))
+		},
+		func() error { return k.remoteUtil.InitSystem(host).ServiceStart("k3s") },
+		func() error { return k.waitNodeReady(host, nodeName) },
+	}
+	if isServer {
+		pipelines = append(pipelines, func() error { return k.waitEtcdHealthy(host) })
+	}
+	pipelines = append(pipelines, func() error { return k.tryUncordonNode(host, nodeName) })
+	return k.runPipelines(fmt.Sprintf("upgrade node %s", nodeName), pipelines...)
+}
+
+// drainOrCordonNode evicts the pods of the node before k3s restarts, or only cordons it if draining is skipped.
+func (k *K3s) drainOrCordonNode(host, nodeName string) error {
+	opts := kubernetes.GetDrainOptions()
+	if !opts.Enabled {
+		return k.execer.CmdAsync(host, fmt.Sprintf(cordonNodeCmd, nodeName))
+	}
+	client, err := k.getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return kubernetes.DrainNode(context.Background(), client.Kubernetes(), nodeName, opts)
+}
+
+func (k *K3s) waitNodeReady(host, nodeName string) error {
+	return k.waitUntil(host, fmt.Sprintf(nodeReadyCmd, nodeName), "True",
+		fmt.Sprintf("wait for node %s ready", nodeName))
+}
+
+func (k *K3s) waitEtcdHealthy(host string) error {
+	return k.waitUntil(host, etcdHealthCmd, "ok", fmt.Sprintf("wait for etcd on %s healthy", host))
+}
+
+func (k *K3s) tryUncordonNode(host, nodeName string) error {
+	timeout := time.Now().Add(1 * time.Minute)
+	for {
+		err := k.execer.CmdAsync(host, fmt.Sprintf(uncordonNodeCmd, nodeName))
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("try uncordon node %s timeout one minute", nodeName)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// waitUntil runs cmd on host every five seconds until it outputs expected, at most two minutes.
+func (k *K3s) waitUntil(host, cmd, expected, phase string) error {
+	timeout := time.Now().Add(2 * time.Minute)
+	for {
+		out, err := k.execer.CmdToString(host, cmd, "")
+		if err == nil && strings.TrimSpace(out) == expected {
+			return nil
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("%s timeout within two minutes", phase)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (k *K3s) getVersionFromImage() string {
+	img := k.cluster.GetRootfsImage()
+	if img == nil || img.Labels == nil {
+		return ""
+	}
+	return img.Labels[v2.ImageKubeVersionKey]
+}
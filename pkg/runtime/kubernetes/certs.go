@@ -18,15 +18,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path"
+	"time"
 
-	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/json"
 
+	"github.com/labring/sealos/pkg/cert"
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
@@ -38,7 +41,183 @@ const (
 )
 
 func (k *KubeadmRuntime) Renew() error {
-	return errors.New("not implement")
+	if err := k.CompleteKubeadmConfig(setCGroupDriverAndSocket, setCertificateKey); err != nil {
+		return err
+	}
+	pipeline := []func() error{
+		k.mergeWithBuiltinKubeadmConfig,
+		k.initCert,
+		k.renewKubeConfigs,
+		k.syncCert,
+		func() error { return k.copyMasterKubeConfig(k.getMaster0IPAndPort()) },
+		k.restartControlPlane,
+		k.showKubeadmCert,
+	}
+	for _, f := range pipeline {
+		if err := f(); err != nil {
+			return fmt.Errorf("failed to renew cert %v", err)
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) CheckExpiration() ([]cert.HostExpiration, error) {
+	var ret []cert.HostExpiration
+	for _, master := range k.getMasterIPAndPortList() {
+		list, err := k.fetchCertExpiration(master)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check cert expiration of master %s: %v", master, err)
+		}
+		ret = append(ret, cert.HostExpiration{Host: master, Certificates: list})
+	}
+	return ret, nil
+}
+
+// fetchCertExpiration downloads the certs and kubeconfigs of a master to a temporary dir and reads their expiry dates.
+func (k *KubeadmRuntime) fetchCertExpiration(master string) ([]cert.Expiration, error) {
+	if err := os.MkdirAll(k.pathResolver.TmpPath(), 0755); err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(k.pathResolver.TmpPath(), "certs-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	certPath := path.Join(tmpDir, "pki")
+	certEtcdPath := path.Join(certPath, "etcd")
+	configs := append(cert.CaList(certPath, certEtcdPath), cert.List(certPath, certEtcdPath)...)
+	for _, c := range configs {
+		src := path.Join(c.DefaultPath, c.BaseName+".crt")
+		if err = k.execer.Fetch(master, src, path.Join(c.Path, c.BaseName+".crt")); err != nil {
+			return nil, err
+		}
+	}
+	list, err := cert.ListExpiration(certPath, certEtcdPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range []string{AdminConf, ControllerConf, SchedulerConf} {
+		dst := path.Join(tmpDir, f)
+		if err = k.execer.Fetch(master, path.Join(kubernetesEtc, f), dst); err != nil {
+			return nil, err
+		}
+		e, err := cert.LoadKubeConfigExpiration(dst)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, nil
+}
+
+// renewKubeConfigs recreates the kubeconfigs of the control plane components with new client certs,
+// kubelet.conf is left untouched since the kubelet rotates its own client cert.
+func (k *KubeadmRuntime) renewKubeConfigs() error {
+	files := []string{AdminConf, ControllerConf, SchedulerConf}
+	for _, f := range files {
+		if err := os.RemoveAll(path.Join(k.pathResolver.EtcPath(), f)); err != nil {
+			return err
+		}
+	}
+	if err := k.CreateKubeConfigFiles(); err != nil {
+		return fmt.Errorf("failed to generate kubernetes conf: %w", err)
+	}
+	return k.SendJoinMasterKubeConfigs(k.getMasterIPAndPortList(), files...)
+}
+
+// restartControlPlane restarts the static pods one master at a time so the new certs are loaded
+// while the rest of the control plane keeps serving.
+func (k *KubeadmRuntime) restartControlPlane() error {
+	components := []string{"etcd", kubernetes.KubeAPIServer, kubernetes.KubeControllerManager, kubernetes.KubeScheduler}
+	for _, master := range k.getMasterIPAndPortList() {
+		logger.Info("start to restart control plane static pods on master %s", master)
+		for _, component := range components {
+			if err := k.restartStaticPod(master, component); err != nil {
+				return fmt.Errorf("failed to restart %s on master %s: %v", component, master, err)
+			}
+		}
+		if err := k.pingAPIServer(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) restartStaticPod(host, component string) error {
+	podID, err := k.getStaticPodSandboxID(host, component)
+	if err != nil {
+		return err
+	}
+	if podID == "" {
+		// etcd may be external
+		logger.Warn("not found %s pod running on %s, skip restart", component, host)
+		return nil
+	}
+	if err = k.removePodSandbox(host, podID); err != nil {
+		return err
+	}
+	return k.waitStaticPodRunning(host, component, podID)
+}
+
+func (k *KubeadmRuntime) listStaticPodContainers(host, component string) (*crictlPS, error) {
+	podIDSh := fmt.Sprintf("crictl ps -a --name %s -o json", component)
+	podIDJson, err := k.sshCmdToString(host, podIDSh)
+	if err != nil {
+		return nil, err
+	}
+	ps := &crictlPS{}
+	if err = json.Unmarshal([]byte(podIDJson), ps); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// getStaticPodSandboxID prefers the sandbox of the running container, empty if none is found.
+func (k *KubeadmRuntime) getStaticPodSandboxID(host, component string) (string, error) {
+	ps, err := k.listStaticPodContainers(host, component)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range ps.Containers {
+		if c.State == containerRunning {
+			return c.PodSandboxID[:13], nil
+		}
+	}
+	if len(ps.Containers) > 0 {
+		return ps.Containers[0].PodSandboxID[:13], nil
+	}
+	return "", nil
+}
+
+func (k *KubeadmRuntime) removePodSandbox(host, podID string) error {
+	logger.Debug("found podID %s in %s", podID, host)
+	//crictl stopp
+	if err := k.sshCmdAsync(host, fmt.Sprintf("crictl --timeout=10s stopp %s", podID)); err != nil {
+		return err
+	}
+	//crictl rmp
+	return k.sshCmdAsync(host, fmt.Sprintf("crictl rmp %s", podID))
+}
+
+func (k *KubeadmRuntime) waitStaticPodRunning(host, component, oldPodID string) error {
+	timeout := time.Now().Add(2 * time.Minute)
+	for {
+		ps, err := k.listStaticPodContainers(host, component)
+		if err == nil {
+			for _, c := range ps.Containers {
+				if c.State == containerRunning && c.PodSandboxID[:13] != oldPodID {
+					logger.Info("%s is running on %s", component, host)
+					return nil
+				}
+			}
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("wait for %s running on %s timeout within two minutes", component, host)
+		}
+		time.Sleep(5 * time.Second)
+	}
 }
 
 func (k *KubeadmRuntime) UpdateCertSANs(certSans []string) error {
@@ -166,39 +345,35 @@ func (k *KubeadmRuntime) showKubeadmCert() error {
 	return k.sshCmdAsync(k.getMaster0IPAndPort(), fmt.Sprintf("%s%s", certCheck, vlogToStr(k.klogLevel)))
 }
 
-func (k *KubeadmRuntime) deleteAPIServer() error {
-	podIDSh := fmt.Sprintf("crictl ps -a --name %s -o json", kubernetes.KubeAPIServer)
-	type crictlPS struct {
-		Containers []struct {
-			ID           string `json:"id"`
-			PodSandboxID string `json:"podSandboxId"`
-		} `json:"containers"`
+const containerRunning = "CONTAINER_RUNNING"
+
+type crictlPS struct {
+	Containers []struct {
+		ID           string `json:"id"`
+		PodSandboxID string `json:"podSandboxId"`
+		State        string `json:"state"`
+	} `json:"containers"`
+}
+
+func (ps *crictlPS) hasRunning() bool {
+	for _, c := range ps.Containers {
+		if c.State == containerRunning {
+			return true
+		}
 	}
+	return false
+}
+
+func (k *KubeadmRuntime) deleteAPIServer() error {
 	logger.Info("delete pod apiserver from crictl")
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, master := range k.getMasterIPAndPortList() {
-		m := master
-		eg.Go(func() error {
-			podIDJson, err := k.sshCmdToString(m, podIDSh)
-			if err != nil {
-				return err
-			}
-			ps := &crictlPS{}
-			if err = json.Unmarshal([]byte(podIDJson), ps); err != nil {
-				return err
-			}
-			if len(ps.Containers) > 0 {
-				podID := ps.Containers[0].PodSandboxID[:13]
-				logger.Debug("found podID %s in %s", podID, m)
-				//crictl stopp
-				if err = k.sshCmdAsync(m, fmt.Sprintf("crictl --timeout=10s stopp %s", podID)); err != nil {
-					return err
-				}
-				//crictl rmp
-				return k.sshCmdAsync(m, fmt.Sprintf("crictl rmp %s", podID))
-			}
-			return errors.New("not found apiServer pod running")
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(k.getMasterIPAndPortList(), func(m string) error {
+		podID, err := k.getStaticPodSandboxID(m, kubernetes.KubeAPIServer)
+		if err != nil {
+			return err
+		}
+		if podID != "" {
+			return k.removePodSandbox(m, podID)
+		}
+		return errors.New("not found apiServer pod running")
+	})
 }
@@ -0,0 +1,99 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/constants"
+)
+
+// putControlPlaneCerts generates the certs and kubeconfigs of a master in dir,
+// and puts them to the default paths of host.
+func putControlPlaneCerts(t *testing.T, hosts *fakeEtcdHosts, host, dir string) {
+	t.Helper()
+	certPath := filepath.Join(dir, "pki")
+	etcdPath := filepath.Join(certPath, "etcd")
+	if err := cert.GenerateCert(certPath, etcdPath, nil, "192.168.0.2", "master1", "10.96.0.0/12", "cluster.local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.CreateJoinControlPlaneKubeConfigFiles(dir, cert.Config{Path: certPath, BaseName: "ca"}, "master1",
+		"https://apiserver.cluster.local:6443", "kubernetes"); err != nil {
+		t.Fatal(err)
+	}
+	put := func(src, dst string) {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts.files[hosts.key(host, dst)] = data
+	}
+	for _, c := range append(cert.CaList(certPath, etcdPath), cert.List(certPath, etcdPath)...) {
+		put(path.Join(c.Path, c.BaseName+".crt"), path.Join(c.DefaultPath, c.BaseName+".crt"))
+	}
+	for _, f := range []string{AdminConf, ControllerConf, SchedulerConf} {
+		put(filepath.Join(dir, f), path.Join(kubernetesEtc, f))
+	}
+}
+
+func TestCheckExpiration(t *testing.T) {
+	defer func(dir string) { constants.DefaultRuntimeRootDir = dir }(constants.DefaultRuntimeRootDir)
+	constants.DefaultRuntimeRootDir = t.TempDir()
+
+	masters := []string{"192.168.0.2:22", "192.168.0.3:22"}
+	k, hosts := newTestEtcdRuntime(masters...)
+	putControlPlaneCerts(t, hosts, masters[0], t.TempDir())
+	putControlPlaneCerts(t, hosts, masters[1], t.TempDir())
+
+	list, err := k.CheckExpiration()
+	if err != nil {
+		t.Fatalf("CheckExpiration() error = %v", err)
+	}
+	if len(list) != len(masters) {
+		t.Fatalf("CheckExpiration() returned %d masters, want %d", len(list), len(masters))
+	}
+	for i, host := range list {
+		if host.Host != masters[i] {
+			t.Errorf("host #%d = %s, want %s", i, host.Host, masters[i])
+		}
+		names := map[string]cert.Expiration{}
+		for _, e := range host.Certificates {
+			names[e.Name] = e
+		}
+		for _, name := range []string{"ca", "apiserver", "etcd-ca", "etcd-server", "front-proxy-client", AdminConf, SchedulerConf} {
+			e, ok := names[name]
+			if !ok {
+				t.Errorf("cert %s of %s is not checked", name, host.Host)
+				continue
+			}
+			if e.ResidualTime() <= 0 {
+				t.Errorf("cert %s of %s is expired", name, host.Host)
+			}
+		}
+		if !names["etcd-ca"].IsCA || names["etcd-server"].CAName != "etcd-ca" {
+			t.Errorf("etcd certs of %s = %+v and %+v, want the CA and the one issued by it", host.Host, names["etcd-ca"], names["etcd-server"])
+		}
+	}
+
+	// a master missing certs fails the check
+	delete(hosts.files, hosts.key(masters[1], path.Join(kubernetesEtc, AdminConf)))
+	if _, err = k.CheckExpiration(); err == nil {
+		t.Errorf("CheckExpiration() of master without admin.conf error = nil, want error")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+)
+
+const (
+	etcdComponent = "etcd"
+	// the etcd container only sees its data dir and pki, so snapshots are put into the data dir
+	etcdSnapshotFileName   = "sealos-snapshot.db"
+	etcdRestoreDirName     = "sealos-restore"
+	etcdManifestsBackupDir = "/etc/kubernetes/manifests-sealos-restore"
+
+	etcdctlCmd             = "crictl exec %s etcdctl --endpoints=https://127.0.0.1:2379 --cacert=%s --cert=%s --key=%s %s"
+	etcdSnapshotRestoreCmd = "snapshot restore %s --name=%s --initial-cluster=%s --initial-cluster-token=%s --initial-advertise-peer-urls=https://%s:2380 --data-dir=%s"
+	etcdSwapDataDirCmd     = "rm -rf %[1]s/member.bak && mv %[1]s/member %[1]s/member.bak && mv %[1]s/%[2]s/member %[1]s/member && rm -rf %[1]s/%[2]s %[1]s/%[3]s"
+)
+
+var controlPlaneStaticPods = []string{etcdComponent, kubernetes.KubeAPIServer, kubernetes.KubeControllerManager, kubernetes.KubeScheduler}
+
+// SaveSnapshot takes a snapshot with the etcdctl of the running etcd container on master
+// and fetches it to the local file dst.
+func (k *KubeadmRuntime) SaveSnapshot(master, dst string) error {
+	containerID, err := k.getRunningContainerID(master, etcdComponent)
+	if err != nil {
+		return err
+	}
+	snapshot := path.Join(k.getEtcdDataDir(), etcdSnapshotFileName)
+	logger.Info("start to save etcd snapshot on %s", master)
+	if err = k.sshCmdAsync(master, k.etcdctl(containerID, "snapshot save "+snapshot)); err != nil {
+		return fmt.Errorf("failed to save etcd snapshot: %v", err)
+	}
+	defer func() {
+		if err := k.sshCmdAsync(master, "rm -f "+snapshot); err != nil {
+			logger.Warn("failed to remove etcd snapshot on %s: %v", master, err)
+		}
+	}()
+	return ssh.FetchWithChecksum(k.execer, master, snapshot, dst)
+}
+
+// RestoreSnapshot restores the snapshot on every master with the member names that etcd already uses,
+// the control plane static pods are stopped while the data dirs are being replaced.
+func (k *KubeadmRuntime) RestoreSnapshot(src string) error {
+	masters := k.getMasterIPAndPortList()
+	names := make(map[string]string, len(masters))
+	var initialCluster []string
+	for _, master := range masters {
+		hostname, err := k.execHostname(master)
+		if err != nil {
+			return err
+		}
+		//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
+		names[master] = strings.ToLower(hostname)
+		initialCluster = append(initialCluster, fmt.Sprintf("%s=https://%s:2380", names[master], iputils.GetHostIP(master)))
+	}
+	token := fmt.Sprintf("sealos-restore-%d", time.Now().Unix())
+	dataDir := k.getEtcdDataDir()
+	snapshot := path.Join(dataDir, etcdSnapshotFileName)
+
+	if err := parallel.Run(masters, func(master string) error {
+		if err := ssh.CopyWithChecksum(k.execer, master, src, snapshot); err != nil {
+			return err
+		}
+		containerID, err := k.getRunningContainerID(master, etcdComponent)
+		if err != nil {
+			return err
+		}
+		restoreDir := path.Join(dataDir, etcdRestoreDirName)
+		restore := fmt.Sprintf(etcdSnapshotRestoreCmd, snapshot, names[master], strings.Join(initialCluster, ","),
+			token, iputils.GetHostIP(master), restoreDir)
+		logger.Info("start to restore etcd snapshot on %s", master)
+		return k.sshCmdAsync(master, "rm -rf "+restoreDir, k.etcdctl(containerID, restore))
+	}); err != nil {
+		return fmt.Errorf("failed to restore etcd snapshot: %v", err)
+	}
+
+	return k.runPipelines("bring the restored cluster back",
+		k.stopControlPlane,
+		func() error {
+			return parallel.Run(masters, func(master string) error {
+				return k.sshCmdAsync(master, fmt.Sprintf(etcdSwapDataDirCmd, dataDir, etcdRestoreDirName, etcdSnapshotFileName))
+			})
+		},
+		k.startControlPlane,
+		k.pingAPIServer,
+	)
+}
+
+func (k *KubeadmRuntime) etcdctl(containerID, args string) string {
+	// the pki of path resolver is synced to kubernetesEtcPKI of masters, etcd container mounts the same path
+	certPath := path.Join(kubernetesEtcPKI, filepath.Base(k.pathResolver.PkiEtcdPath()))
+	return fmt.Sprintf(etcdctlCmd, containerID,
+		path.Join(certPath, "ca.crt"),
+		path.Join(certPath, "healthcheck-client.crt"),
+		path.Join(certPath, "healthcheck-client.key"),
+		args,
+	)
+}
+
+// stopControlPlane moves the manifests of control plane static pods away and waits until kubelet stops them.
+func (k *KubeadmRuntime) stopControlPlane() error {
+	return parallel.Run(k.getMasterIPAndPortList(), func(master string) error {
+		logger.Info("stop control plane static pods on %s", master)
+		cmds := []string{"mkdir -p " + etcdManifestsBackupDir}
+		for _, component := range controlPlaneStaticPods {
+			cmds = append(cmds, fmt.Sprintf("mv -f %s/%s.yaml %s/", kubernetesEtcStaticPod, component, etcdManifestsBackupDir))
+		}
+		if err := k.sshCmdAsync(master, cmds...); err != nil {
+			return err
+		}
+		for _, component := range controlPlaneStaticPods {
+			if err := k.waitContainerStopped(master, component); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (k *KubeadmRuntime) startControlPlane() error {
+	return parallel.Run(k.getMasterIPAndPortList(), func(master string) error {
+		logger.Info("start control plane static pods on %s", master)
+		if err := k.sshCmdAsync(master, fmt.Sprintf("mv -f %s/*.yaml %s/ && rm -rf %s",
+			etcdManifestsBackupDir, kubernetesEtcStaticPod, etcdManifestsBackupDir)); err != nil {
+			return err
+		}
+		for _, component := range controlPlaneStaticPods {
+			if err := k.waitStaticPodRunning(master, component, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (k *KubeadmRuntime) getRunningContainerID(host, component string) (string, error) {
+	ps, err := k.listStaticPodContainers(host, component)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range ps.Containers {
+		if c.State == containerRunning {
+			return c.ID, nil
+		}
+	}
+	return "", fmt.Errorf("not found %s container running on %s", component, host)
+}
+
+func (k *KubeadmRuntime) waitContainerStopped(host, component string) error {
+	timeout := time.Now().Add(2 * time.Minute)
+	for {
+		ps, err := k.listStaticPodContainers(host, component)
+		if err == nil && !ps.hasRunning() {
+			return nil
+		}
+		if time.Now().After(timeout) {
+			return fmt.Errorf("wait for %s stopped on %s timeout within two minutes", component, host)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
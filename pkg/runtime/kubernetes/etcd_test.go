@@ -0,0 +1,181 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime/kubernetes/types"
+	"github.com/labring/sealos/pkg/ssh"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+// fakeEtcdHosts plays the masters, the files and control plane state of every
+// host are kept in memory.
+type fakeEtcdHosts struct {
+	ssh.Interface
+	mu      sync.Mutex
+	files   map[string][]byte
+	stopped map[string]bool
+	cmds    map[string][]string
+}
+
+func newFakeEtcdHosts() *fakeEtcdHosts {
+	return &fakeEtcdHosts{files: map[string][]byte{}, stopped: map[string]bool{}, cmds: map[string][]string{}}
+}
+
+func (f *fakeEtcdHosts) key(host, path string) string {
+	return host + ":" + path
+}
+
+func (f *fakeEtcdHosts) run(host, cmd string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cmds[host] = append(f.cmds[host], cmd)
+	fields := strings.Fields(cmd)
+	switch {
+	case strings.HasPrefix(cmd, "crictl ps"):
+		state := containerRunning
+		if f.stopped[host] {
+			state = "CONTAINER_EXITED"
+		}
+		return fmt.Sprintf(`{"containers":[{"id":"etcd-%s","podSandboxId":"0123456789abcdef","state":"%s"}]}`, host, state)
+	case strings.Contains(cmd, "snapshot save"):
+		f.files[f.key(host, fields[len(fields)-1])] = []byte("snapshot of " + host)
+	case strings.HasPrefix(cmd, "sha256sum"):
+		return fmt.Sprintf("%x", sha256.Sum256(f.files[f.key(host, fields[1])]))
+	case strings.HasSuffix(cmd, "hostname"):
+		return "Master-" + strings.Split(host, ":")[0]
+	case strings.HasPrefix(cmd, "mkdir -p "+etcdManifestsBackupDir):
+		f.stopped[host] = true
+	case strings.HasPrefix(cmd, "mv -f "+etcdManifestsBackupDir):
+		f.stopped[host] = false
+	}
+	return ""
+}
+
+func (f *fakeEtcdHosts) CmdAsync(host string, cmds ...string) error {
+	for _, cmd := range cmds {
+		f.run(host, cmd)
+	}
+	return nil
+}
+
+func (f *fakeEtcdHosts) CmdToString(host, cmd, _ string) (string, error) {
+	return f.run(host, cmd), nil
+}
+
+func (f *fakeEtcdHosts) Copy(host, src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[f.key(host, dst)] = data
+	return nil
+}
+
+func (f *fakeEtcdHosts) Fetch(host, src, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// the parent dirs are created as the ssh client does
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, f.files[f.key(host, src)], 0600)
+}
+
+type fakeClient struct {
+	kubernetes.Client
+	clientset clientset.Interface
+}
+
+func (c *fakeClient) Kubernetes() clientset.Interface {
+	return c.clientset
+}
+
+func newTestEtcdRuntime(masters ...string) (*KubeadmRuntime, *fakeEtcdHosts) {
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	cluster.Spec.Hosts = []v2.Host{{IPS: masters, Roles: []string{v2.MASTER}}}
+	hosts := newFakeEtcdHosts()
+	return &KubeadmRuntime{
+		cluster:       cluster,
+		kubeadmConfig: &types.KubeadmConfig{},
+		cli:           &fakeClient{clientset: fake.NewSimpleClientset()},
+		execer:        hosts,
+		pathResolver:  constants.NewPathResolver(cluster.Name),
+		remoteUtil:    ssh.NewRemoteFromSSH(cluster.Name, hosts),
+	}, hosts
+}
+
+func TestSaveSnapshot(t *testing.T) {
+	k, hosts := newTestEtcdRuntime("192.168.0.2:22", "192.168.0.3:22")
+	dst := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := k.SaveSnapshot("192.168.0.3:22", dst); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "snapshot of 192.168.0.3:22" {
+		t.Errorf("saved snapshot = %q, want the one of 192.168.0.3:22", data)
+	}
+	cmds := strings.Join(hosts.cmds["192.168.0.3:22"], "\n")
+	if !strings.Contains(cmds, "crictl exec etcd-192.168.0.3:22 etcdctl") {
+		t.Errorf("etcdctl is not run in the etcd container:\n%s", cmds)
+	}
+	// the snapshot on master is removed after fetched
+	if !strings.Contains(cmds, "rm -f /var/lib/etcd/"+etcdSnapshotFileName) {
+		t.Errorf("snapshot is not removed from master:\n%s", cmds)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	masters := []string{"192.168.0.2:22", "192.168.0.3:22"}
+	k, hosts := newTestEtcdRuntime(masters...)
+	src := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := os.WriteFile(src, []byte("snapshot"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.RestoreSnapshot(src); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	initialCluster := "--initial-cluster=master-192.168.0.2=https://192.168.0.2:2380,master-192.168.0.3=https://192.168.0.3:2380"
+	for _, master := range masters {
+		if got := string(hosts.files[hosts.key(master, "/var/lib/etcd/"+etcdSnapshotFileName)]); got != "snapshot" {
+			t.Errorf("snapshot on %s = %q, want %q", master, got, "snapshot")
+		}
+		cmds := strings.Join(hosts.cmds[master], "\n")
+		name := "--name=master-" + strings.Split(master, ":")[0]
+		for _, want := range []string{name, initialCluster, "mv /var/lib/etcd/sealos-restore/member /var/lib/etcd/member"} {
+			if !strings.Contains(cmds, want) {
+				t.Errorf("commands on %s don't contain %q:\n%s", master, want, cmds)
+			}
+		}
+		if hosts.stopped[master] {
+			t.Errorf("control plane of %s is not started again", master)
+		}
+	}
+}
@@ -17,29 +17,23 @@ limitations under the License.
 package kubernetes
 
 import (
-	"context"
 	"path/filepath"
 
-	"golang.org/x/sync/errgroup"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 const copyKubeAdminConfigCommand = `rm -rf $HOME/.kube/config && mkdir -p $HOME/.kube && cp /etc/kubernetes/admin.conf $HOME/.kube/config`
 
 func (k *KubeadmRuntime) copyKubeConfigFileToNodes(hosts ...string) error {
 	src := k.pathResolver.AdminFile()
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range hosts {
-		node := node
-		eg.Go(func() error {
-			home, err := k.execer.CmdToString(node, "echo $HOME", "")
-			if err != nil {
-				return err
-			}
-			dst := filepath.Join(home, ".kube", "config")
-			return k.execer.Copy(node, src, dst)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(hosts, func(node string) error {
+		home, err := k.execer.CmdToString(node, "echo $HOME", "")
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(home, ".kube", "config")
+		return k.execer.Copy(node, src, dst)
+	})
 }
 
 func (k *KubeadmRuntime) copyMasterKubeConfig(host string) error {
@@ -15,16 +15,14 @@
 package kubernetes
 
 import (
-	"context"
 	"fmt"
 	"path"
 
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 	"github.com/labring/sealos/pkg/utils/strings"
-
-	"golang.org/x/sync/errgroup"
 )
 
 func (k *KubeadmRuntime) InitMaster0() error {
@@ -55,16 +53,11 @@ func (k *KubeadmRuntime) imagePull(hostAndPort string) error {
 
 // sendJoinCPConfig send join CP masters configuration
 func (k *KubeadmRuntime) sendJoinCPConfig(joinMaster []string) error {
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, master := range joinMaster {
-		master := master
-		eg.Go(func() error {
-			k.mu.Lock()
-			defer k.mu.Unlock()
-			return k.ConfigJoinMasterKubeadmToMaster(master)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(joinMaster, func(master string) error {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		return k.ConfigJoinMasterKubeadmToMaster(master)
+	})
 }
 
 func (k *KubeadmRuntime) ConfigJoinMasterKubeadmToMaster(master string) error {
@@ -159,28 +152,33 @@ func (k *KubeadmRuntime) deleteMasters(masters []string) error {
 	if len(masters) == 0 {
 		return nil
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, master := range masters {
-		master := master
-		eg.Go(func() error {
-			logger.Info("start to delete master %s", master)
-			if err := k.deleteMaster(master); err != nil {
-				logger.Error("delete master %s failed %v", master, err)
-			} else {
-				logger.Info("succeeded in deleting master %s", master)
-			}
-			return nil
-		})
+	return parallel.Run(masters, func(master string) error {
+		logger.Info("start to delete master %s", master)
+		// a master failing to drain is kept and fails the deletion
+		if err := k.drainMaster(master); err != nil {
+			return err
+		}
+		if err := k.deleteMaster(master); err != nil {
+			logger.Error("delete master %s failed %v", master, err)
+		} else {
+			logger.Info("succeeded in deleting master %s", master)
+		}
+		return nil
+	})
+}
+
+func (k *KubeadmRuntime) drainMaster(master string) error {
+	if len(strings.RemoveFromSlice(k.getMasterIPList(), master)) == 0 {
+		return nil
 	}
-	return eg.Wait()
+	return k.drainNodeToDelete(master)
 }
 
 func (k *KubeadmRuntime) deleteMaster(master string) error {
+	masterIPs := strings.RemoveFromSlice(k.getMasterIPList(), master)
 	return k.resetNode(master, func() {
 		//remove master
-		masterIPs := strings.RemoveFromSlice(k.getMasterIPList(), master)
 		if len(masterIPs) > 0 {
-			// TODO: do we need draining first?
 			if err := k.removeNode(master); err != nil {
 				logger.Warn(fmt.Errorf("delete master %s failed %v", master, err))
 			}
@@ -15,21 +15,19 @@
 package kubernetes
 
 import (
-	"context"
 	"fmt"
 	"path"
 
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
-
-	"golang.org/x/sync/errgroup"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 func (k *KubeadmRuntime) joinNodes(newNodesIPList []string) error {
 	var err error
 	if err = ssh.WaitReady(k.execer, 6, newNodesIPList...); err != nil {
-		return fmt.Errorf("join nodes wait for ssh ready time out: %w", err)
+		return fmt.Errorf("join nodes wait for ssh ready time out: %v", err)
 	}
 
 	masters := k.getMasterIPListAndHTTPSPort()
@@ -39,35 +37,29 @@ func (k *KubeadmRuntime) joinNodes(newNodesIPList []string) error {
 	if err = k.mergeWithBuiltinKubeadmConfig(); err != nil {
 		return err
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range newNodesIPList {
-		node := node
-		eg.Go(func() error {
-			logger.Info("start to join %s as worker", node)
-			k.mu.Lock()
-			err = k.copyKubeadmConfigToNode(node)
-			if err != nil {
-				return fmt.Errorf("failed to copy join node kubeadm config %s %v", node, err)
-			}
-			k.mu.Unlock()
-			logger.Info("run ipvs once module: %s", node)
-			err = k.execIPVS(node, masters)
-			if err != nil {
-				return fmt.Errorf("run ipvs once failed %v", err)
-			}
-			logger.Info("start join node: %s", node)
-			joinCmd := k.Command(JoinNode)
-			if joinCmd == "" {
-				return fmt.Errorf("get join node command failed, kubernetes version is %s", k.getKubeVersion())
-			}
-			if err = k.sshCmdAsync(node, joinCmd); err != nil {
-				return fmt.Errorf("failed to join node %s %v", node, err)
-			}
-			logger.Info("succeeded in joining %s as worker", node)
-			return nil
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(newNodesIPList, func(node string) error {
+		logger.Info("start to join %s as worker", node)
+		k.mu.Lock()
+		err := k.copyKubeadmConfigToNode(node)
+		k.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to copy join node kubeadm config %s %v", node, err)
+		}
+		logger.Info("run ipvs once module: %s", node)
+		if err = k.execIPVS(node, masters); err != nil {
+			return fmt.Errorf("run ipvs once failed %v", err)
+		}
+		logger.Info("start join node: %s", node)
+		joinCmd := k.Command(JoinNode)
+		if joinCmd == "" {
+			return fmt.Errorf("get join node command failed, kubernetes version is %s", k.getKubeVersion())
+		}
+		if err = k.sshCmdAsync(node, joinCmd); err != nil {
+			return fmt.Errorf("failed to join node %s %v", node, err)
+		}
+		logger.Info("succeeded in joining %s as worker", node)
+		return nil
+	})
 }
 
 func (k *KubeadmRuntime) copyKubeadmConfigToNode(node string) error {
@@ -93,22 +85,22 @@ func (k *KubeadmRuntime) deleteNodes(nodes []string) error {
 	if len(nodes) == 0 {
 		return nil
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range nodes {
-		node := node
-		eg.Go(func() error {
-			logger.Info("start to delete worker %s", node)
-			if err := k.deleteNode(node); err != nil {
-				return fmt.Errorf("delete node %s failed %v", node, err)
-			}
-			logger.Info("succeeded in deleting worker %s", node)
-			return nil
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(nodes, func(node string) error {
+		logger.Info("start to delete worker %s", node)
+		if err := k.deleteNode(node); err != nil {
+			return fmt.Errorf("delete node %s failed %v", node, err)
+		}
+		logger.Info("succeeded in deleting worker %s", node)
+		return nil
+	})
 }
 
 func (k *KubeadmRuntime) deleteNode(node string) error {
+	if len(k.getMasterIPList()) > 0 {
+		if err := k.drainNodeToDelete(node); err != nil {
+			return err
+		}
+	}
 	return k.resetNode(node, func() {
 		//remove node
 		if len(k.getMasterIPList()) > 0 {
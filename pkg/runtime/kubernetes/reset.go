@@ -15,13 +15,12 @@
 package kubernetes
 
 import (
-	"context"
 	"fmt"
 
 	"golang.org/x/exp/slices"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 const (
@@ -41,17 +40,12 @@ func (k *KubeadmRuntime) reset() error {
 
 func (k *KubeadmRuntime) resetNodes(nodes []string) {
 	logger.Info("start to reset nodes: %v", nodes)
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range nodes {
-		node := node
-		eg.Go(func() error {
-			if err := k.resetNode(node, nil); err != nil {
-				logger.Error("delete node %s failed %v", node, err)
-			}
-			return nil
-		})
-	}
-	if err := eg.Wait(); err != nil {
+	if err := parallel.Run(nodes, func(node string) error {
+		if err := k.resetNode(node, nil); err != nil {
+			logger.Error("delete node %s failed %v", node, err)
+		}
+		return nil
+	}); err != nil {
 		return
 	}
 }
@@ -28,6 +28,8 @@ import (
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
@@ -100,10 +102,21 @@ func (k *KubeadmRuntime) ScaleUp(newMasterIPList []string, newNodeIPList []strin
 	}
 	if len(newNodeIPList) != 0 {
 		logger.Info("%s will be added as worker", newNodeIPList)
-		if err := k.joinNodes(newNodeIPList); err != nil {
+		// the nodes joined are still set up if the others failed, so that they
+		// are able to be kept when the failed ones are tolerated
+		err := k.joinNodes(newNodeIPList)
+		joined := newNodeIPList
+		if hostErrs, ok := parallel.AsHostErrors(err); ok {
+			joined = stringsutil.RemoveSubSlice(newNodeIPList, hostErrs.Hosts())
+		} else if err != nil {
 			return err
 		}
-		return k.copyKubeConfigFileToNodes(newNodeIPList...)
+		if len(joined) > 0 {
+			if cerr := k.copyKubeConfigFileToNodes(joined...); cerr != nil {
+				return cerr
+			}
+		}
+		return err
 	}
 	return nil
 }
@@ -17,18 +17,17 @@ limitations under the License.
 package kubernetes
 
 import (
-	"context"
 	"fmt"
 	"path"
 	"strings"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/registry/helpers"
 	"github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 func (k *KubeadmRuntime) getKubeVersion() string {
@@ -95,24 +94,34 @@ func (k *KubeadmRuntime) syncNodeIPVSYaml(masterIPs, nodesIPs []string) error {
 		masters = append(masters, fmt.Sprintf("%s:%d", iputils.GetHostIP(master), k.getAPIServerPort()))
 	}
 
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range nodesIPs {
-		node := node
-		eg.Go(func() error {
-			logger.Info("start to sync lvscare static pod to node: %s master: %+v", node, masters)
-			err := k.execIPVSPod(node, masters)
-			if err != nil {
-				return fmt.Errorf("update lvscare static pod failed %s %v", node, err)
-			}
-			return nil
-		})
+	var rc *v1beta1.RegistryConfig
+	if k.cluster.IsRegistryHA() {
+		rc = helpers.GetRegistryInfo(k.execer, k.pathResolver.RootFSPath(), k.cluster.GetRegistryIPAndPort())
 	}
-	return eg.Wait()
+	return parallel.Run(nodesIPs, func(node string) error {
+		logger.Info("start to sync lvscare static pod to node: %s master: %+v", node, masters)
+		if err := k.execIPVSPod(node, masters); err != nil {
+			return fmt.Errorf("update lvscare static pod failed %s %v", node, err)
+		}
+		if rc != nil {
+			if err := k.execRegistryIPVSPod(node, rc); err != nil {
+				return fmt.Errorf("update registry lvscare static pod failed %s %v", node, err)
+			}
+		}
+		return nil
+	})
 }
 
 func (k *KubeadmRuntime) execIPVSPod(ip string, masters []string) error {
 	image := k.cluster.GetLvscareImage()
-	return k.remoteUtil.StaticPod(ip, k.getVipAndPort(), constants.LvsCareStaticPodName, image, masters, kubernetesEtcStaticPod)
+	return k.remoteUtil.StaticPod(ip, k.getVipAndPort(), constants.LvsCareStaticPodName, image, masters, kubernetesEtcStaticPod, k.cluster.GetLvscareMetricsPort())
+}
+
+// execRegistryIPVSPod balances sealos.hub across the registry replicas.
+func (k *KubeadmRuntime) execRegistryIPVSPod(ip string, rc *v1beta1.RegistryConfig) error {
+	image := k.cluster.GetLvscareImage()
+	return k.remoteUtil.StaticPod(ip, helpers.ReplicaVIPAddress(k.cluster, rc), constants.LvsCareRegistryStaticPodName, image,
+		helpers.ReplicaAddresses(k.cluster, rc), kubernetesEtcStaticPod, 0, helpers.ReplicaLvscareOptionsOn(k.remoteUtil, ip)...)
 }
 
 func (k *KubeadmRuntime) execToken(ip, certificateKey string) (string, error) {
@@ -15,13 +15,11 @@
 package kubernetes
 
 import (
-	"context"
 	"fmt"
 	"path/filepath"
 
-	"golang.org/x/sync/errgroup"
-
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 const (
@@ -52,18 +50,13 @@ func (k *KubeadmRuntime) copyStaticFiles(nodes []string) error {
 	for _, file := range MasterStaticFiles {
 		staticFilePath := filepath.Join(k.pathResolver.RootFSStaticsPath(), file.Name)
 		cmdLinkStatic := fmt.Sprintf(copyFileToDirCommand, file.DestinationDir, staticFilePath, filepath.Join(file.DestinationDir, file.Name))
-		eg, _ := errgroup.WithContext(context.Background())
-		for _, host := range nodes {
-			host := host
-			eg.Go(func() error {
-				err := k.sshCmdAsync(host, cmdLinkStatic)
-				if err != nil {
-					return fmt.Errorf("failed to copy static file to %s: %s", host, err.Error())
-				}
-				return nil
-			})
-		}
-		if err := eg.Wait(); err != nil {
+		if err := parallel.Run(nodes, func(host string) error {
+			err := k.sshCmdAsync(host, cmdLinkStatic)
+			if err != nil {
+				return fmt.Errorf("failed to copy static file to %s: %s", host, err.Error())
+			}
+			return nil
+		}); err != nil {
 			return err
 		}
 	}
@@ -24,6 +24,7 @@ import (
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
 
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/runtime/decode"
 	"github.com/labring/sealos/pkg/runtime/kubernetes/types"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -33,7 +34,6 @@ import (
 const (
 	upgradeApplyCmd = "kubeadm upgrade apply --yes %s"
 	upradeNodeCmd   = "kubeadm upgrade node"
-	//drainNodeCmd    = "kubectl drain %s --ignore-daemonsets"
 	cordonNodeCmd   = "kubectl cordon %s"
 	uncordonNodeCmd = "kubectl uncordon %s"
 	daemonReload    = "systemctl daemon-reload"
@@ -91,8 +91,14 @@ func (k *KubeadmRuntime) upgradeMaster0(version string) error {
 		fmt.Sprintf(installKubeadmCmd, kubeBinaryPath),
 		//execute  kubeadm upgrade apply {version} at master0
 		fmt.Sprintf(upgradeApplyCmd, version),
-		//kubectl cordon <node-to-cordon>
-		fmt.Sprintf(cordonNodeCmd, master0Name),
+	)
+	if err != nil {
+		return err
+	}
+	if err = k.drainOrCordonNode(master0ip, master0Name); err != nil {
+		return err
+	}
+	err = k.sshCmdAsync(master0ip,
 		//install kubelet:{version},kubectl{version} at master0
 		fmt.Sprintf(installKubectlCmd, kubeBinaryPath),
 		fmt.Sprintf(installKubeletCmd, kubeBinaryPath),
@@ -131,8 +137,14 @@ func (k *KubeadmRuntime) upgradeOtherNodes(ips []string, version string) error {
 			fmt.Sprintf(installKubeadmCmd, kubeBinaryPath),
 			//upgrade other control-plane and nodes
 			upradeNodeCmd,
-			//kubectl cordon <node-to-cordon>
-			fmt.Sprintf(cordonNodeCmd, nodename),
+		)
+		if err != nil {
+			return err
+		}
+		if err = k.drainOrCordonNode(ip, nodename); err != nil {
+			return err
+		}
+		err = k.sshCmdAsync(ip,
 			//install kubelet:{version},kubectl{version} at the node
 			fmt.Sprintf(installKubectlCmd, kubeBinaryPath),
 			fmt.Sprintf(installKubeletCmd, kubeBinaryPath),
@@ -234,6 +246,20 @@ func (k *KubeadmRuntime) pingAPIServer() error {
 	return nil
 }
 
+// drainOrCordonNode evicts the pods of the node before kubelet restarts, or only cordons it if draining is skipped.
+func (k *KubeadmRuntime) drainOrCordonNode(ip, nodename string) error {
+	opts := kubernetes.GetDrainOptions()
+	if !opts.Enabled {
+		//kubectl cordon <node-to-cordon>
+		return k.sshCmdAsync(ip, fmt.Sprintf(cordonNodeCmd, nodename))
+	}
+	client, err := k.getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return kubernetes.DrainNode(context.Background(), client.Kubernetes(), nodename, opts)
+}
+
 func (k *KubeadmRuntime) tryUncordonNode(ip, nodename string) error {
 	err := k.sshCmdAsync(ip, fmt.Sprintf(uncordonNodeCmd, nodename))
 	timeout := time.Now().Add(1 * time.Minute)
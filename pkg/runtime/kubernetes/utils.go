@@ -21,12 +21,13 @@ import (
 	"fmt"
 	"path"
 
-	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 func (k *KubeadmRuntime) runPipelines(phase string, pipelines ...func() error) error {
@@ -60,17 +61,12 @@ func (k *KubeadmRuntime) sendNewCertAndKey(hosts []string) error {
 }
 
 func (k *KubeadmRuntime) sendFileToHosts(Hosts []string, src, dst string) error {
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range Hosts {
-		node := node
-		eg.Go(func() error {
-			if err := k.sshCopy(node, src, dst); err != nil {
-				return fmt.Errorf("send file failed %v", err)
-			}
-			return nil
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(Hosts, func(node string) error {
+		if err := k.sshCopy(node, src, dst); err != nil {
+			return fmt.Errorf("send file failed %v", err)
+		}
+		return nil
+	})
 }
 
 func (k *KubeadmRuntime) removeNode(ip string) error {
@@ -93,6 +89,31 @@ func (k *KubeadmRuntime) removeNode(ip string) error {
 	return nil
 }
 
+// drainNode evicts the pods of the node before it's removed from the cluster, skipped if draining is disabled.
+func (k *KubeadmRuntime) drainNode(ip string) error {
+	opts := kubernetes.GetDrainOptions()
+	if !opts.Enabled {
+		return nil
+	}
+	client, err := k.getKubeInterface()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	exp := kubernetes.NewKubeExpansion(client.Kubernetes())
+	hostname, err := exp.FetchHostNameFromInternalIP(ctx, iputils.GetHostIP(ip))
+	if err != nil {
+		return fmt.Errorf("cannot get node with ip address %s: %v", ip, err)
+	}
+	return kubernetes.DrainNode(ctx, client.Kubernetes(), hostname, opts)
+}
+
+// drainNodeToDelete drains the node before it's deleted, the error is ignored
+// if the nodes are deleted by force.
+func (k *KubeadmRuntime) drainNodeToDelete(ip string) error {
+	return kubernetes.CheckDrainError(ip, k.drainNode(ip), kubernetes.GetDrainOptions())
+}
+
 func (k *KubeadmRuntime) setFeatureGatesConfiguration() {
 	k.kubeadmConfig.FinalizeFeatureGatesConfiguration()
 }
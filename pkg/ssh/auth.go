@@ -0,0 +1,229 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/containers/storage/pkg/homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	fileutils "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// defaultIdentities are the private keys tried in ~/.ssh, like what OpenSSH does.
+var defaultIdentities = []string{"id_rsa", "id_ecdsa", "id_ed25519", "id_dsa"}
+
+const certSuffix = "-cert.pub"
+
+func defaultIdentityFiles() []string {
+	var files []string
+	for _, fn := range defaultIdentities {
+		if absPath := path.Join(homedir.Get(), ".ssh", fn); fileutils.IsExist(absPath) {
+			files = append(files, absPath)
+		}
+	}
+	return files
+}
+
+func isDefaultIdentityFile(file string) bool {
+	for _, fn := range defaultIdentities {
+		if file == path.Join(homedir.Get(), ".ssh", fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPublicKeysAuth returns the publickey auth method with the signers of private
+// keys, certificates and ssh-agent. They are put in a single method since the
+// client never tries the same method twice.
+func newPublicKeysAuth(opt *Option) (ssh.AuthMethod, error) {
+	var signers []ssh.Signer
+	if len(opt.rawPrivateKeyData) > 0 {
+		signer, err := parsePrivateKey([]byte(opt.rawPrivateKeyData), []byte(opt.passphrase))
+		if err != nil {
+			return nil, err
+		}
+		if signer, err = withCertificate(signer, opt.certificate); err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	} else if len(opt.privateKey) > 0 {
+		if !fileutils.IsExist(opt.privateKey) {
+			logger.Debug("not trying to parse private key file cause it's not exists")
+		} else {
+			signer, err := parsePrivateKeyFile(opt.privateKey, opt.passphrase)
+			if err != nil {
+				return nil, err
+			}
+			if signer, err = withCertificate(signer, certificateOf(opt.privateKey, opt.certificate)); err != nil {
+				return nil, err
+			}
+			signers = append(signers, signer)
+		}
+	}
+	// the other default keys are tried only if the key is not specified
+	if len(opt.rawPrivateKeyData) == 0 && (opt.privateKey == "" || isDefaultIdentityFile(opt.privateKey)) {
+		for _, file := range defaultIdentityFiles() {
+			if file == opt.privateKey {
+				continue
+			}
+			signer, err := parsePrivateKeyFile(file, "")
+			if err != nil {
+				logger.Debug("skip private key %s: %v", file, err)
+				continue
+			}
+			if signer, err = withCertificate(signer, certificateOf(file, "")); err != nil {
+				logger.Debug("skip certificate of private key %s: %v", file, err)
+			}
+			signers = append(signers, signer)
+		}
+	}
+
+	useAgent := opt.agent || (len(signers) == 0 && len(opt.password) == 0)
+	if useAgent {
+		if _, err := defaultAgent.client(); err != nil {
+			if opt.agent {
+				return nil, err
+			}
+			logger.Debug("not using ssh-agent: %v", err)
+			useAgent = false
+		}
+	}
+	if len(signers) == 0 && !useAgent {
+		return nil, nil
+	}
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		if !useAgent {
+			return signers, nil
+		}
+		agentSigners, err := defaultAgent.signers()
+		if err != nil {
+			logger.Warn("failed to get keys from ssh-agent: %v", err)
+			return signers, nil
+		}
+		return append(agentSigners, signers...), nil
+	}), nil
+}
+
+// defaultAgent is the connection to ssh-agent shared by all the clients of process.
+var defaultAgent = &sharedAgent{}
+
+// sharedAgent connects to ssh-agent on demand, the connection is reused until it's broken.
+type sharedAgent struct {
+	mu   sync.Mutex
+	conn net.Conn
+	ac   agent.ExtendedAgent
+}
+
+func (a *sharedAgent) client() (agent.ExtendedAgent, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ac != nil {
+		return a.ac, nil
+	}
+	conn, err := dialAgent()
+	if err != nil {
+		return nil, err
+	}
+	a.conn, a.ac = conn, agent.NewClient(conn)
+	return a.ac, nil
+}
+
+// signers returns the keys of ssh-agent, the connection is closed and dialed
+// again once if it's broken, e.g. ssh-agent is restarted.
+func (a *sharedAgent) signers() ([]ssh.Signer, error) {
+	var err error
+	for i := 0; i < 2; i++ {
+		var ac agent.ExtendedAgent
+		if ac, err = a.client(); err != nil {
+			return nil, err
+		}
+		var signers []ssh.Signer
+		if signers, err = ac.Signers(); err == nil {
+			return signers, nil
+		}
+		a.close(ac)
+	}
+	return nil, err
+}
+
+// close closes the connection if it's still the one of ac.
+func (a *sharedAgent) close(ac agent.ExtendedAgent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ac != ac || a.conn == nil {
+		return
+	}
+	_ = a.conn.Close()
+	a.conn, a.ac = nil, nil
+}
+
+// dialAgent connects to the ssh-agent listening on SSH_AUTH_SOCK.
+func dialAgent() (net.Conn, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, errors.New("ssh-agent is not available, SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh-agent %s: %v", sock, err)
+	}
+	return conn, nil
+}
+
+// certificateOf returns the certificate file of the private key, which is
+// <private key>-cert.pub if it exists and not specified.
+func certificateOf(privateKey, certificate string) string {
+	if certificate != "" {
+		return certificate
+	}
+	if file := privateKey + certSuffix; fileutils.IsExist(file) {
+		return file
+	}
+	return ""
+}
+
+// withCertificate returns a signer that presents the OpenSSH user certificate.
+func withCertificate(signer ssh.Signer, certificate string) (ssh.Signer, error) {
+	if certificate == "" {
+		return signer, nil
+	}
+	data, err := os.ReadFile(certificate)
+	if err != nil {
+		return signer, fmt.Errorf("failed to read certificate file %v", err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return signer, fmt.Errorf("failed to parse certificate %s: %v", certificate, err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return signer, fmt.Errorf("%s is not a certificate", certificate)
+	}
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return signer, fmt.Errorf("certificate %s does not match the private key: %v", certificate, err)
+	}
+	return certSigner, nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func newTestSigner(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, priv
+}
+
+func TestWithCertificate(t *testing.T) {
+	ca, _ := newTestSigner(t)
+	signer, _ := newTestSigner(t)
+	cert := &ssh.Certificate{
+		Key:             signer.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"root"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	certFile := keyFile + certSuffix
+	if err := os.WriteFile(certFile, ssh.MarshalAuthorizedKey(cert), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := certificateOf(keyFile, ""); got != certFile {
+		t.Fatalf("certificateOf() = %s, want %s", got, certFile)
+	}
+
+	got, err := withCertificate(signer, certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.PublicKey().(*ssh.Certificate); !ok {
+		t.Errorf("withCertificate() public key = %T, want *ssh.Certificate", got.PublicKey())
+	}
+
+	other, _ := newTestSigner(t)
+	if _, err = withCertificate(other, certFile); err == nil {
+		t.Error("withCertificate() expected error for the certificate of another key")
+	}
+}
+
+func TestSharedAgent(t *testing.T) {
+	_, priv := newTestSigner(t)
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			go func() {
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	accepted := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(conns)
+	}
+
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	a := &sharedAgent{}
+	defer a.close(a.ac)
+	for i := 0; i < 3; i++ {
+		signers, err := a.signers()
+		if err != nil || len(signers) != 1 {
+			t.Fatalf("signers() = %d signers, %v, want 1", len(signers), err)
+		}
+	}
+	if n := accepted(); n != 1 {
+		t.Errorf("ssh-agent is dialed %d times, want the connection shared", n)
+	}
+
+	// a broken connection is dialed again
+	mu.Lock()
+	_ = conns[0].Close()
+	mu.Unlock()
+	if signers, err := a.signers(); err != nil || len(signers) != 1 {
+		t.Errorf("signers() after the connection broken = %d signers, %v, want 1", len(signers), err)
+	}
+	if n := accepted(); n != 2 {
+		t.Errorf("ssh-agent is dialed %d times, want 2", n)
+	}
+
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if _, err = (&sharedAgent{}).client(); err == nil {
+		t.Error("client() expected error without SSH_AUTH_SOCK")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/types/v1beta1"
 )
 
@@ -28,6 +29,7 @@ type clusterClient struct {
 	configs  map[string]*Option
 	cache    map[*Option]Interface
 	mutex    sync.RWMutex
+	pool     *connPool
 }
 
 func OverSSHConfig(original, override *v1beta1.SSH) {
@@ -47,9 +49,24 @@ func OverSSHConfig(original, override *v1beta1.SSH) {
 		if override.PkPasswd != "" {
 			original.PkPasswd = override.PkPasswd
 		}
+		if override.Cert != "" {
+			original.Cert = override.Cert
+		}
+		if override.Agent {
+			original.Agent = override.Agent
+		}
 		if override.Port > 0 {
 			original.Port = override.Port
 		}
+		if len(override.ProxyJump) > 0 {
+			original.ProxyJump = override.ProxyJump
+		}
+		if override.HostKeyPolicy != "" {
+			original.HostKeyPolicy = override.HostKeyPolicy
+		}
+		if len(override.HostKeyFingerprints) > 0 {
+			original.HostKeyFingerprints = override.HostKeyFingerprints
+		}
 	}
 }
 
@@ -70,6 +87,7 @@ func (cc *clusterClient) getSSHOptionForHost(host string) (*Option, error) {
 	}
 
 	opt := newOptionFromSSH(sshConfig, cc.isStdout)
+	WithKnownHostsFile(constants.KnownHostsFile(cc.cluster.Name))(opt)
 	cc.mutex.Lock()
 	cc.configs[host] = opt
 	cc.mutex.Unlock()
@@ -85,11 +103,12 @@ func (cc *clusterClient) getClientForHost(host string) (Interface, error) {
 	client := cc.cache[sshConfig]
 	cc.mutex.RUnlock()
 	if client == nil {
-		var err error
-		client, err = newFromOptions(sshConfig)
+		c, err := newFromOptions(sshConfig)
 		if err != nil {
 			return nil, err
 		}
+		c.pool = cc.pool
+		client = c
 		cc.mutex.Lock()
 		cc.cache[sshConfig] = client
 		cc.mutex.Unlock()
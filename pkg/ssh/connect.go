@@ -46,26 +46,70 @@ var hostsClientMap = &HostClientMap{
 func (c *Client) connect(host string) (*ssh.Client, error) {
 	ip, port := iputils.GetSSHHostIPAndPort(host)
 	addr := formalizeAddr(ip, port)
-	return ssh.Dial("tcp", addr, c.ClientConfig)
+	if len(c.jumps) == 0 {
+		return ssh.Dial("tcp", addr, c.ClientConfig)
+	}
+	return dialThroughJumps(c.jumps, addr, c.ClientConfig)
+}
+
+// dialThroughJumps connects to addr through the chain of jump hosts, the
+// connections to jump hosts are closed once the returned client is closed.
+func dialThroughJumps(jumps []jump, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var clients []*ssh.Client
+	closeAll := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			_ = clients[i].Close()
+		}
+	}
+	client, err := ssh.Dial("tcp", jumps[0].addr, jumps[0].config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to jump host %s: %v", jumps[0].addr, err)
+	}
+	clients = append(clients, client)
+	hops := make([]jump, 0, len(jumps))
+	hops = append(hops, jumps[1:]...)
+	hops = append(hops, jump{addr: addr, config: config})
+	for _, hop := range hops {
+		conn, err := client.Dial("tcp", hop.addr)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to dial %s through jump host: %v", hop.addr, err)
+		}
+		c, chans, reqs, err := ssh.NewClientConn(conn, hop.addr, hop.config)
+		if err != nil {
+			_ = conn.Close()
+			closeAll()
+			return nil, fmt.Errorf("failed to connect to %s through jump host: %v", hop.addr, err)
+		}
+		client = ssh.NewClient(c, chans, reqs)
+		clients = append(clients, client)
+	}
+	go func() {
+		_ = client.Wait()
+		closeAll()
+	}()
+	return client, nil
 }
 
 func newSession(client *ssh.Client) (*ssh.Session, error) {
 	session, err := client.NewSession()
 	if err != nil {
-		_ = client.Close()
 		return nil, err
 	}
+	if err := requestPty(session); err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+func requestPty(session *ssh.Session) error {
 	modes := ssh.TerminalModes{
 		ssh.ECHO:          0,     //disable echoing
 		ssh.TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
 		ssh.TTY_OP_OSPEED: 14400, // output speed = 14.4kbaud
 	}
-	if err := session.RequestPty("xterm", 80, 40, modes); err != nil {
-		_ = session.Close()
-		_ = client.Close()
-		return nil, err
-	}
-	return session, nil
+	return session.RequestPty("xterm", 80, 40, modes)
 }
 
 func (c *Client) Connect(host string) (sshClient *ssh.Client, session *ssh.Session, err error) {
@@ -76,6 +120,70 @@ func (c *Client) Connect(host string) (sshClient *ssh.Client, session *ssh.Sessi
 	return
 }
 
+func (c *Client) connectWithRetry(host string) (sshClient *ssh.Client, err error) {
+	err = exponentialBackOffRetry(defaultMaxRetry, time.Millisecond*100, 2, func() error {
+		sshClient, err = c.connect(host)
+		return err
+	}, isErrorWorthRetry)
+	return
+}
+
+func (c *Client) poolKey(host string) string {
+	return c.user + "@" + host
+}
+
+func (c *Client) pooledConnect(host string) (*ssh.Client, error) {
+	return c.pool.get(c.poolKey(host), func() (*ssh.Client, error) { return c.connectWithRetry(host) })
+}
+
+// openSession opens a session to host, which is multiplexed over the pooled
+// connection of host if the client has a pool. release must be called once the
+// session is done, which closes the connection as well if it's not pooled.
+func (c *Client) openSession(host string) (session *ssh.Session, release func(), err error) {
+	if c.pool == nil {
+		sshClient, session, err := c.Connect(host)
+		if err != nil {
+			return nil, nil, err
+		}
+		return session, func() {
+			_ = session.Close()
+			_ = sshClient.Close()
+		}, nil
+	}
+	for retried := false; ; retried = true {
+		sshClient, err := c.pooledConnect(host)
+		if err != nil {
+			return nil, nil, err
+		}
+		session, err = sshClient.NewSession()
+		if err == nil {
+			if err = requestPty(session); err != nil {
+				_ = session.Close()
+				return nil, nil, err
+			}
+			return session, func() { _ = session.Close() }, nil
+		}
+		if isSessionRefused(err) {
+			// too many sessions on the pooled connection, use a dedicated one
+			c.pool.record(func(s *poolStats) { s.fallbacks++ })
+			c.pool.debug(fmt.Sprintf("sessions to %s refused: %v, falling back to a new connection", host, err))
+			sshClient, session, err := c.Connect(host)
+			if err != nil {
+				return nil, nil, err
+			}
+			return session, func() {
+				_ = session.Close()
+				_ = sshClient.Close()
+			}, nil
+		}
+		// the pooled connection is broken, reconnect once
+		c.pool.evict(c.poolKey(host), sshClient)
+		if retried {
+			return nil, nil, err
+		}
+	}
+}
+
 func isErrorWorthRetry(err error) bool {
 	return strings.Contains(err.Error(), "connection reset by peer") ||
 		strings.Contains(err.Error(), io.EOF.Error())
@@ -108,6 +216,9 @@ func (c *Client) newClientAndSession(host string) (*ssh.Client, *ssh.Session, er
 		return nil, nil, err
 	}
 	session, err := newSession(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+	}
 	return sshClient, session, err
 }
 
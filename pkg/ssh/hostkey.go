@@ -0,0 +1,183 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// the known hosts files are read and appended by the connections in parallel
+var knownHostsMutex sync.Mutex
+
+func newHostKeyCallback(opt *Option) (ssh.HostKeyCallback, error) {
+	if len(opt.hostKeyFingerprints) > 0 {
+		return pinnedHostKeyCallback(opt.hostKeyFingerprints), nil
+	}
+	switch opt.hostKeyPolicy {
+	case "", v2.HostKeyPolicyInsecure:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case v2.HostKeyPolicyStrict:
+		return strictHostKeyCallback(filepath.Join(constants.GetHomeDir(), ".ssh", constants.DefaultKnownHostsFileName)), nil
+	case v2.HostKeyPolicyTOFU:
+		if opt.knownHostsFile == "" {
+			return nil, fmt.Errorf("host key policy %s requires a known hosts file to record the keys", opt.hostKeyPolicy)
+		}
+		return tofuHostKeyCallback(opt.knownHostsFile), nil
+	default:
+		return nil, fmt.Errorf("unknown host key policy %q, must be one of %s, %s and %s", opt.hostKeyPolicy,
+			v2.HostKeyPolicyInsecure, v2.HostKeyPolicyStrict, v2.HostKeyPolicyTOFU)
+	}
+}
+
+func pinnedHostKeyCallback(fingerprints []string) ssh.HostKeyCallback {
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		fp := ssh.FingerprintSHA256(key)
+		for _, want := range fingerprints {
+			if fp == want {
+				return nil
+			}
+		}
+		return fmt.Errorf("host key fingerprint %s of %s does not match the pinned fingerprints %v", fp, hostname, fingerprints)
+	}
+}
+
+func strictHostKeyCallback(file string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		callback, err := knownhosts.New(file)
+		if err != nil {
+			return fmt.Errorf("failed to load known hosts %s: %v", file, err)
+		}
+		if err = callback(hostname, remote, key); err != nil {
+			return hostKeyError(hostname, key, file, err)
+		}
+		return nil
+	}
+}
+
+func tofuHostKeyCallback(file string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		knownHostsMutex.Lock()
+		defer knownHostsMutex.Unlock()
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		callback, err := knownhosts.New(file)
+		if err != nil {
+			return fmt.Errorf("failed to load known hosts %s: %v", file, err)
+		}
+		err = callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return hostKeyError(hostname, key, file, err)
+		}
+		logger.Info("trust host key %s of %s on first use", ssh.FingerprintSHA256(key), hostname)
+		_, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+		return err
+	}
+}
+
+func hostKeyError(hostname string, key ssh.PublicKey, file string, err error) error {
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+	fp := ssh.FingerprintSHA256(key)
+	if len(keyErr.Want) == 0 {
+		return fmt.Errorf("host key fingerprint %s of %s is unknown, it's not in %s", fp, hostname, file)
+	}
+	want := make([]string, 0, len(keyErr.Want))
+	for _, k := range keyErr.Want {
+		want = append(want, fmt.Sprintf("%s (%s:%d)", ssh.FingerprintSHA256(k.Key), k.Filename, k.Line))
+	}
+	return fmt.Errorf("host key fingerprint %s of %s does not match the known %s, "+
+		"the host might be spoofed, or reset the key if it's reinstalled", fp, hostname, strings.Join(want, ", "))
+}
+
+// ResetKnownHosts removes the recorded keys of hosts from the known hosts file,
+// a host is either an ip or an ip:port, which removes the keys of all ports if no
+// port is given. It returns the number of removed lines.
+func ResetKnownHosts(file string, hosts ...string) (int, error) {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var (
+		kept    []string
+		removed int
+	)
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if matchKnownHostsLine(line, hosts) {
+			removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	content := strings.Join(kept, "\n")
+	if len(kept) > 0 {
+		content += "\n"
+	}
+	return removed, os.WriteFile(file, []byte(content), 0600)
+}
+
+func matchKnownHostsLine(line string, hosts []string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return false
+	}
+	for _, pattern := range strings.Split(fields[0], ",") {
+		for _, host := range hosts {
+			if strings.Contains(host, ":") {
+				if pattern == knownhosts.Normalize(host) {
+					return true
+				}
+			} else if pattern == host || strings.HasPrefix(pattern, "["+host+"]:") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func newTestPublicKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestTOFUHostKeyCallback(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	callback, err := newHostKeyCallback(&Option{hostKeyPolicy: v2.HostKeyPolicyTOFU, knownHostsFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, other := newTestPublicKey(t), newTestPublicKey(t)
+	remote := &net.TCPAddr{IP: net.ParseIP("192.168.0.2"), Port: 22}
+
+	if err = callback("192.168.0.2:22", remote, key); err != nil {
+		t.Fatalf("first use error = %v", err)
+	}
+	if err = callback("192.168.0.2:22", remote, key); err != nil {
+		t.Fatalf("known key error = %v", err)
+	}
+	err = callback("192.168.0.2:22", remote, other)
+	if err == nil || !strings.Contains(err.Error(), ssh.FingerprintSHA256(other)) {
+		t.Fatalf("changed key error = %v, want an error with the fingerprint", err)
+	}
+
+	removed, err := ResetKnownHosts(file, "192.168.0.2")
+	if err != nil || removed != 1 {
+		t.Fatalf("ResetKnownHosts() = %d, %v, want 1 removed", removed, err)
+	}
+	if err = callback("192.168.0.2:22", remote, other); err != nil {
+		t.Errorf("key after reset error = %v", err)
+	}
+}
+
+func TestPinnedHostKeyCallback(t *testing.T) {
+	key := newTestPublicKey(t)
+	callback, err := newHostKeyCallback(&Option{
+		hostKeyPolicy:       v2.HostKeyPolicyStrict,
+		hostKeyFingerprints: []string{ssh.FingerprintSHA256(key)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = callback("192.168.0.2:22", nil, key); err != nil {
+		t.Errorf("pinned key error = %v", err)
+	}
+	if err = callback("192.168.0.2:22", nil, newTestPublicKey(t)); err == nil {
+		t.Error("expected error for key not pinned")
+	}
+
+	if _, err = newHostKeyCallback(&Option{hostKeyPolicy: "unknown"}); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -15,15 +15,12 @@
 package ssh
 
 import (
-	"net"
-	"path"
 	"time"
 
-	"github.com/containers/storage/pkg/homedir"
 	"github.com/spf13/pflag"
 	"golang.org/x/crypto/ssh"
 
-	"github.com/labring/sealos/pkg/utils/file"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 )
 
 type Option struct {
@@ -34,8 +31,21 @@ type Option struct {
 	privateKey        string
 	rawPrivateKeyData string
 	passphrase        string
+	certificate       string
+	agent             bool
 	timeout           time.Duration
-	hostKeyCallback   ssh.HostKeyCallback
+	// hostKeyCallback overrides the callback created by the host key policy if set
+	hostKeyCallback     ssh.HostKeyCallback
+	hostKeyPolicy       v2.HostKeyPolicy
+	hostKeyFingerprints []string
+	knownHostsFile      string
+	proxyJumps          []proxyJump
+}
+
+// proxyJump is a jump host that the connection is dialed through.
+type proxyJump struct {
+	addr string
+	opt  *Option
 }
 
 func (o *Option) BindFlags(fs *pflag.FlagSet) {
@@ -46,6 +56,9 @@ func (o *Option) BindFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&o.privateKey, "private-key", "i", o.privateKey,
 		"selects a file from which the identity (private key) for public key authentication is read")
 	fs.StringVar(&o.passphrase, "passphrase", o.passphrase, "passphrase for decrypting a PEM encoded private key")
+	fs.StringVar(&o.certificate, "certificate", o.certificate,
+		"OpenSSH user certificate signed for the private key, defaults to <private key>-cert.pub if exists")
+	fs.BoolVar(&o.agent, "agent", o.agent, "authenticate with the keys in ssh-agent listening on SSH_AUTH_SOCK")
 	fs.DurationVar(&o.timeout, "timeout", o.timeout, "ssh connection establish timeout")
 }
 
@@ -54,23 +67,14 @@ const (
 )
 
 func NewOption() *Option {
-	homedir := homedir.Get()
-	getSSHFile := func(filenames ...string) string {
-		for _, fn := range filenames {
-			absPath := path.Join(homedir, ".ssh", fn)
-			if file.IsExist(absPath) {
-				return absPath
-			}
-		}
-		return ""
+	var privateKey string
+	if files := defaultIdentityFiles(); len(files) > 0 {
+		privateKey = files[0]
 	}
 	opt := &Option{
 		user:       defaultUsername,
-		privateKey: getSSHFile("id_rsa", "id_dsa"),
+		privateKey: privateKey,
 		timeout:    10 * time.Second,
-		hostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			return nil
-		},
 	}
 	return opt
 }
@@ -115,6 +119,21 @@ func WithPrivateKeyAndPhrase(pk, passphrase string) OptionFunc {
 	}
 }
 
+// WithCertificate sets the OpenSSH user certificate signed for the private key.
+func WithCertificate(certificate string) OptionFunc {
+	return func(o *Option) {
+		o.certificate = certificate
+	}
+}
+
+// WithAgentEnable authenticates with the keys in ssh-agent as well, ssh-agent is
+// only tried if neither a private key nor a password is given by default.
+func WithAgentEnable(b bool) OptionFunc {
+	return func(o *Option) {
+		o.agent = b
+	}
+}
+
 func WithTimeout(timeout time.Duration) OptionFunc {
 	if timeout == 0 {
 		timeout = 10 * time.Second
@@ -129,3 +148,28 @@ func WithHostKeyCallback(fn ssh.HostKeyCallback) OptionFunc {
 		o.hostKeyCallback = fn
 	}
 }
+
+// WithHostKeyPolicy sets how the host keys are verified, the host keys are
+// only checked against the fingerprints if any of them is given.
+func WithHostKeyPolicy(policy v2.HostKeyPolicy, fingerprints ...string) OptionFunc {
+	return func(o *Option) {
+		o.hostKeyPolicy = policy
+		o.hostKeyFingerprints = fingerprints
+	}
+}
+
+// WithKnownHostsFile sets the file that the host keys are recorded in by the
+// tofu policy, which is inherited by the jump hosts.
+func WithKnownHostsFile(file string) OptionFunc {
+	return func(o *Option) {
+		o.knownHostsFile = file
+	}
+}
+
+// WithProxyJump appends a jump host to the chain, addr is in the form of ip:port,
+// opt is used to authenticate to the jump host.
+func WithProxyJump(addr string, opt *Option) OptionFunc {
+	return func(o *Option) {
+		o.proxyJumps = append(o.proxyJumps, proxyJump{addr: addr, opt: opt})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// the pools of clusters, which are shared by the clients of a cluster created
+// during a command.
+var (
+	clusterPools   = map[string]*connPool{}
+	clusterPoolsMu sync.Mutex
+)
+
+func getClusterPool(clusterName string) *connPool {
+	clusterPoolsMu.Lock()
+	defer clusterPoolsMu.Unlock()
+	p, ok := clusterPools[clusterName]
+	if !ok {
+		p = newConnPool()
+		clusterPools[clusterName] = p
+	}
+	return p
+}
+
+// connPool keeps one authenticated connection per host and user, the sessions
+// and the sftp client of a host are multiplexed over its connection.
+type connPool struct {
+	mu    sync.Mutex
+	conns map[string]*pooledConn
+	stats poolStats
+}
+
+type poolStats struct {
+	open       int
+	dials      int
+	reuses     int
+	reconnects int
+	fallbacks  int
+}
+
+// pooledConn is the connection of a host, it's redialed once it's broken.
+type pooledConn struct {
+	mu     sync.Mutex
+	client *ssh.Client
+	sftp   *sftp.Client
+	dialed bool
+}
+
+func newConnPool() *connPool {
+	return &connPool{conns: make(map[string]*pooledConn)}
+}
+
+func (p *connPool) entry(key string) *pooledConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc, ok := p.conns[key]
+	if !ok {
+		pc = &pooledConn{}
+		p.conns[key] = pc
+	}
+	return pc
+}
+
+// get returns the alive connection of key, a new one is dialed if there is none.
+func (p *connPool) get(key string, dial func() (*ssh.Client, error)) (*ssh.Client, error) {
+	pc := p.entry(key)
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.client != nil {
+		p.record(func(s *poolStats) { s.reuses++ })
+		return pc.client, nil
+	}
+	client, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	reconnect := pc.dialed
+	pc.client, pc.dialed = client, true
+	p.record(func(s *poolStats) {
+		s.open++
+		s.dials++
+		if reconnect {
+			s.reconnects++
+		}
+	})
+	p.debug("connected to " + key)
+	go func() {
+		_ = client.Wait()
+		p.evict(key, client)
+	}()
+	return client, nil
+}
+
+// getSftp returns the sftp client over the connection of key.
+func (p *connPool) getSftp(key string, dial func() (*ssh.Client, error),
+	newSftp func(*ssh.Client) (*sftp.Client, error)) (*sftp.Client, error) {
+	client, err := p.get(key, dial)
+	if err != nil {
+		return nil, err
+	}
+	pc := p.entry(key)
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.client == client && pc.sftp != nil {
+		return pc.sftp, nil
+	}
+	sftpClient, err := newSftp(client)
+	if err != nil {
+		return nil, err
+	}
+	if pc.client == client {
+		pc.sftp = sftpClient
+	}
+	return sftpClient, nil
+}
+
+// evict closes the connection of key if it's still the pooled one, the next
+// get dials a new connection.
+func (p *connPool) evict(key string, client *ssh.Client) {
+	pc := p.entry(key)
+	pc.mu.Lock()
+	if pc.client != client {
+		pc.mu.Unlock()
+		return
+	}
+	if pc.sftp != nil {
+		_ = pc.sftp.Close()
+	}
+	_ = pc.client.Close()
+	pc.client, pc.sftp = nil, nil
+	pc.mu.Unlock()
+	p.record(func(s *poolStats) { s.open-- })
+	p.debug("connection to " + key + " is closed")
+}
+
+func (p *connPool) record(fn func(*poolStats)) {
+	p.mu.Lock()
+	fn(&p.stats)
+	p.mu.Unlock()
+}
+
+func (p *connPool) snapshot() poolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *connPool) debug(msg string) {
+	s := p.snapshot()
+	logger.Debug("ssh pool: %s, %d connection(s) open, %d dialed, %d reused, %d reconnected, %d fallback(s)",
+		msg, s.open, s.dials, s.reuses, s.reconnects, s.fallbacks)
+}
+
+// isSessionRefused returns true if the server refuses to open more sessions
+// on a connection, which is limited by MaxSessions of sshd.
+func isSessionRefused(err error) bool {
+	var openErr *ssh.OpenChannelError
+	return errors.As(err, &openErr)
+}
@@ -0,0 +1,129 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer is an ssh server that echoes the commands it runs.
+type testServer struct {
+	addr  string
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startTestServer(t *testing.T) *testServer {
+	hostKey, _ := newTestSigner(t)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "root" && string(pass) == "passwd" {
+				return nil, nil
+			}
+			return nil, errors.New("permission denied")
+		},
+	}
+	config.AddHostKey(hostKey)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	s := &testServer{addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				_ = req.Reply(true, nil)
+				if req.Type == "exec" {
+					var payload struct{ Command string }
+					_ = ssh.Unmarshal(req.Payload, &payload)
+					_, _ = ch.Write([]byte(payload.Command))
+					_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+					_ = ch.Close()
+				}
+			}
+		}()
+	}
+}
+
+// closeAll breaks the connections from server side.
+func (s *testServer) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func TestPooledCmd(t *testing.T) {
+	server := startTestServer(t)
+	client, err := New(nil, WithPassword("passwd"), WithPrivateKeyAndPhrase("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.pool = newConnPool()
+
+	for i := 0; i < 3; i++ {
+		out, err := client.Cmd(server.addr, "hostname")
+		if err != nil || string(out) != "hostname" {
+			t.Fatalf("Cmd() = %q, %v", out, err)
+		}
+	}
+	if s := client.pool.snapshot(); s.dials != 1 || s.reuses != 2 {
+		t.Errorf("stats = %+v, want 1 dial and 2 reuses", s)
+	}
+
+	server.closeAll()
+	// wait for the broken connection to be evicted, or it's found broken on opening session
+	time.Sleep(100 * time.Millisecond)
+	if out, err := client.Cmd(server.addr, "hostname"); err != nil || string(out) != "hostname" {
+		t.Fatalf("Cmd() after the connection broken = %q, %v", out, err)
+	}
+	if s := client.pool.snapshot(); s.dials != 2 || s.reconnects != 1 || s.open != 1 {
+		t.Errorf("stats = %+v, want reconnected with 1 connection open", s)
+	}
+}
@@ -20,6 +20,7 @@ package ssh
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/labring/sealos/pkg/utils/initsystem"
 
@@ -38,6 +39,7 @@ const (
 	cGroupCommandFmt      = "cri cgroup-driver --short"
 	socketCommandFmt      = "cri socket"
 	initSystemCommandFmt  = "initsystem %s %s"
+	ipvsHelpCommandFmt    = "ipvs --help"
 )
 
 type RenderTemplate func(name, defaultStr string, data map[string]interface{}) (string, error)
@@ -73,11 +75,15 @@ func (s *Remote) IPVS(ip, vip string, masters []string) error {
 	}
 	return s.executeRemoteUtilSubcommand(ip, out)
 }
-func (s *Remote) IPVSClean(ip, vip string) error {
-	ipvsTemplate := `ipvs --vs {{.vip}}  -C`
+
+// IPVSOnce creates the proxy rules of vip once, the real servers are probed
+// as the options of lvscare tell.
+func (s *Remote) IPVSOnce(ip, vip string, realServers []string, options ...string) error {
+	ipvsTemplate := `ipvs --vs {{.vip}}  {{range $h := .rs}}--rs  {{$h}} {{end}} {{range $o := .options}}{{$o}} {{end}} --run-once`
 	data := map[string]interface{}{
-		"vip": vip,
-		"ip":  iputils.GetHostIP(ip),
+		"vip":     vip,
+		"rs":      realServers,
+		"options": options,
 	}
 	out, err := template.RenderTemplate("ipvs", ipvsTemplate, data)
 	if err != nil {
@@ -86,16 +92,41 @@ func (s *Remote) IPVSClean(ip, vip string) error {
 	return s.executeRemoteUtilSubcommand(ip, out)
 }
 
-func (s *Remote) StaticPod(ip, vip, name, image string, masters []string, path string, options ...string) error {
-	staticPodIPVSTemplate := `static-pod lvscare --path {{.path}} --name {{.name}} --vip {{.vip}} --image {{.image}}  {{range $h := .masters}} --masters  {{$h}} {{end}} {{range $o := .options}} --options  {{$o}} {{end}}`
+// IPVSSupports returns true if the ipvs subcommand of sealctl on ip, which is
+// shipped in the cluster image along with lvscare, has the given flag.
+func (s *Remote) IPVSSupports(ip, flag string) bool {
+	out, err := s.outputRemoteUtilSubcommand(ip, ipvsHelpCommandFmt)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(out, "--"+flag+" ")
+}
+
+func (s *Remote) IPVSClean(ip, vip string, options ...string) error {
+	ipvsTemplate := `ipvs --vs {{.vip}}  {{range $o := .options}}{{$o}} {{end}} -C`
 	data := map[string]interface{}{
 		"vip":     vip,
-		"image":   image,
-		"masters": masters,
-		"name":    name,
-		"path":    path,
+		"ip":      iputils.GetHostIP(ip),
 		"options": options,
 	}
+	out, err := template.RenderTemplate("ipvs", ipvsTemplate, data)
+	if err != nil {
+		return err
+	}
+	return s.executeRemoteUtilSubcommand(ip, out)
+}
+
+func (s *Remote) StaticPod(ip, vip, name, image string, masters []string, path string, metricsPort int, options ...string) error {
+	staticPodIPVSTemplate := `static-pod lvscare --path {{.path}} --name {{.name}} --vip {{.vip}} --image {{.image}} {{if .metricsPort}}--metrics-port {{.metricsPort}}{{end}} {{range $h := .masters}} --masters  {{$h}} {{end}} {{range $o := .options}} --options  {{$o}} {{end}}`
+	data := map[string]interface{}{
+		"vip":         vip,
+		"image":       image,
+		"masters":     masters,
+		"name":        name,
+		"path":        path,
+		"metricsPort": metricsPort,
+		"options":     options,
+	}
 	out, err := template.RenderTemplate("lvscare", staticPodIPVSTemplate, data)
 	if err != nil {
 		return err
@@ -0,0 +1,58 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeSealctl answers the commands of sealctl with out and err.
+type fakeSealctl struct {
+	Interface
+	out string
+	err error
+}
+
+func (f *fakeSealctl) CmdToString(_, _, _ string) (string, error) {
+	return f.out, f.err
+}
+
+func TestIPVSSupports(t *testing.T) {
+	const help = "Flags:" +
+		"      --health-path string           health check path (default \"/healthz\")" +
+		"      --persistence-timeout duration   stick the connections of a client to one real server" +
+		"      --run-once                     create ipvs rules and exit"
+	tests := []struct {
+		name string
+		out  string
+		err  error
+		flag string
+		want bool
+	}{
+		{name: "supported", out: help, flag: "persistence-timeout", want: true},
+		{name: "prefix of another flag", out: help, flag: "persistence", want: false},
+		{name: "not supported", out: help, flag: "metrics-bind-address", want: false},
+		{name: "failed", out: "unknown command", err: errors.New("exit status 1"), flag: "run-once", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRemoteFromSSH("default", &fakeSealctl{out: tt.out, err: tt.err})
+			if got := r.IPVSSupports("192.168.0.2", tt.flag); got != tt.want {
+				t.Errorf("IPVSSupports(%s) = %v, want %v", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
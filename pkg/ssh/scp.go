@@ -75,12 +75,7 @@ func (c *Client) newClientAndSftpClient(host string) (*ssh.Client, *sftp.Client,
 	if err != nil {
 		return nil, nil, err
 	}
-	// create sftp client
-	if c.Option.sudo || c.Option.user != defaultUsername {
-		sftpClient, err = NewSudoSftpClient(sshClient, c.password)
-	} else {
-		sftpClient, err = sftp.NewClient(sshClient)
-	}
+	sftpClient, err = c.newSftpClient(sshClient)
 
 	if err == nil {
 		hc := HostClient{
@@ -93,7 +88,18 @@ func (c *Client) newClientAndSftpClient(host string) (*ssh.Client, *sftp.Client,
 	return sshClient, sftpClient, err
 }
 
+func (c *Client) newSftpClient(sshClient *ssh.Client) (*sftp.Client, error) {
+	if c.Option.sudo || c.Option.user != defaultUsername {
+		return NewSudoSftpClient(sshClient, c.password)
+	}
+	return sftp.NewClient(sshClient)
+}
+
 func (c *Client) sftpConnect(host string) (sshClient *ssh.Client, sftpClient *sftp.Client, err error) {
+	if c.pool != nil {
+		sftpClient, err = c.pool.getSftp(c.poolKey(host), func() (*ssh.Client, error) { return c.connectWithRetry(host) }, c.newSftpClient)
+		return nil, sftpClient, err
+	}
 	err = exponentialBackOffRetry(defaultMaxRetry, time.Millisecond*100, 2, func() error {
 		sshClient, sftpClient, err = c.newClientAndSftpClient(host)
 		return err
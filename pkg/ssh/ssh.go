@@ -16,15 +16,18 @@ package ssh
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/spf13/pflag"
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/sync/errgroup"
 
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
-	fileutils "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/parallel"
 )
 
 var (
@@ -65,6 +68,15 @@ type Interface interface {
 type Client struct {
 	*ssh.ClientConfig
 	*Option
+	jumps []jump
+	// pool is shared by the clients of a cluster, nil means a new connection per operation.
+	pool *connPool
+}
+
+// jump is a jump host with the ssh config to connect it.
+type jump struct {
+	addr   string
+	config *ssh.ClientConfig
 }
 
 var _ Interface = &Client{}
@@ -90,6 +102,32 @@ func newFromOptions(opt *Option, opts ...OptionFunc) (*Client, error) {
 		opts[i](opt)
 	}
 
+	config, err := newClientConfig(opt)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{ClientConfig: config, Option: opt}
+	for _, pj := range opt.proxyJumps {
+		if pj.opt.knownHostsFile == "" {
+			pj.opt.knownHostsFile = opt.knownHostsFile
+		}
+		jumpConfig, err := newClientConfig(pj.opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ssh config of jump host %s: %v", pj.addr, err)
+		}
+		client.jumps = append(client.jumps, jump{addr: pj.addr, config: jumpConfig})
+	}
+	return client, nil
+}
+
+func newClientConfig(opt *Option) (*ssh.ClientConfig, error) {
+	hostKeyCallback := opt.hostKeyCallback
+	if hostKeyCallback == nil {
+		var err error
+		if hostKeyCallback, err = newHostKeyCallback(opt); err != nil {
+			return nil, err
+		}
+	}
 	config := &ssh.ClientConfig{
 		Config: ssh.Config{
 			Ciphers: defaultCiphers,
@@ -97,29 +135,19 @@ func newFromOptions(opt *Option, opts ...OptionFunc) (*Client, error) {
 		User:            opt.user,
 		Timeout:         opt.timeout,
 		Auth:            []ssh.AuthMethod{},
-		HostKeyCallback: opt.hostKeyCallback,
+		HostKeyCallback: hostKeyCallback,
 	}
 	if len(opt.password) > 0 {
 		config.Auth = append(config.Auth, ssh.Password(opt.password))
 	}
-	if len(opt.rawPrivateKeyData) > 0 {
-		signer, err := parsePrivateKey([]byte(opt.rawPrivateKeyData), []byte(opt.passphrase))
-		if err != nil {
-			return nil, err
-		}
-		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
-	} else if len(opt.privateKey) > 0 {
-		if !fileutils.IsExist(opt.privateKey) {
-			logger.Debug("not trying to parse private key file cause it's not exists")
-		} else {
-			signer, err := parsePrivateKeyFile(opt.privateKey, opt.passphrase)
-			if err != nil {
-				return nil, err
-			}
-			config.Auth = append(config.Auth, ssh.PublicKeys(signer))
-		}
+	publicKeys, err := newPublicKeysAuth(opt)
+	if err != nil {
+		return nil, err
 	}
-	return &Client{ClientConfig: config, Option: opt}, nil
+	if publicKeys != nil {
+		config.Auth = append(config.Auth, publicKeys)
+	}
+	return config, nil
 }
 
 func newOptionFromSSH(ssh *v2.SSH, isStdout bool) *Option {
@@ -138,9 +166,21 @@ func newOptionFromSSH(ssh *v2.SSH, isStdout bool) *Option {
 	if len(ssh.PkData) > 0 {
 		opts = append(opts, WithRawPrivateKeyDataAndPhrase(ssh.PkData, ssh.PkPasswd))
 	}
+	if len(ssh.Cert) > 0 {
+		opts = append(opts, WithCertificate(ssh.Cert))
+	}
+	if ssh.Agent {
+		opts = append(opts, WithAgentEnable(true))
+	}
 	if ssh.User != "" && ssh.User != defaultUsername {
 		opts = append(opts, WithSudoEnable(true))
 	}
+	if ssh.HostKeyPolicy != "" || len(ssh.HostKeyFingerprints) > 0 {
+		opts = append(opts, WithHostKeyPolicy(ssh.HostKeyPolicy, ssh.HostKeyFingerprints...))
+	}
+	for _, pj := range ssh.ProxyJump {
+		opts = append(opts, WithProxyJump(proxyJumpAddr(pj), newOptionFromSSH(proxyJumpSSH(ssh, pj), isStdout)))
+	}
 
 	opt := NewOption()
 	for i := range opts {
@@ -149,12 +189,32 @@ func newOptionFromSSH(ssh *v2.SSH, isStdout bool) *Option {
 	return opt
 }
 
-func newFromSSH(ssh *v2.SSH, isStdout bool) (Interface, error) {
-	return New(newOptionFromSSH(ssh, isStdout))
+// proxyJumpSSH returns the ssh config of the jump host, which is the config of
+// the target host overridden by the one of the jump host.
+func proxyJumpSSH(target *v2.SSH, pj v2.ProxyJump) *v2.SSH {
+	ret := target.DeepCopy()
+	ret.ProxyJump = nil
+	// the fingerprints pinned for the target never match the jump host
+	ret.HostKeyFingerprints = nil
+	// neither does the certificate of the target's key if another key is given
+	if pj.SSH != nil && (pj.SSH.Pk != "" || pj.SSH.PkData != "") {
+		ret.Cert = ""
+	}
+	OverSSHConfig(ret, pj.SSH)
+	return ret
+}
+
+func proxyJumpAddr(pj v2.ProxyJump) string {
+	defaultPort := "22"
+	if pj.SSH != nil && pj.SSH.Port > 0 {
+		defaultPort = strconv.Itoa(int(pj.SSH.Port))
+	}
+	ip, port := iputils.GetHostIPAndPortOrDefault(pj.Host, defaultPort)
+	return net.JoinHostPort(ip, port)
 }
 
-func MustNewClient(ssh *v2.SSH, isStdout bool) Interface {
-	client, err := newFromSSH(ssh, isStdout)
+func MustNewClient(ssh *v2.SSH, isStdout bool, opts ...OptionFunc) Interface {
+	client, err := New(newOptionFromSSH(ssh, isStdout), opts...)
 	if err != nil {
 		logger.Fatal("failed to create ssh client: %v", err)
 	}
@@ -167,17 +227,13 @@ func NewCacheClientFromCluster(cluster *v2.Cluster, isStdout bool) Interface {
 		isStdout: isStdout,
 		configs:  make(map[string]*Option),
 		cache:    make(map[*Option]Interface),
+		pool:     getClusterPool(cluster.Name),
 	}
 	return cc
 }
 
 func WaitReady(client Interface, _ int, hosts ...string) error {
-	eg, _ := errgroup.WithContext(context.Background())
-	for i := range hosts {
-		host := hosts[i]
-		eg.Go(func() (err error) {
-			return client.Ping(host)
-		})
-	}
-	return eg.Wait()
+	return parallel.Run(hosts, func(host string) error {
+		return client.Ping(host)
+	})
 }
@@ -20,16 +20,22 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/labring/sealos/pkg/utils/events"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
 func (c *Client) Ping(host string) error {
+	if c.pool != nil {
+		if _, err := c.pooledConnect(host); err != nil {
+			return fmt.Errorf("failed to connect %s: %v", host, err)
+		}
+		return nil
+	}
 	client, _, err := c.Connect(host)
 	if err != nil {
 		return fmt.Errorf("failed to connect %s: %v", host, err)
@@ -49,15 +55,16 @@ func (c *Client) wrapCommands(cmds ...string) string {
 	return fmt.Sprintf("sudo -E /bin/bash -c '%s'", cmdEscaped)
 }
 
-func (c *Client) CmdAsyncWithContext(ctx context.Context, host string, cmds ...string) error {
+func (c *Client) CmdAsyncWithContext(ctx context.Context, host string, cmds ...string) (err error) {
 	cmd := c.wrapCommands(cmds...)
 	logger.Debug("start to exec `%s` on %s", cmd, host)
-	client, session, err := c.Connect(host)
+	done := events.Start(events.Event{Type: events.TypeCommand, Host: host, Command: strings.Join(cmds, "; ")})
+	defer func() { done(err) }()
+	session, release, err := c.openSession(host)
 	if err != nil {
 		return fmt.Errorf("connect error: %v", err)
 	}
-	defer client.Close()
-	defer session.Close()
+	defer release()
 	stdout, err := session.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("stdout pipe %s: %v", host, err)
@@ -109,15 +116,16 @@ func (c *Client) CmdAsync(host string, cmds ...string) error {
 	return c.CmdAsyncWithContext(ctx, host, cmds...)
 }
 
-func (c *Client) Cmd(host, cmd string) ([]byte, error) {
+func (c *Client) Cmd(host, cmd string) (_ []byte, err error) {
+	done := events.Start(events.Event{Type: events.TypeCommand, Host: host, Command: cmd})
+	defer func() { done(err) }()
 	cmd = c.wrapCommands(cmd)
 	logger.Debug("start to exec `%s` on %s", cmd, host)
-	client, session, err := c.Connect(host)
+	session, release, err := c.openSession(host)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ssh session for %s: %v", host, err)
 	}
-	defer client.Close()
-	defer session.Close()
+	defer release()
 	in, err := session.StdinPipe()
 	if err != nil {
 		return nil, err
@@ -184,7 +192,7 @@ func (c *Client) handlePipe(host string, pipe io.Reader, out io.Writer, isStdout
 	r := bufio.NewReader(pipe)
 	writers := []io.Writer{out}
 	if isStdout {
-		writers = append(writers, &withPrefixWriter{prefix: host + "\t", newline: true, w: os.Stdout})
+		writers = append(writers, &withPrefixWriter{prefix: host + "\t", newline: true, w: logger.ConsoleOutput()})
 	}
 	w := io.MultiWriter(writers...)
 	var line []byte
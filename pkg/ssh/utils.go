@@ -21,8 +21,13 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/labring/sealos/pkg/utils/hash"
 )
 
+const sha256SumCmd = `sha256sum %s | cut -d" " -f1`
+
 func CopyDir(sshClient Interface, host, src, dest string, filter func(fs.DirEntry) bool) error {
 	entries, err := os.ReadDir(src)
 	if err != nil {
@@ -42,3 +47,34 @@ func CopyDir(sshClient Interface, host, src, dest string, filter func(fs.DirEntr
 	}
 	return nil
 }
+
+// FetchWithChecksum fetches a single remote file and verifies that its sha256 digest matches the remote one.
+func FetchWithChecksum(sshClient Interface, host, src, dest string) error {
+	remoteHash, err := sshClient.CmdToString(host, fmt.Sprintf(sha256SumCmd, src), "")
+	if err != nil {
+		return fmt.Errorf("failed to calculate sha256 sum of %s on %s: %v", src, host, err)
+	}
+	if err = sshClient.Fetch(host, src, dest); err != nil {
+		return err
+	}
+	if localHash := hash.FileDigest(dest); localHash != strings.TrimSpace(remoteHash) {
+		_ = os.Remove(dest)
+		return fmt.Errorf("checksum mismatch of %s fetched from %s, local %s remote %s", src, host, localHash, remoteHash)
+	}
+	return nil
+}
+
+// CopyWithChecksum copies a single local file to remote and verifies that its sha256 digest matches the local one.
+func CopyWithChecksum(sshClient Interface, host, src, dest string) error {
+	if err := sshClient.Copy(host, src, dest); err != nil {
+		return err
+	}
+	remoteHash, err := sshClient.CmdToString(host, fmt.Sprintf(sha256SumCmd, dest), "")
+	if err != nil {
+		return fmt.Errorf("failed to calculate sha256 sum of %s on %s: %v", dest, host, err)
+	}
+	if localHash := hash.FileDigest(src); localHash != strings.TrimSpace(remoteHash) {
+		return fmt.Errorf("checksum mismatch of %s copied to %s, local %s remote %s", src, host, localHash, remoteHash)
+	}
+	return nil
+}
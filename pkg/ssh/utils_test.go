@@ -0,0 +1,106 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeFileHost keeps the remote files in memory, corrupt damages the content
+// of every file transferred.
+type fakeFileHost struct {
+	Interface
+	files   map[string][]byte
+	corrupt bool
+}
+
+func (f *fakeFileHost) transfer(data []byte) []byte {
+	if f.corrupt {
+		return append([]byte("x"), data...)
+	}
+	return data
+}
+
+func (f *fakeFileHost) Copy(_, src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	f.files[dst] = f.transfer(data)
+	return nil
+}
+
+func (f *fakeFileHost) Fetch(_, src, dst string) error {
+	data, ok := f.files[src]
+	if !ok {
+		return fmt.Errorf("%s not found", src)
+	}
+	return os.WriteFile(dst, f.transfer(data), 0600)
+}
+
+func (f *fakeFileHost) CmdToString(_, cmd, _ string) (string, error) {
+	path := strings.Fields(cmd)[1]
+	data, ok := f.files[path]
+	if !ok {
+		return "", fmt.Errorf("%s not found", path)
+	}
+	return fmt.Sprintf("%x\n", sha256.Sum256(data)), nil
+}
+
+func TestFetchWithChecksum(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "snapshot.db")
+	host := &fakeFileHost{files: map[string][]byte{"/var/lib/etcd/snapshot.db": []byte("snapshot")}}
+	if err := FetchWithChecksum(host, "192.168.0.2:22", "/var/lib/etcd/snapshot.db", dst); err != nil {
+		t.Fatalf("FetchWithChecksum() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "snapshot" {
+		t.Errorf("fetched %q, want %q", data, "snapshot")
+	}
+
+	host.corrupt = true
+	err := FetchWithChecksum(host, "192.168.0.2:22", "/var/lib/etcd/snapshot.db", dst)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("FetchWithChecksum() error = %v, want checksum mismatch", err)
+	}
+	// a corrupted file is never left behind
+	if _, err = os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("corrupted %s should be removed, stat error = %v", dst, err)
+	}
+}
+
+func TestCopyWithChecksum(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := os.WriteFile(src, []byte("snapshot"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	host := &fakeFileHost{files: map[string][]byte{}}
+	if err := CopyWithChecksum(host, "192.168.0.2:22", src, "/var/lib/etcd/snapshot.db"); err != nil {
+		t.Fatalf("CopyWithChecksum() error = %v", err)
+	}
+	if got := string(host.files["/var/lib/etcd/snapshot.db"]); got != "snapshot" {
+		t.Errorf("copied %q, want %q", got, "snapshot")
+	}
+
+	host.corrupt = true
+	err := CopyWithChecksum(host, "192.168.0.2:22", src, "/var/lib/etcd/snapshot.db")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("CopyWithChecksum() error = %v, want checksum mismatch", err)
+	}
+}
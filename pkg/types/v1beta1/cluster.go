@@ -48,6 +48,12 @@ type RegistryConfig struct {
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
 	Data     string `json:"data,omitempty"`
+	// HA runs a replica of registry with its own storage on every master
+	// instead of master0 only, it's only read from the Clusterfile.
+	HA bool `json:"ha,omitempty"`
+	// VIP is the virtual IP balanced across the replicas by lvscare on the
+	// hosts running no replica.
+	VIP string `json:"vip,omitempty"`
 }
 type ImageType string
 
@@ -63,6 +69,8 @@ const (
 	ImageSealosVersionEnvSysKey = "SEALOS_SYS_SEALOS_VERSION"
 	ImageRunModeEnvSysKey       = "SEALOS_SYS_RUN_MODE"
 	ImageImageEndpointSysKey    = "SEALOS_SYS_IMAGE_ENDPOINT"
+
+	LvscareMetricsPortEnvKey = "LVSCARE_METRICS_PORT"
 )
 
 const (
@@ -208,11 +216,66 @@ func NewCancelledCommandCondition(message string) CommandCondition {
 	}
 }
 
+type StepPhase string
+
+const (
+	StepRunning   StepPhase = "Running"
+	StepSucceeded StepPhase = "Succeeded"
+	StepFailed    StepPhase = "Failed"
+)
+
+// Checkpoint records the progress of the last processor applied to the cluster,
+// so that a failed apply can be resumed from where it stopped.
+type Checkpoint struct {
+	Processor string    `json:"processor"`
+	Phase     StepPhase `json:"phase"`
+	// the images to install and the hosts to scale, which are needed to resume the processor
+	// +optional
+	Images []string `json:"images,omitempty"`
+	// +optional
+	MastersToJoin []string `json:"mastersToJoin,omitempty"`
+	// +optional
+	MastersToDelete []string `json:"mastersToDelete,omitempty"`
+	// +optional
+	NodesToJoin []string `json:"nodesToJoin,omitempty"`
+	// +optional
+	NodesToDelete []string `json:"nodesToDelete,omitempty"`
+	// +optional
+	Steps []StepCheckpoint `json:"steps,omitempty"`
+}
+
+// StepCheckpoint is the result of a pipeline step.
+type StepCheckpoint struct {
+	Name           string      `json:"name"`
+	Phase          StepPhase   `json:"phase"`
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// results of the steps running on hosts
+	// +optional
+	Hosts []HostCheckpoint `json:"hosts,omitempty"`
+	// the hosts and images that the step is applied to, a succeeded step is
+	// run again when resuming if there are more of them
+	// +optional
+	TargetHosts []string `json:"targetHosts,omitempty"`
+	// +optional
+	Images []string `json:"images,omitempty"`
+}
+
+type HostCheckpoint struct {
+	Host  string    `json:"host"`
+	Phase StepPhase `json:"phase"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 type ClusterStatus struct {
 	Phase             ClusterPhase       `json:"phase,omitempty"`
 	Mounts            []MountImage       `json:"mounts,omitempty"`
 	Conditions        []ClusterCondition `json:"conditions,omitempty"`
 	CommandConditions []CommandCondition `json:"commandCondition,omitempty"`
+	// +optional
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
 }
 
 type SSH struct {
@@ -223,6 +286,47 @@ type SSH struct {
 	Pk       string `json:"pk,omitempty"`
 	PkPasswd string `json:"pkPasswd,omitempty"`
 	Port     uint16 `json:"port,omitempty"`
+	// Cert is the OpenSSH user certificate signed for the private key,
+	// defaults to <pk>-cert.pub if it exists.
+	// +optional
+	Cert string `json:"cert,omitempty"`
+	// Agent authenticates with the keys in ssh-agent listening on SSH_AUTH_SOCK as well,
+	// ssh-agent is always tried if there is neither a private key nor a password.
+	// +optional
+	Agent bool `json:"agent,omitempty"`
+	// ProxyJump is the chain of jump hosts that the connection is dialed through, in order.
+	// +optional
+	ProxyJump []ProxyJump `json:"proxyJump,omitempty"`
+	// HostKeyPolicy is how the host keys are verified, one of insecure, strict and tofu,
+	// defaults to insecure.
+	// +optional
+	HostKeyPolicy HostKeyPolicy `json:"hostKeyPolicy,omitempty"`
+	// HostKeyFingerprints pins the SHA256 fingerprints of the host keys, e.g. SHA256:xxx,
+	// they are checked regardless of the HostKeyPolicy if set.
+	// +optional
+	HostKeyFingerprints []string `json:"hostKeyFingerprints,omitempty"`
+}
+
+type HostKeyPolicy string
+
+const (
+	// HostKeyPolicyInsecure accepts any host key.
+	HostKeyPolicyInsecure HostKeyPolicy = "insecure"
+	// HostKeyPolicyStrict only accepts the host keys in ~/.ssh/known_hosts.
+	HostKeyPolicyStrict HostKeyPolicy = "strict"
+	// HostKeyPolicyTOFU trusts the host key on first use and records it in the
+	// working dir of cluster, a changed key is rejected later.
+	HostKeyPolicyTOFU HostKeyPolicy = "tofu"
+)
+
+// ProxyJump is an intermediate host such as a bastion.
+type ProxyJump struct {
+	// Host is the address of the jump host, the port defaults to 22.
+	Host string `json:"host"`
+	// SSH overrides the ssh config of the target host to authenticate to the jump host,
+	// the proxyJump of it is ignored.
+	// +optional
+	SSH *SSH `json:"ssh,omitempty"`
 }
 
 func (s *SSH) DefaultPort() uint16 {
@@ -263,4 +367,8 @@ type ClusterSpec struct {
 	// More info: https://kubernetes.io/docs/tasks/inject-data-application/define-command-argument-container/#running-a-command-in-a-shell
 	// +optional
 	Command []string `json:"command,omitempty"`
+	// Registry configures the registry of cluster, the other fields than HA
+	// and VIP are read from etc/registry.yml of the rootfs image.
+	// +optional
+	Registry *RegistryConfig `json:"registry,omitempty"`
 }
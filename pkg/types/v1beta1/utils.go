@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"strconv"
+
 	"github.com/Masterminds/semver/v3"
 	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -58,11 +60,27 @@ func (c *Cluster) GetRegistryIPList() []string {
 func (c *Cluster) GetRegistryIPAndPortList() []string {
 	ret := c.GetIPSByRole(REGISTRY)
 	if len(ret) == 0 {
+		if c.IsRegistryHA() {
+			return c.GetMasterIPAndPortList()
+		}
 		ret = []string{c.GetMaster0IPAndPort()}
 	}
 	return ret
 }
 
+// IsRegistryHA returns true if the registry runs on every master, or every
+// host of registry role if any.
+func (c *Cluster) IsRegistryHA() bool {
+	return c.Spec.Registry != nil && c.Spec.Registry.HA
+}
+
+func (c *Cluster) GetRegistryVIP() string {
+	if c.Spec.Registry != nil && c.Spec.Registry.VIP != "" {
+		return c.Spec.Registry.VIP
+	}
+	return defaultRegistryVIP
+}
+
 func (c *Cluster) GetMaster0IP() string {
 	master0 := c.GetMaster0IPAndPort()
 	if master0 == "" {
@@ -191,6 +209,7 @@ func (c *Cluster) GetDistribution() string {
 
 const (
 	defaultVIP          = "10.103.97.2"
+	defaultRegistryVIP  = "10.103.97.3"
 	DefaultLvsCareImage = "sealos.hub:5000/sealos/lvscare:latest"
 )
 
@@ -220,6 +239,20 @@ func (c *Cluster) GetLvscareImage() string {
 	return DefaultLvsCareImage
 }
 
+// GetLvscareMetricsPort returns the port of node lvscare serves /metrics and
+// /healthz on, it's 0 (disabled) unless set by env LVSCARE_METRICS_PORT.
+func (c *Cluster) GetLvscareMetricsPort() int {
+	v, ok := maps.FromSlice(c.Spec.Env)[LvscareMetricsPortEnvKey]
+	if !ok {
+		return 0
+	}
+	port, err := strconv.Atoi(v)
+	if err != nil || port < 0 || port > 65535 {
+		return 0
+	}
+	return port
+}
+
 // UpdateCondition updates condition in cluster conditions using giving condition
 // adds condition if not existed
 func UpdateCondition(conditions []ClusterCondition, condition ClusterCondition) []ClusterCondition {
@@ -249,3 +282,53 @@ func UpdateCommandCondition(cmdConditions []CommandCondition, cmdCondition Comma
 	cmdConditions = append(cmdConditions, cmdCondition)
 	return cmdConditions
 }
+
+// GetStep returns the checkpoint of the named step, nil if the step has not been recorded.
+func (c *Checkpoint) GetStep(name string) *StepCheckpoint {
+	for i := range c.Steps {
+		if c.Steps[i].Name == name {
+			return &c.Steps[i]
+		}
+	}
+	return nil
+}
+
+// GetOrAddStep returns the checkpoint of the named step, adds it if not existed.
+func (c *Checkpoint) GetOrAddStep(name string) *StepCheckpoint {
+	if step := c.GetStep(name); step != nil {
+		return step
+	}
+	c.Steps = append(c.Steps, StepCheckpoint{Name: name})
+	return &c.Steps[len(c.Steps)-1]
+}
+
+// UpdateHost updates the result of host in step, adds it if not existed.
+func (s *StepCheckpoint) UpdateHost(host string, phase StepPhase, message string) {
+	for i := range s.Hosts {
+		if s.Hosts[i].Host == host {
+			s.Hosts[i].Phase, s.Hosts[i].Message = phase, message
+			return
+		}
+	}
+	s.Hosts = append(s.Hosts, HostCheckpoint{Host: host, Phase: phase, Message: message})
+}
+
+// IsHostSucceeded returns true if the step has succeeded on host.
+func (s *StepCheckpoint) IsHostSucceeded(host string) bool {
+	for i := range s.Hosts {
+		if s.Hosts[i].Host == host {
+			return s.Hosts[i].Phase == StepSucceeded
+		}
+	}
+	return false
+}
+
+// Covers returns true if the step has been applied to all of the hosts and images.
+func (s *StepCheckpoint) Covers(hosts, images []string) bool {
+	return sets.NewString(s.TargetHosts...).HasAll(hosts...) && s.CoversImages(images)
+}
+
+// CoversImages returns true if the step has been applied to all of the images.
+func (s *StepCheckpoint) CoversImages(images []string) bool {
+	return sets.NewString(s.Images...).HasAll(images...)
+}
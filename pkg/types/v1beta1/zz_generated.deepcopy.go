@@ -23,6 +23,54 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Checkpoint) DeepCopyInto(out *Checkpoint) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MastersToJoin != nil {
+		in, out := &in.MastersToJoin, &out.MastersToJoin
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MastersToDelete != nil {
+		in, out := &in.MastersToDelete, &out.MastersToDelete
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodesToJoin != nil {
+		in, out := &in.NodesToJoin, &out.NodesToJoin
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodesToDelete != nil {
+		in, out := &in.NodesToDelete, &out.NodesToDelete
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]StepCheckpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Checkpoint.
+func (in *Checkpoint) DeepCopy() *Checkpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(Checkpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
@@ -76,7 +124,7 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = make(ImageList, len(*in))
 		copy(*out, *in)
 	}
-	out.SSH = in.SSH
+	in.SSH.DeepCopyInto(&out.SSH)
 	if in.Hosts != nil {
 		in, out := &in.Hosts, &out.Hosts
 		*out = make([]Host, len(*in))
@@ -94,6 +142,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Registry != nil {
+		in, out := &in.Registry, &out.Registry
+		*out = new(RegistryConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -131,6 +184,11 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(Checkpoint)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -263,7 +321,7 @@ func (in *Host) DeepCopyInto(out *Host) {
 	if in.SSH != nil {
 		in, out := &in.SSH, &out.SSH
 		*out = new(SSH)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
@@ -298,6 +356,22 @@ func (in ImageList) DeepCopy() ImageList {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostCheckpoint) DeepCopyInto(out *HostCheckpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostCheckpoint.
+func (in *HostCheckpoint) DeepCopy() *HostCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(HostCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MountImage) DeepCopyInto(out *MountImage) {
 	*out = *in
@@ -338,6 +412,27 @@ func (in *MountImage) DeepCopy() *MountImage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyJump) DeepCopyInto(out *ProxyJump) {
+	*out = *in
+	if in.SSH != nil {
+		in, out := &in.SSH, &out.SSH
+		*out = new(SSH)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyJump.
+func (in *ProxyJump) DeepCopy() *ProxyJump {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyJump)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegistryConfig) DeepCopyInto(out *RegistryConfig) {
 	*out = *in
@@ -357,6 +452,18 @@ func (in *RegistryConfig) DeepCopy() *RegistryConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSH) DeepCopyInto(out *SSH) {
 	*out = *in
+	if in.ProxyJump != nil {
+		in, out := &in.ProxyJump, &out.ProxyJump
+		*out = make([]ProxyJump, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HostKeyFingerprints != nil {
+		in, out := &in.HostKeyFingerprints, &out.HostKeyFingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -369,3 +476,35 @@ func (in *SSH) DeepCopy() *SSH {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepCheckpoint) DeepCopyInto(out *StepCheckpoint) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]HostCheckpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetHosts != nil {
+		in, out := &in.TargetHosts, &out.TargetHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepCheckpoint.
+func (in *StepCheckpoint) DeepCopy() *StepCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(StepCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -60,8 +60,10 @@ func compress(paths []string, options Options) (reader io.ReadCloser, err error)
 	pr, pw := io.Pipe()
 	tw := tar.NewWriter(pw)
 	bufWriter := bufio.NewWriterSize(nil, compressionBufSize)
+	var gw *gzip.Writer
 	if options.Compress {
-		tw = tar.NewWriter(gzip.NewWriter(pw))
+		gw = gzip.NewWriter(pw)
+		tw = tar.NewWriter(gw)
 	}
 	go func() {
 		defer func() {
@@ -69,6 +71,12 @@ func compress(paths []string, options Options) (reader io.ReadCloser, err error)
 			if err != nil {
 				return
 			}
+			// gzip footer is only written when closing
+			if gw != nil {
+				if err = gw.Close(); err != nil {
+					return
+				}
+			}
 			err = pw.Close()
 			if err != nil {
 				return
@@ -0,0 +1,150 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Version is the version of the event schema, fields are only added but
+// never renamed or removed within a version.
+const Version = "v1"
+
+type Type string
+
+const (
+	// TypeStep is a pipeline step of processors.
+	TypeStep Type = "step"
+	// TypeCommand is a command executed on a host.
+	TypeCommand Type = "command"
+	// TypeImage is an image pull.
+	TypeImage Type = "image"
+	// TypeChecker is a checker of cluster.
+	TypeChecker Type = "checker"
+)
+
+type Status string
+
+const (
+	StatusStarted   Status = "started"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusSkipped is only used by steps which have succeeded in the resumed apply.
+	StatusSkipped Status = "skipped"
+)
+
+// Event is the progress of sealos operations, which is written as a line of json.
+type Event struct {
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"`
+	Type    Type      `json:"type"`
+	Status  Status    `json:"status"`
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+	// processor and step of step events
+	// +optional
+	Processor string `json:"processor,omitempty"`
+	// +optional
+	Step string `json:"step,omitempty"`
+	// host and command of command events
+	// +optional
+	Host string `json:"host,omitempty"`
+	// +optional
+	Command string `json:"command,omitempty"`
+	// +optional
+	Image string `json:"image,omitempty"`
+	// name and phase (Pre or Post) of checker events
+	// +optional
+	Checker string `json:"checker,omitempty"`
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// milliseconds elapsed since started, only set in succeeded and failed events
+	// +optional
+	DurationMs int64 `json:"durationMs,omitempty"`
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	mu    sync.Mutex
+	sinks []io.Writer
+)
+
+// AddSink makes the following events written into w.
+func AddSink(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = append(sinks, w)
+}
+
+// Reset removes all the sinks.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = nil
+}
+
+// Enabled returns true if any sink is added.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(sinks) > 0
+}
+
+// Emit writes the event into all sinks, the version is always set and
+// the time is set to now if it's zero.
+func Emit(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sinks) == 0 {
+		return
+	}
+	e.Version = Version
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	for _, w := range sinks {
+		// a broken sink must not break the operation
+		_, _ = w.Write(b)
+	}
+}
+
+// Start emits the started event and returns a func that emits the
+// succeeded or failed event according to the error.
+func Start(e Event) func(err error) {
+	if !Enabled() {
+		return func(error) {}
+	}
+	started := time.Now()
+	e.Time, e.Status = started, StatusStarted
+	Emit(e)
+	return func(err error) {
+		e.Time = time.Now()
+		e.DurationMs = e.Time.Sub(started).Milliseconds()
+		e.Status = StatusSucceeded
+		if err != nil {
+			e.Status, e.Error = StatusFailed, err.Error()
+		}
+		Emit(e)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	defer Reset()
+	// no sink, nothing happens
+	Start(Event{Type: TypeStep})(nil)
+
+	var buf bytes.Buffer
+	AddSink(&buf)
+	Start(Event{Type: TypeCommand, Host: "192.168.0.2:22", Command: "ls"})(nil)
+	Start(Event{Type: TypeImage, Image: "labring/kubernetes:v1.25.0"})(errors.New("not found"))
+
+	var got []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		e := Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %s: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d events, want 4", len(got))
+	}
+	for i, want := range []Status{StatusStarted, StatusSucceeded, StatusStarted, StatusFailed} {
+		if got[i].Status != want || got[i].Version != Version || got[i].Time.IsZero() {
+			t.Errorf("event %d = %+v, want status %s", i, got[i], want)
+		}
+	}
+	if got[1].Host != "192.168.0.2:22" || got[1].Command != "ls" {
+		t.Errorf("finished event = %+v, want host and command kept", got[1])
+	}
+	if got[3].Error != "not found" || got[3].Image != "labring/kubernetes:v1.25.0" {
+		t.Errorf("failed event = %+v, want error and image", got[3])
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/labring/sealos/pkg/utils/logger"
 	strutil "github.com/labring/sealos/pkg/utils/strings"
 )
 
@@ -29,7 +30,7 @@ func CmdWithContext(ctx context.Context, name string, args ...string) error {
 	cmd := exec.CommandContext(ctx, name, args[:]...) // #nosec
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = logger.ConsoleOutput()
 	return cmd.Run()
 }
 
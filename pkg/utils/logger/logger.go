@@ -16,8 +16,10 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -27,8 +29,38 @@ import (
 
 var (
 	defaultLogger *zap.Logger
+	consoleOutput = &switchWriter{w: os.Stdout}
 )
 
+// switchWriter is the console output, which is able to be switched after the
+// loggers are configured.
+type switchWriter struct {
+	mu sync.RWMutex
+	w  io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.w.Write(p)
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = w
+}
+
+// SetConsoleOutput sets where the console logs and the outputs of commands are written, default is stdout.
+func SetConsoleOutput(w io.Writer) {
+	consoleOutput.set(w)
+}
+
+// ConsoleOutput returns the writer of console logs, outputs of commands should be written into it too.
+func ConsoleOutput() io.Writer {
+	return consoleOutput
+}
+
 // init default logger with only console output info above
 func init() {
 	zc := zapcore.NewTee(newConsoleCore(zap.InfoLevel))
@@ -76,7 +108,7 @@ func genConfigs(debugMode bool, showPath bool) (zapcore.LevelEnabler, []zap.Opti
 }
 
 func newConsoleCore(le zapcore.LevelEnabler) zapcore.Core {
-	consoleLogger := zapcore.Lock(os.Stdout)
+	consoleLogger := zapcore.Lock(zapcore.AddSync(consoleOutput))
 
 	zec := zap.NewProductionEncoderConfig()
 	zec.EncodeLevel = zapcore.LowercaseColorLevelEncoder
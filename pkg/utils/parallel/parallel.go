@@ -0,0 +1,157 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// HostErrors holds the error of every host that an operation failed on,
+// the hosts not in it are known to be succeeded.
+type HostErrors map[string]error
+
+func (e HostErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, host := range e.Hosts() {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", host, e[host]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Hosts returns the failed hosts in order.
+func (e HostErrors) Hosts() []string {
+	hosts := make([]string, 0, len(e))
+	for host := range e {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// AsHostErrors finds the first HostErrors in err's chain.
+func AsHostErrors(err error) (HostErrors, bool) {
+	var he HostErrors
+	if errors.As(err, &he) {
+		return he, true
+	}
+	return nil, false
+}
+
+// ErrSkipped is the error of hosts that are not run since too many hosts
+// failed in the previous batches.
+var ErrSkipped = errors.New("skipped since too many hosts failed")
+
+// Options controls how an operation fans out to hosts.
+type Options struct {
+	// MaxParallel is the max number of hosts running at the same time, 0 means no limit.
+	MaxParallel int
+	// BatchSize rolls the hosts out batch by batch, 0 means all hosts in one batch.
+	BatchSize int
+	// BatchPause is the pause between two batches.
+	BatchPause time.Duration
+	// MaxFailedHosts is the number of failed hosts that is tolerated, the
+	// remaining batches are skipped once more hosts failed.
+	MaxFailedHosts int
+}
+
+// DefaultOptions is used by Run, which is set by the global flags.
+var DefaultOptions = Options{}
+
+func RegisterFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&DefaultOptions.MaxParallel, "max-parallel", 0, "max number of hosts operated at the same time, 0 means no limit")
+	fs.IntVar(&DefaultOptions.BatchSize, "batch-size", 0, "roll operations out to hosts batch by batch with the size, 0 means all hosts in one batch")
+	fs.DurationVar(&DefaultOptions.BatchPause, "batch-pause", 0, "pause between two batches of hosts")
+	fs.IntVar(&DefaultOptions.MaxFailedHosts, "max-failed-hosts", 0, "number of failed nodes tolerated when scaling out, the remaining batches are skipped once more hosts failed")
+}
+
+// Run runs fn on hosts with DefaultOptions and waits for all of them,
+// a HostErrors is returned if fn failed on any host.
+func Run(hosts []string, fn func(host string) error) error {
+	return RunWithOptions(hosts, DefaultOptions, fn)
+}
+
+// RunWithOptions runs fn on hosts concurrently batch by batch, a HostErrors is
+// returned if fn failed on any host, the hosts not run are failed with ErrSkipped.
+func RunWithOptions(hosts []string, opts Options, fn func(host string) error) error {
+	var (
+		mu   sync.Mutex
+		errs = HostErrors{}
+	)
+	batchSize := opts.BatchSize
+	if batchSize <= 0 || batchSize > len(hosts) {
+		batchSize = len(hosts)
+	}
+	for start := 0; start < len(hosts); start += batchSize {
+		if start > 0 {
+			if len(errs) > opts.MaxFailedHosts {
+				for _, host := range hosts[start:] {
+					errs[host] = ErrSkipped
+				}
+				break
+			}
+			if opts.BatchPause > 0 {
+				logger.Info("pause %s before the next batch of hosts", opts.BatchPause)
+				time.Sleep(opts.BatchPause)
+			}
+		}
+		end := start + batchSize
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+		runBatch(hosts[start:end], opts.MaxParallel, func(host string) {
+			if err := fn(host); err != nil {
+				mu.Lock()
+				errs[host] = err
+				mu.Unlock()
+			}
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func runBatch(hosts []string, maxParallel int, fn func(host string)) {
+	var wg sync.WaitGroup
+	var sem chan struct{}
+	if maxParallel > 0 {
+		sem = make(chan struct{}, maxParallel)
+	}
+	for i := range hosts {
+		host := hosts[i]
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			fn(host)
+		}()
+	}
+	wg.Wait()
+}
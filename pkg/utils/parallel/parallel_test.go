@@ -0,0 +1,101 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	hosts := []string{"192.168.0.2:22", "192.168.0.3:22", "192.168.0.4:22"}
+	if err := Run(hosts, func(string) error { return nil }); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	err := Run(hosts, func(host string) error {
+		if host == "192.168.0.2:22" {
+			return nil
+		}
+		return errors.New("failed")
+	})
+	he, ok := AsHostErrors(fmt.Errorf("wrapped: %w", err))
+	if !ok {
+		t.Fatalf("AsHostErrors() got %v, want HostErrors", err)
+	}
+	if want := []string{"192.168.0.3:22", "192.168.0.4:22"}; !reflect.DeepEqual(he.Hosts(), want) {
+		t.Errorf("Hosts() = %v, want %v", he.Hosts(), want)
+	}
+	if want := "192.168.0.3:22: failed; 192.168.0.4:22: failed"; he.Error() != want {
+		t.Errorf("Error() = %q, want %q", he.Error(), want)
+	}
+}
+
+func TestRunWithOptions(t *testing.T) {
+	hosts := []string{"192.168.0.2:22", "192.168.0.3:22", "192.168.0.4:22", "192.168.0.5:22", "192.168.0.6:22"}
+
+	var (
+		mu            sync.Mutex
+		running, peak int
+		ran           []string
+	)
+	fn := func(host string) error {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		ran = append(ran, host)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if host == "192.168.0.3:22" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	err := RunWithOptions(hosts, Options{MaxParallel: 2, MaxFailedHosts: 1}, fn)
+	if he, ok := AsHostErrors(err); !ok || len(he) != 1 {
+		t.Fatalf("RunWithOptions() error = %v, want one failed host", err)
+	}
+	if peak > 2 || len(ran) != len(hosts) {
+		t.Errorf("peak = %d, ran %d hosts, want at most 2 in parallel and all hosts ran", peak, len(ran))
+	}
+
+	ran, peak = nil, 0
+	err = RunWithOptions(hosts, Options{BatchSize: 2}, fn)
+	he, _ := AsHostErrors(err)
+	if want := []string{"192.168.0.3:22", "192.168.0.4:22", "192.168.0.5:22", "192.168.0.6:22"}; !reflect.DeepEqual(he.Hosts(), want) {
+		t.Errorf("failed hosts = %v, want %v", he.Hosts(), want)
+	}
+	if !errors.Is(he["192.168.0.4:22"], ErrSkipped) || len(ran) != 2 {
+		t.Errorf("hosts after the failed batch should be skipped, ran %v", ran)
+	}
+
+	ran = nil
+	if err = RunWithOptions(hosts, Options{BatchSize: 2, MaxFailedHosts: 1}, fn); err == nil {
+		t.Error("RunWithOptions() expected error")
+	}
+	if len(ran) != len(hosts) {
+		t.Errorf("ran %v, want all hosts ran since the failure is tolerated", ran)
+	}
+}
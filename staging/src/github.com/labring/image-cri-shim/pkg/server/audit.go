@@ -0,0 +1,109 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	api "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// the sources of the auth sent to the runtime.
+const (
+	authSourceNone       = "none"
+	authSourceRequest    = "request"
+	authSourceOffline    = "offline"
+	authSourceRegistries = "registries"
+)
+
+// auditEvent is a line of the audit log.
+type auditEvent struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Pod is namespace/name of the pod the image is pulled for, if kubelet tells.
+	Pod   string `json:"pod,omitempty"`
+	Image string `json:"image"`
+	// RewrittenImage is the image sent to the runtime, the last tried one if
+	// all the pulls failed.
+	RewrittenImage string  `json:"rewrittenImage"`
+	AuthSource     string  `json:"authSource,omitempty"`
+	Result         string  `json:"result"`
+	Error          string  `json:"error,omitempty"`
+	Duration       float64 `json:"durationSeconds"`
+}
+
+// auditLogger writes the audit events to a file as JSON lines, the file is
+// opened in append mode so that it could be rotated by copytruncate.
+type auditLogger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newAuditLogger(path string) (*auditLogger, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), DirPermissions); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLogger{file: f}, nil
+}
+
+// log writes the event, it does nothing if the audit log is disabled.
+func (l *auditLogger) log(event *auditEvent, start time.Time, err error) {
+	if l == nil {
+		return
+	}
+	event.Time = start
+	event.Duration = time.Since(start).Seconds()
+	event.Result = resultSuccess
+	if err != nil {
+		event.Result, event.Error = resultFailure, err.Error()
+	}
+	data, jerr := json.Marshal(event)
+	if jerr != nil {
+		logger.Warn("failed to marshal audit event: %v", jerr)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, werr := l.file.Write(append(data, '\n')); werr != nil {
+		logger.Warn("failed to write audit log: %v", werr)
+	}
+}
+
+func (l *auditLogger) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+func podOf(config *api.PodSandboxConfig) string {
+	if config == nil || config.Metadata == nil {
+		return ""
+	}
+	return config.Metadata.Namespace + "/" + config.Metadata.Name
+}
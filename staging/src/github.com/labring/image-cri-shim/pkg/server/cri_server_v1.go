@@ -18,20 +18,20 @@ package server
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/docker/docker/api/types"
 
-	"github.com/google/go-containerregistry/pkg/name"
-
 	api "k8s.io/cri-api/pkg/apis/runtime/v1"
 
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
 type v1ImageService struct {
-	imageClient       api.ImageServiceClient
-	CRIConfigs        map[string]types.AuthConfig
-	OfflineCRIConfigs map[string]types.AuthConfig
+	imageClient api.ImageServiceClient
+	auth        *atomic.Pointer[registryAuth]
+	audit       *auditLogger
 }
 
 func ToV1AuthConfig(c *types.AuthConfig) *api.AuthConfig {
@@ -61,11 +61,7 @@ func (s *v1ImageService) ImageStatus(ctx context.Context,
 	req *api.ImageStatusRequest) (*api.ImageStatusResponse, error) {
 	logger.Debug("ImageStatus: %+v", req)
 	if req.Image != nil {
-		if id, _ := s.GetImageRefByID(ctx, req.Image.Image); id != "" {
-			req.Image.Image = id
-		} else {
-			req.Image.Image, _, _ = replaceImage(req.Image.Image, "ImageStatus", s.OfflineCRIConfigs)
-		}
+		req.Image.Image = s.resolveImage(ctx, req.Image.Image, "ImageStatus")
 	}
 	rsp, err := s.imageClient.ImageStatus(ctx, req)
 
@@ -79,40 +75,58 @@ func (s *v1ImageService) ImageStatus(ctx context.Context,
 func (s *v1ImageService) PullImage(ctx context.Context,
 	req *api.PullImageRequest) (*api.PullImageResponse, error) {
 	logger.Debug("PullImage begin: %+v", req)
-	if req.Image != nil {
-		imageName, ok, auth := replaceImage(req.Image.Image, "PullImage", s.OfflineCRIConfigs)
-		if ok {
-			req.Auth = ToV1AuthConfig(auth)
-		} else {
-			if req.Auth == nil {
-				ref, _ := name.ParseReference(imageName)
-				if v, ok := s.CRIConfigs[ref.Context().RegistryStr()]; ok {
-					req.Auth = ToV1AuthConfig(&v)
-				}
-			}
+	if req.Image == nil {
+		return s.imageClient.PullImage(ctx, req)
+	}
+	start := time.Now()
+	event := &auditEvent{Action: "PullImage", Pod: podOf(req.SandboxConfig), Image: req.Image.Image}
+	candidates := s.auth.Load().pullCandidates(req.Image.Image, req.Auth)
+	observeRewrite(candidates[0].image != req.Image.Image)
+	var (
+		rsp      *api.PullImageResponse
+		err      error
+		firstErr error
+	)
+	for _, c := range candidates {
+		req.Image.Image, req.Auth = c.image, c.auth
+		event.RewrittenImage, event.AuthSource = c.image, c.authSource
+		logger.Debug("PullImage after: %+v", req)
+		pullStart := time.Now()
+		rsp, err = s.imageClient.PullImage(ctx, req)
+		observePull(c.image, time.Since(pullStart), err)
+		if err == nil {
+			break
+		}
+		if firstErr == nil {
+			firstErr = err
 		}
-		req.Image.Image = imageName
+		if ctx.Err() != nil {
+			break
+		}
+		logger.Warn("failed to pull image %s, try the next one: %v", c.image, err)
+	}
+	if err != nil {
+		err = firstErr
 	}
-	logger.Debug("PullImage after: %+v", req)
-	rsp, err := s.imageClient.PullImage(ctx, req)
+	s.audit.log(event, start, err)
 	if err != nil {
 		return nil, err
 	}
-
-	return rsp, err
+	return rsp, nil
 }
 
 func (s *v1ImageService) RemoveImage(ctx context.Context,
 	req *api.RemoveImageRequest) (*api.RemoveImageResponse, error) {
 	logger.Debug("RemoveImage: %+v", req)
-	if req.Image != nil {
-		if id, _ := s.GetImageRefByID(ctx, req.Image.Image); id != "" {
-			req.Image.Image = id
-		} else {
-			req.Image.Image, _, _ = replaceImage(req.Image.Image, "RemoveImage", s.OfflineCRIConfigs)
-		}
+	if req.Image == nil {
+		return s.imageClient.RemoveImage(ctx, req)
 	}
+	start := time.Now()
+	event := &auditEvent{Action: "RemoveImage", Image: req.Image.Image}
+	req.Image.Image = s.resolveImage(ctx, req.Image.Image, "RemoveImage")
+	event.RewrittenImage = req.Image.Image
 	rsp, err := s.imageClient.RemoveImage(ctx, req)
+	s.audit.log(event, start, err)
 
 	if err != nil {
 		return nil, err
@@ -133,6 +147,23 @@ func (s *v1ImageService) ImageFsInfo(ctx context.Context,
 	return rsp, err
 }
 
+// resolveImage returns the ID of the image if it's found by its name, the name
+// rewritten or in mirrors, otherwise the name replaced by the offline registry.
+func (s *v1ImageService) resolveImage(ctx context.Context, image, action string) string {
+	registryAuth := s.auth.Load()
+	images := []string{image}
+	if newImage, ok := registryAuth.Rules.Rewrite(image); ok {
+		images = append(images, newImage)
+	}
+	for _, img := range append(images, registryAuth.Rules.Mirrors(image)...) {
+		if id, _ := s.GetImageRefByID(ctx, img); id != "" {
+			return id
+		}
+	}
+	newImage, _, _ := replaceImage(image, action, registryAuth.OfflineCRIConfigs)
+	return newImage
+}
+
 func (s *v1ImageService) GetImageRefByID(ctx context.Context, image string) (string, error) {
 	resp, err := s.imageClient.ImageStatus(ctx, &api.ImageStatusRequest{
 		Image: &api.ImageSpec{
@@ -0,0 +1,102 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const metricsNamespace = "image_cri_shim"
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	pullsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "pulls_total",
+		Help:      "Number of image pulls sent to the runtime, by registry and result.",
+	}, []string{"registry", "result"})
+	pullDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "pull_duration_seconds",
+		Help:      "Duration of image pulls sent to the runtime, by registry.",
+		Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"registry"})
+	rewritesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rewrites_total",
+		Help:      "Number of PullImage requests by whether the image is redirected by rules or the offline registry (hit) or not (miss).",
+	}, []string{"result"})
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		pullsTotal, pullDuration, rewritesTotal,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func observePull(image string, duration time.Duration, err error) {
+	domain := "unknown"
+	if ref, perr := name.ParseReference(image); perr == nil {
+		domain = ref.Context().RegistryStr()
+	}
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+	pullsTotal.WithLabelValues(domain, result).Inc()
+	pullDuration.WithLabelValues(domain).Observe(duration.Seconds())
+}
+
+func observeRewrite(hit bool) {
+	if hit {
+		rewritesTotal.WithLabelValues("hit").Inc()
+		return
+	}
+	rewritesTotal.WithLabelValues("miss").Inc()
+}
+
+// ServeMetrics serves /metrics on addr until ctx is done.
+func ServeMetrics(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	logger.Info("serving metrics on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -22,12 +22,15 @@ import (
 	"os/user"
 	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	dockertype "github.com/docker/docker/api/types"
 	"google.golang.org/grpc"
 	k8sv1api "k8s.io/cri-api/pkg/apis/runtime/v1"
 
+	"github.com/labring/image-cri-shim/pkg/types"
+
 	"github.com/labring/sealos/pkg/utils/logger"
 	netutil "github.com/labring/sealos/pkg/utils/net"
 )
@@ -45,6 +48,11 @@ type Options struct {
 	//CRIConfigs is cri config for auth
 	CRIConfigs        map[string]dockertype.AuthConfig
 	OfflineCRIConfigs map[string]dockertype.AuthConfig
+	// Rules are the rewrite rules and mirrors of images.
+	Rules *types.ImageRules
+	// AuditLog is the file to write the audit log of PullImage and RemoveImage
+	// requests to, it's disabled if empty.
+	AuditLog string
 }
 
 type Server interface {
@@ -54,6 +62,8 @@ type Server interface {
 
 	Chmod(mode os.FileMode) error
 
+	UpdateAuth(auth *types.ShimAuthConfig)
+
 	Start() error
 
 	Stop()
@@ -64,6 +74,17 @@ type server struct {
 	imageV1Client k8sv1api.ImageServiceClient
 	options       Options
 	listener      net.Listener // socket our gRPC server listens on
+	auth          atomic.Pointer[registryAuth]
+	audit         *auditLogger
+}
+
+// registryAuth are the credentials and rules to replace images with, they're
+// swapped as a whole on reload and the requests in flight keep using the ones
+// they loaded.
+type registryAuth struct {
+	CRIConfigs        map[string]dockertype.AuthConfig
+	OfflineCRIConfigs map[string]dockertype.AuthConfig
+	Rules             *types.ImageRules
 }
 
 // RegisterImageService registers an image service with the server.
@@ -79,9 +100,9 @@ func (s *server) RegisterImageService(conn *grpc.ClientConn) error {
 	}
 
 	k8sv1api.RegisterImageServiceServer(s.server, &v1ImageService{
-		imageClient:       s.imageV1Client,
-		CRIConfigs:        s.options.CRIConfigs,
-		OfflineCRIConfigs: s.options.OfflineCRIConfigs,
+		imageClient: s.imageV1Client,
+		auth:        &s.auth,
+		audit:       s.audit,
 	})
 
 	return nil
@@ -187,9 +208,22 @@ func (s *server) Chown(uid, gid int) error {
 	return nil
 }
 
+// UpdateAuth replaces the credentials and rules used by the image service.
+func (s *server) UpdateAuth(auth *types.ShimAuthConfig) {
+	s.auth.Store(&registryAuth{
+		CRIConfigs:        auth.CRIConfigs,
+		OfflineCRIConfigs: auth.OfflineCRIConfigs,
+		Rules:             auth.Rules,
+	})
+	logger.Info("registry auth of image service is updated")
+}
+
 func (s *server) Stop() {
 	logger.Info("stopping server on socket %s...", s.options.Socket)
 	s.server.Stop()
+	if err := s.audit.Close(); err != nil {
+		logger.Warn("failed to close audit log: %v", err)
+	}
 }
 
 func NewServer(options Options) (Server, error) {
@@ -197,9 +231,19 @@ func NewServer(options Options) (Server, error) {
 		return nil, fmt.Errorf("invalid socked")
 	}
 
+	audit, err := newAuditLogger(options.AuditLog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %v", options.AuditLog, err)
+	}
 	s := &server{
 		options: options,
+		audit:   audit,
 	}
+	s.auth.Store(&registryAuth{
+		CRIConfigs:        options.CRIConfigs,
+		OfflineCRIConfigs: options.OfflineCRIConfigs,
+		Rules:             options.Rules,
+	})
 	return s, nil
 }
 
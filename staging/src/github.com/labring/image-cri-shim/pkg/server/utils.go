@@ -20,6 +20,8 @@ import (
 	"github.com/labring/sreg/pkg/registry/crane"
 
 	"github.com/docker/docker/api/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	api "k8s.io/cri-api/pkg/apis/runtime/v1"
 
 	"github.com/labring/sealos/pkg/utils/logger"
 )
@@ -90,3 +92,47 @@ func replaceImage(image, action string, authConfig map[string]types.AuthConfig)
 	logger.Info("image: %s, newImage: %s, action: %s", image, newImage, action)
 	return newImage, true, cfg
 }
+
+// pullCandidate is an image name to pull with the auth of its registry.
+type pullCandidate struct {
+	image      string
+	auth       *api.AuthConfig
+	authSource string
+}
+
+// pullCandidates returns the images to try in order: the image rewritten by
+// rules or replaced by the offline registry, the mirrors of its registry, and
+// the image itself with the auth of request.
+func (a *registryAuth) pullCandidates(image string, auth *api.AuthConfig) []pullCandidate {
+	var candidates []pullCandidate
+	if newImage, ok := a.Rules.Rewrite(image); ok {
+		logger.Info("image: %s, newImage: %s, action: %s", image, newImage, "PullImage")
+		candidates = append(candidates, a.candidateOf(newImage))
+	} else if newImage, ok, cfg := replaceImage(image, "PullImage", a.OfflineCRIConfigs); ok && cfg != nil {
+		candidates = append(candidates, pullCandidate{image: newImage, auth: ToV1AuthConfig(cfg), authSource: authSourceOffline})
+	}
+	for _, mirror := range a.Rules.Mirrors(image) {
+		candidates = append(candidates, a.candidateOf(mirror))
+	}
+	if auth != nil {
+		return append(candidates, pullCandidate{image: image, auth: auth, authSource: authSourceRequest})
+	}
+	return append(candidates, a.candidateOf(image))
+}
+
+// candidateOf returns the image with the auth of its registry, the auth is nil
+// if it's not configured.
+func (a *registryAuth) candidateOf(image string) pullCandidate {
+	c := pullCandidate{image: image, authSource: authSourceNone}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return c
+	}
+	domain := ref.Context().RegistryStr()
+	if v, ok := a.OfflineCRIConfigs[domain]; ok {
+		c.auth, c.authSource = ToV1AuthConfig(&v), authSourceOffline
+	} else if v, ok := a.CRIConfigs[crane.NormalizeRegistry(domain)]; ok {
+		c.auth, c.authSource = ToV1AuthConfig(&v), authSourceRegistries
+	}
+	return c
+}
@@ -0,0 +1,170 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	dockertype "github.com/docker/docker/api/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	api "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/labring/image-cri-shim/pkg/types"
+)
+
+// newOfflineRegistry serves a registry with the images pushed and returns its
+// host.
+func newOfflineRegistry(t *testing.T, images ...string) string {
+	t.Helper()
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, image := range images {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tag, err := name.NewTag(host+"/"+image, name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = remote.Write(tag, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return host
+}
+
+func TestPullCandidates(t *testing.T) {
+	type candidate struct {
+		image      string
+		username   string
+		authSource string
+	}
+	offline := newOfflineRegistry(t, "library/nginx:1.25")
+	offlineAuth := map[string]dockertype.AuthConfig{
+		offline: {Username: "offline", ServerAddress: "http://" + offline},
+	}
+	registries := map[string]dockertype.AuthConfig{
+		"index.docker.io": {Username: "dockerhub"},
+		"hub.local":       {Username: "hub"},
+	}
+	requestAuth := &api.AuthConfig{Username: "request"}
+
+	tests := []struct {
+		name     string
+		offline  map[string]dockertype.AuthConfig
+		rewrites []types.RewriteRule
+		mirrors  []types.Mirror
+		image    string
+		auth     *api.AuthConfig
+		want     []candidate
+	}{
+		{
+			name:  "image only",
+			image: "nginx:1.25",
+			want:  []candidate{{"nginx:1.25", "dockerhub", authSourceRegistries}},
+		},
+		{
+			name:  "image with auth of request",
+			image: "nginx:1.25",
+			auth:  requestAuth,
+			want:  []candidate{{"nginx:1.25", "request", authSourceRequest}},
+		},
+		{
+			name:  "image of registry without auth",
+			image: "quay.io/coreos/etcd:v3.5",
+			want:  []candidate{{"quay.io/coreos/etcd:v3.5", "", authSourceNone}},
+		},
+		{
+			name:     "rewrite, mirrors and image in order",
+			offline:  offlineAuth,
+			rewrites: []types.RewriteRule{{Prefix: "docker.io/library/", Target: "hub.local/library/"}},
+			mirrors:  []types.Mirror{{Registry: "docker.io", Endpoints: []string{"mirror1.local", "https://" + offline + "/dockerhub"}}},
+			image:    "nginx:1.25",
+			auth:     requestAuth,
+			want: []candidate{
+				{"hub.local/library/nginx:1.25", "hub", authSourceRegistries},
+				{"mirror1.local/library/nginx:1.25", "", authSourceNone},
+				{offline + "/dockerhub/library/nginx:1.25", "offline", authSourceOffline},
+				{"nginx:1.25", "request", authSourceRequest},
+			},
+		},
+		{
+			name:    "offline registry before mirrors",
+			offline: offlineAuth,
+			mirrors: []types.Mirror{{Registry: "docker.io", Endpoints: []string{"mirror1.local"}}},
+			image:   "nginx:1.25",
+			want: []candidate{
+				{offline + "/library/nginx:1.25", "offline", authSourceOffline},
+				{"mirror1.local/library/nginx:1.25", "", authSourceNone},
+				{"nginx:1.25", "dockerhub", authSourceRegistries},
+			},
+		},
+		{
+			name:     "rewrite instead of offline registry",
+			offline:  offlineAuth,
+			rewrites: []types.RewriteRule{{Regex: `^docker\.io/(.*)$`, Target: "hub.local/$1"}},
+			image:    "nginx:1.25",
+			want: []candidate{
+				{"hub.local/library/nginx:1.25", "hub", authSourceRegistries},
+				{"nginx:1.25", "dockerhub", authSourceRegistries},
+			},
+		},
+		{
+			name:    "image not in offline registry",
+			offline: offlineAuth,
+			image:   "nginx:1.26",
+			auth:    requestAuth,
+			want:    []candidate{{"nginx:1.26", "request", authSourceRequest}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := types.NewImageRules(tt.rewrites, tt.mirrors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := &registryAuth{
+				CRIConfigs:        registries,
+				OfflineCRIConfigs: tt.offline,
+				Rules:             rules,
+			}
+			if a.OfflineCRIConfigs == nil {
+				// the docker config of host is used if it's nil
+				a.OfflineCRIConfigs = map[string]dockertype.AuthConfig{}
+			}
+			var got []candidate
+			for _, c := range a.pullCandidates(tt.image, tt.auth) {
+				var username string
+				if c.auth != nil {
+					username = c.auth.Username
+				}
+				got = append(got, candidate{c.image, username, c.authSource})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pullCandidates(%s) = %v, want %v", tt.image, got, tt.want)
+			}
+		})
+	}
+}
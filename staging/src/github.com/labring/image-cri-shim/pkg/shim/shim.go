@@ -42,6 +42,9 @@ type Shim interface {
 	Start() error
 	// Stop stops the shim.
 	Stop()
+	// UpdateAuth replaces the registry credentials and image rules without
+	// interrupting requests.
+	UpdateAuth(auth *types.ShimAuthConfig)
 }
 
 // shim is the implementation of Shim.
@@ -76,6 +79,8 @@ func NewShim(cfg *types.Config, auth *types.ShimAuthConfig) (Shim, error) {
 		Mode:              0660,
 		CRIConfigs:        auth.CRIConfigs,
 		OfflineCRIConfigs: auth.OfflineCRIConfigs,
+		Rules:             auth.Rules,
+		AuditLog:          cfg.AuditLog,
 	}
 	srv, err := server.NewServer(srvopts)
 	if err != nil {
@@ -116,6 +121,11 @@ func (r *shim) Stop() {
 	r.server.Stop()
 }
 
+// UpdateAuth replaces the registry credentials and rules of the image service.
+func (r *shim) UpdateAuth(auth *types.ShimAuthConfig) {
+	r.server.UpdateAuth(auth)
+}
+
 func (r *shim) dialNotify(socket string, uid int, gid int, mode os.FileMode, err error) {
 	if err != nil {
 		logger.Error("failed to determine permissions/ownership of client socket %q: %v",
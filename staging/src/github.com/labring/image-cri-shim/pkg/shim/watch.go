@@ -0,0 +1,85 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shim
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// settleDelay is the time to wait after the last change of config file, so
+// that a file being written is not loaded.
+const settleDelay = 200 * time.Millisecond
+
+// WatchConfig calls onChange after the config file is changed, the directory
+// is watched since the file may be replaced by renaming. It returns a func to
+// stop watching.
+func WatchConfig(file string, onChange func()) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(file) && !isConfigMapUpdate(event.Name) {
+					continue
+				}
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(settleDelay, onChange)
+				mu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("failed to watch config file %s: %v", file, err)
+			}
+		}
+	}()
+	return func() {
+		_ = watcher.Close()
+		mu.Lock()
+		if timer != nil {
+			timer.Stop()
+		}
+		mu.Unlock()
+	}, nil
+}
+
+// isConfigMapUpdate returns true if the event is the data dir symlink of a
+// mounted ConfigMap or Secret being swapped.
+func isConfigMapUpdate(name string) bool {
+	return filepath.Base(name) == "..data"
+}
@@ -54,11 +54,23 @@ type Config struct {
 	Timeout         metav1.Duration `json:"timeout"`
 	Auth            string          `json:"auth"`
 	Registries      []Registry      `json:"registries"`
+	// Rewrites redirect the images matched to other registries, they're
+	// tried before the offline registry of Address.
+	Rewrites []RewriteRule `json:"rewrites,omitempty"`
+	// Mirrors are tried in order when pulling from the upstream fails.
+	Mirrors []Mirror `json:"mirrors,omitempty"`
+	// Metrics is the address to serve /metrics on, e.g. 127.0.0.1:10254,
+	// it's disabled if empty.
+	Metrics string `json:"metrics,omitempty"`
+	// AuditLog is the file to write the PullImage and RemoveImage requests to
+	// as JSON lines, it's disabled if empty.
+	AuditLog string `json:"auditLog,omitempty"`
 }
 
 type ShimAuthConfig struct {
 	CRIConfigs        map[string]types2.AuthConfig `json:"-"`
 	OfflineCRIConfigs map[string]types2.AuthConfig `json:"-"`
+	Rules             *ImageRules                  `json:"-"`
 }
 
 func (c *Config) PreProcess() (*ShimAuthConfig, error) {
@@ -130,6 +142,14 @@ func (c *Config) PreProcess() (*ShimAuthConfig, error) {
 		logger.Info("criOfflineAuth: %+v", shimAuth.OfflineCRIConfigs)
 	}
 
+	rules, err := NewImageRules(c.Rewrites, c.Mirrors)
+	if err != nil {
+		return nil, err
+	}
+	shimAuth.Rules = rules
+	logger.Info("rewrites: %+v, mirrors: %+v", c.Rewrites, c.Mirrors)
+	logger.Info("metrics: %s, audit log: %s", c.Metrics, c.AuditLog)
+
 	if c.Address == "" {
 		return nil, errors.New("registry addr is empty")
 	}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	registry2 "github.com/labring/sreg/pkg/registry/crane"
+)
+
+// RewriteRule redirects the images matched by Prefix or Regex to Target.
+//
+//	# docker.io/library/nginx:1.25 -> hub.local/library/nginx:1.25
+//	- prefix: docker.io/library/*
+//	  target: hub.local/library/*
+//	# quay.io/coreos/etcd:v3.5 -> hub.local/quay/coreos/etcd:v3.5
+//	- regex: ^quay\.io/(.*)$
+//	  target: hub.local/quay/$1
+type RewriteRule struct {
+	// Prefix is the prefix of image name, a trailing '*' is optional.
+	Prefix string `json:"prefix,omitempty"`
+	// Regex is the regular expression of image name, Target could refer the
+	// submatches by $1, $2...
+	Regex  string `json:"regex,omitempty"`
+	Target string `json:"target"`
+}
+
+// Mirror is the ordered mirror list of an upstream registry, e.g. docker.io.
+type Mirror struct {
+	Registry  string   `json:"registry"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// ImageRules are the compiled rewrite rules and mirrors. The image names are
+// matched in the full form with registry and tag, e.g. docker.io/library/nginx:latest.
+type ImageRules struct {
+	rewrites []rewriter
+	mirrors  map[string][]string
+}
+
+type rewriter struct {
+	prefix string
+	regex  *regexp.Regexp
+	target string
+}
+
+func NewImageRules(rewrites []RewriteRule, mirrors []Mirror) (*ImageRules, error) {
+	rules := &ImageRules{mirrors: make(map[string][]string)}
+	for _, r := range rewrites {
+		if r.Target == "" {
+			return nil, fmt.Errorf("target of rewrite rule %+v is empty", r)
+		}
+		switch {
+		case r.Prefix != "" && r.Regex != "":
+			return nil, fmt.Errorf("rewrite rule %+v has both prefix and regex", r)
+		case r.Prefix != "":
+			rules.rewrites = append(rules.rewrites, rewriter{
+				prefix: strings.TrimSuffix(r.Prefix, "*"),
+				target: strings.TrimSuffix(r.Target, "*"),
+			})
+		case r.Regex != "":
+			re, err := regexp.Compile(r.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex of rewrite rule %+v: %v", r, err)
+			}
+			rules.rewrites = append(rules.rewrites, rewriter{regex: re, target: r.Target})
+		default:
+			return nil, fmt.Errorf("rewrite rule %+v has neither prefix nor regex", r)
+		}
+	}
+	for _, m := range mirrors {
+		if m.Registry == "" {
+			return nil, fmt.Errorf("registry of mirror %+v is empty", m)
+		}
+		upstream := registry2.NormalizeRegistry(registry2.GetRegistryDomain(m.Registry))
+		for _, ep := range m.Endpoints {
+			if ep = strings.TrimSuffix(trimScheme(ep), "/"); ep != "" {
+				rules.mirrors[upstream] = append(rules.mirrors[upstream], ep)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// Rewrite returns the image name of the first matched rewrite rule.
+func (r *ImageRules) Rewrite(image string) (string, bool) {
+	if r == nil || len(r.rewrites) == 0 {
+		return image, false
+	}
+	full, err := fullName(image)
+	if err != nil {
+		return image, false
+	}
+	for _, rw := range r.rewrites {
+		var newImage string
+		switch {
+		case rw.regex != nil:
+			if !rw.regex.MatchString(full) {
+				continue
+			}
+			newImage = rw.regex.ReplaceAllString(full, rw.target)
+		case strings.HasPrefix(full, rw.prefix):
+			newImage = rw.target + strings.TrimPrefix(full, rw.prefix)
+		default:
+			continue
+		}
+		if _, err = name.ParseReference(newImage); err != nil {
+			continue
+		}
+		return newImage, true
+	}
+	return image, false
+}
+
+// Mirrors returns the image names in the mirrors of its registry, in order.
+func (r *ImageRules) Mirrors(image string) []string {
+	if r == nil || len(r.mirrors) == 0 {
+		return nil
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil
+	}
+	endpoints := r.mirrors[registry2.NormalizeRegistry(ref.Context().RegistryStr())]
+	images := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		images = append(images, ep+"/"+ref.Context().RepositoryStr()+identifier(ref))
+	}
+	return images
+}
+
+// fullName returns the image name with registry and tag or digest, docker.io
+// is used for the images of Docker Hub.
+func fullName(image string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	registry := ref.Context().RegistryStr()
+	if registry == name.DefaultRegistry {
+		registry = "docker.io"
+	}
+	return registry + "/" + ref.Context().RepositoryStr() + identifier(ref), nil
+}
+
+func identifier(ref name.Reference) string {
+	if _, ok := ref.(name.Digest); ok {
+		return "@" + ref.Identifier()
+	}
+	return ":" + ref.Identifier()
+}
+
+func trimScheme(address string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+}
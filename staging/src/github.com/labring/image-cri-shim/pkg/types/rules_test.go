@@ -0,0 +1,216 @@
+/*
+Copyright 2023 sealos.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+func TestImageRulesRewrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		rewrites []RewriteRule
+		image    string
+		want     string
+		ok       bool
+	}{
+		{
+			name:     "prefix of short name",
+			rewrites: []RewriteRule{{Prefix: "docker.io/library/*", Target: "hub.local/library/*"}},
+			image:    "nginx",
+			want:     "hub.local/library/nginx:latest",
+			ok:       true,
+		},
+		{
+			name:     "prefix of full name",
+			rewrites: []RewriteRule{{Prefix: "docker.io/library/*", Target: "hub.local/library/*"}},
+			image:    "docker.io/library/nginx:1.25",
+			want:     "hub.local/library/nginx:1.25",
+			ok:       true,
+		},
+		{
+			name:     "prefix of docker hub alias",
+			rewrites: []RewriteRule{{Prefix: "docker.io/library/*", Target: "hub.local/library/*"}},
+			image:    "index.docker.io/library/nginx:1.25",
+			want:     "hub.local/library/nginx:1.25",
+			ok:       true,
+		},
+		{
+			name:     "prefix without trailing star",
+			rewrites: []RewriteRule{{Prefix: "docker.io/bitnami/", Target: "hub.local/bitnami/"}},
+			image:    "bitnami/redis:7",
+			want:     "hub.local/bitnami/redis:7",
+			ok:       true,
+		},
+		{
+			name:     "prefix of digest",
+			rewrites: []RewriteRule{{Prefix: "docker.io/library/*", Target: "hub.local/library/*"}},
+			image:    "nginx@" + testDigest,
+			want:     "hub.local/library/nginx@" + testDigest,
+			ok:       true,
+		},
+		{
+			name:     "regex of digest",
+			rewrites: []RewriteRule{{Regex: `^quay\.io/(.*)$`, Target: "hub.local/quay/$1"}},
+			image:    "quay.io/coreos/etcd@" + testDigest,
+			want:     "hub.local/quay/coreos/etcd@" + testDigest,
+			ok:       true,
+		},
+		{
+			name:     "regex with submatches",
+			rewrites: []RewriteRule{{Regex: `^ghcr\.io/([^/]+)/([^:]+):(.*)$`, Target: "hub.local/$1/$2:ghcr-$3"}},
+			image:    "ghcr.io/labring/sealos:v4.3.0",
+			want:     "hub.local/labring/sealos:ghcr-v4.3.0",
+			ok:       true,
+		},
+		{
+			name: "first matched rule wins",
+			rewrites: []RewriteRule{
+				{Prefix: "docker.io/library/", Target: "hub1.local/library/"},
+				{Prefix: "docker.io/", Target: "hub2.local/"},
+			},
+			image: "nginx:1.25",
+			want:  "hub1.local/library/nginx:1.25",
+			ok:    true,
+		},
+		{
+			name: "skip rule of invalid result",
+			rewrites: []RewriteRule{
+				{Prefix: "docker.io/", Target: "hub1.local/UPPER/"},
+				{Prefix: "docker.io/", Target: "hub2.local/"},
+			},
+			image: "nginx:1.25",
+			want:  "hub2.local/library/nginx:1.25",
+			ok:    true,
+		},
+		{
+			name:     "no rule matched",
+			rewrites: []RewriteRule{{Prefix: "docker.io/library/*", Target: "hub.local/library/*"}},
+			image:    "ghcr.io/labring/sealos:latest",
+			want:     "ghcr.io/labring/sealos:latest",
+			ok:       false,
+		},
+		{
+			name:     "invalid image",
+			rewrites: []RewriteRule{{Regex: ".*", Target: "hub.local/all"}},
+			image:    "Invalid::",
+			want:     "Invalid::",
+			ok:       false,
+		},
+		{
+			name:  "no rules",
+			image: "nginx",
+			want:  "nginx",
+			ok:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewImageRules(tt.rewrites, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := rules.Rewrite(tt.image); got != tt.want || ok != tt.ok {
+				t.Errorf("Rewrite(%s) = %s, %v, want %s, %v", tt.image, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestImageRulesMirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors []Mirror
+		image   string
+		want    []string
+	}{
+		{
+			name:    "endpoints in order",
+			mirrors: []Mirror{{Registry: "docker.io", Endpoints: []string{"https://mirror1.local", "mirror2.local:5000/dockerhub"}}},
+			image:   "bitnami/redis:7",
+			want:    []string{"mirror1.local/bitnami/redis:7", "mirror2.local:5000/dockerhub/bitnami/redis:7"},
+		},
+		{
+			name:    "registry of docker hub alias",
+			mirrors: []Mirror{{Registry: "https://registry-1.docker.io/", Endpoints: []string{"mirror.local"}}},
+			image:   "docker.io/library/nginx:1.25",
+			want:    []string{"mirror.local/library/nginx:1.25"},
+		},
+		{
+			name:    "skip empty endpoints",
+			mirrors: []Mirror{{Registry: "ghcr.io", Endpoints: []string{"http://mirror.local/", "", "https://"}}},
+			image:   "ghcr.io/labring/sealos:latest",
+			want:    []string{"mirror.local/labring/sealos:latest"},
+		},
+		{
+			name:    "digest",
+			mirrors: []Mirror{{Registry: "quay.io", Endpoints: []string{"mirror.local/quay"}}},
+			image:   "quay.io/coreos/etcd@" + testDigest,
+			want:    []string{"mirror.local/quay/coreos/etcd@" + testDigest},
+		},
+		{
+			name:    "no mirror of registry",
+			mirrors: []Mirror{{Registry: "ghcr.io", Endpoints: []string{"mirror.local"}}},
+			image:   "quay.io/coreos/etcd:v3.5",
+			want:    []string{},
+		},
+		{
+			name:    "invalid image",
+			mirrors: []Mirror{{Registry: "docker.io", Endpoints: []string{"mirror.local"}}},
+			image:   "Invalid::",
+		},
+		{
+			name:  "no mirrors",
+			image: "nginx",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewImageRules(nil, tt.mirrors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rules.Mirrors(tt.image); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mirrors(%s) = %v, want %v", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewImageRulesInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		rewrites []RewriteRule
+		mirrors  []Mirror
+	}{
+		{"no target", []RewriteRule{{Prefix: "docker.io/"}}, nil},
+		{"no match", []RewriteRule{{Target: "hub.local/"}}, nil},
+		{"prefix and regex", []RewriteRule{{Prefix: "docker.io/", Regex: "^docker", Target: "hub.local/"}}, nil},
+		{"invalid regex", []RewriteRule{{Regex: "(", Target: "hub.local/"}}, nil},
+		{"no registry", nil, []Mirror{{Endpoints: []string{"mirror.local"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewImageRules(tt.rewrites, tt.mirrors); err == nil {
+				t.Error("NewImageRules() returns no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// Config is the config file of lvscare, which lists the virtual servers to care.
+//
+//	virtualServers:
+//	- address: 10.103.97.2:6443
+//	  realServers:
+//	  - address: 192.168.0.2:6443
+//	  - address: 192.168.0.3:6443
+//	- address: 10.103.97.3:2379
+//	  scheduler: wrr
+//	  realServers:
+//	  - address: 192.168.0.2:2379
+//	    weight: 2
+//	  health:
+//	    type: tcp
+//	    failureThreshold: 3
+type Config struct {
+	VirtualServers []VirtualServer `json:"virtualServers"`
+}
+
+type VirtualServer struct {
+	Address     string       `json:"address"`
+	Scheduler   string       `json:"scheduler,omitempty"`
+	RealServers []RealServer `json:"realServers"`
+	// Health overrides the health check flags for the virtual server.
+	Health *HealthCheck `json:"health,omitempty"`
+}
+
+type RealServer struct {
+	Address string `json:"address"`
+	// Weight is the weight of real server when it's healthy, 1 if not set.
+	Weight int `json:"weight,omitempty"`
+}
+
+type HealthCheck struct {
+	Type               string            `json:"type,omitempty"`
+	Path               string            `json:"path,omitempty"`
+	Scheme             string            `json:"scheme,omitempty"`
+	Method             string            `json:"method,omitempty"`
+	Body               string            `json:"body,omitempty"`
+	Headers            map[string]string `json:"headers,omitempty"`
+	Status             []int             `json:"status,omitempty"`
+	GRPCService        string            `json:"grpcService,omitempty"`
+	GRPCTLS            *bool             `json:"grpcTLS,omitempty"`
+	InsecureSkipVerify *bool             `json:"insecureSkipVerify,omitempty"`
+	Timeout            *metav1.Duration  `json:"timeout,omitempty"`
+	FailureThreshold   int               `json:"failureThreshold,omitempty"`
+	SuccessThreshold   int               `json:"successThreshold,omitempty"`
+	DrainTimeout       *metav1.Duration  `json:"drainTimeout,omitempty"`
+}
+
+func loadConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+	return config, config.validate()
+}
+
+func (c *Config) validate() error {
+	seen := make(map[endpoint]bool)
+	for i := range c.VirtualServers {
+		vs := &c.VirtualServers[i]
+		ep, err := parseEndpoint(vs.Address)
+		if err != nil {
+			return fmt.Errorf("invalid virtual server %q: %v", vs.Address, err)
+		}
+		if seen[ep] {
+			return fmt.Errorf("duplicated virtual server %s", vs.Address)
+		}
+		seen[ep] = true
+		if vs.Scheduler != "" {
+			if err = validateScheduler(vs.Scheduler); err != nil {
+				return err
+			}
+		}
+		for _, rs := range vs.RealServers {
+			if _, err = parseEndpoint(rs.Address); err != nil {
+				return fmt.Errorf("invalid real server %q of %s: %v", rs.Address, vs.Address, err)
+			}
+			if rs.Weight < 0 {
+				return fmt.Errorf("invalid weight %d of real server %s", rs.Weight, rs.Address)
+			}
+		}
+	}
+	return nil
+}
+
+// watchConfig calls onChange when the config file or the directory of it is
+// changed, the file of a mounted ConfigMap is replaced by renaming a symlink.
+func watchConfig(file string, onChange func()) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				logger.Debug("config dir changed: %s", event)
+				onChange()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("failed to watch config file %s: %v", file, err)
+			}
+		}
+	}()
+	return watcher.Close, nil
+}
+
+func readConfigFile(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", file, err)
+	}
+	return data, nil
+}
@@ -32,6 +32,10 @@ type stopper interface {
 	Stop()
 }
 
+type healthzChecker interface {
+	Healthz() error
+}
+
 type Ruler interface {
 	Setup() error
 	Cleanup() error
@@ -0,0 +1,157 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/kubernetes/pkg/util/ipvs"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const metricsNamespace = appName
+
+var labelNames = []string{"virtual_server", "real_server"}
+
+var (
+	realServerUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "real_server_up",
+		Help:      "Whether the real server passed the last health check (1) or not (0).",
+	}, labelNames)
+	probeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "probe_duration_seconds",
+		Help:      "Duration of the health checks of real servers.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, labelNames)
+	realServerFlaps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "real_server_flaps_total",
+		Help:      "Number of times the real server changed between up and down.",
+	}, labelNames)
+	activeConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "real_server_active_connections",
+		Help:      "Number of active connections of the real server in IPVS.",
+	}, labelNames)
+	inactiveConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "real_server_inactive_connections",
+		Help:      "Number of inactive connections of the real server in IPVS.",
+	}, labelNames)
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		realServerUp, probeDuration, realServerFlaps, activeConnections, inactiveConnections,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// realServerStates are the last results of health checks, for counting flaps.
+var realServerStates = struct {
+	sync.Mutex
+	up map[[2]string]bool
+}{up: make(map[[2]string]bool)}
+
+func virtualServerLabel(vSrv *ipvs.VirtualServer) string {
+	return net.JoinHostPort(vSrv.Address.String(), strconv.Itoa(int(vSrv.Port)))
+}
+
+func observeProbe(vs, rs string, duration time.Duration, up bool) {
+	probeDuration.WithLabelValues(vs, rs).Observe(duration.Seconds())
+	value := 0.0
+	if up {
+		value = 1
+	}
+	realServerUp.WithLabelValues(vs, rs).Set(value)
+
+	key := [2]string{vs, rs}
+	realServerStates.Lock()
+	last, ok := realServerStates.up[key]
+	realServerStates.up[key] = up
+	realServerStates.Unlock()
+	if ok && last != up {
+		realServerFlaps.WithLabelValues(vs, rs).Inc()
+	}
+}
+
+func observeConnections(vs, rs string, rSrv *ipvs.RealServer) {
+	active, inactive := 0, 0
+	if rSrv != nil {
+		active, inactive = rSrv.ActiveConn, rSrv.InactiveConn
+	}
+	activeConnections.WithLabelValues(vs, rs).Set(float64(active))
+	inactiveConnections.WithLabelValues(vs, rs).Set(float64(inactive))
+}
+
+// forgetMetrics removes the metrics of the real server, or all the real
+// servers of the virtual server if rs is empty.
+func forgetMetrics(vs, rs string) {
+	labels := prometheus.Labels{"virtual_server": vs}
+	if rs != "" {
+		labels["real_server"] = rs
+	}
+	for _, vec := range []*prometheus.MetricVec{
+		realServerUp.MetricVec, probeDuration.MetricVec, realServerFlaps.MetricVec,
+		activeConnections.MetricVec, inactiveConnections.MetricVec,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+	realServerStates.Lock()
+	for key := range realServerStates.up {
+		if key[0] == vs && (rs == "" || key[1] == rs) {
+			delete(realServerStates.up, key)
+		}
+	}
+	realServerStates.Unlock()
+}
+
+// serveMetrics serves /metrics and /healthz on addr until ctx is done.
+func serveMetrics(ctx context.Context, addr string, healthz func() error) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := healthz(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	logger.Info("serving metrics and healthz on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
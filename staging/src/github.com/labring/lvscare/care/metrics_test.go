@@ -0,0 +1,154 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/kubernetes/pkg/util/ipvs"
+)
+
+func TestObserveProbe(t *testing.T) {
+	const vs = "10.103.97.100:6443"
+	tests := []struct {
+		name      string
+		rs        string
+		results   []bool
+		wantUp    float64
+		wantFlaps float64
+	}{
+		{name: "always up", rs: "192.168.0.2:6443", results: []bool{true, true, true}, wantUp: 1},
+		{name: "down", rs: "192.168.0.3:6443", results: []bool{true, false}, wantUp: 0, wantFlaps: 1},
+		{name: "flapping", rs: "192.168.0.4:6443", results: []bool{false, true, false, true}, wantUp: 1, wantFlaps: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer forgetMetrics(vs, tt.rs)
+			for _, up := range tt.results {
+				observeProbe(vs, tt.rs, time.Millisecond, up)
+			}
+			if got := testutil.ToFloat64(realServerUp.WithLabelValues(vs, tt.rs)); got != tt.wantUp {
+				t.Errorf("real_server_up = %v, want %v", got, tt.wantUp)
+			}
+			if got := testutil.ToFloat64(realServerFlaps.WithLabelValues(vs, tt.rs)); got != tt.wantFlaps {
+				t.Errorf("real_server_flaps_total = %v, want %v", got, tt.wantFlaps)
+			}
+		})
+	}
+}
+
+func TestForgetMetrics(t *testing.T) {
+	const (
+		vs1 = "10.103.97.101:6443"
+		vs2 = "10.103.97.102:6443"
+		rs1 = "192.168.0.2:6443"
+		rs2 = "192.168.0.3:6443"
+	)
+	defer forgetMetrics(vs2, "")
+	count := testutil.CollectAndCount(realServerUp)
+	for _, vs := range []string{vs1, vs2} {
+		for _, rs := range []string{rs1, rs2} {
+			observeProbe(vs, rs, time.Millisecond, true)
+			observeConnections(vs, rs, &ipvs.RealServer{ActiveConn: 2, InactiveConn: 1})
+		}
+	}
+	if got := testutil.ToFloat64(activeConnections.WithLabelValues(vs1, rs1)); got != 2 {
+		t.Errorf("real_server_active_connections = %v, want 2", got)
+	}
+
+	forgetMetrics(vs1, rs1)
+	if got := testutil.CollectAndCount(realServerUp); got != count+3 {
+		t.Errorf("real_server_up series = %d after forgetting a real server, want %d", got, count+3)
+	}
+	forgetMetrics(vs1, "")
+	if got := testutil.CollectAndCount(realServerUp); got != count+2 {
+		t.Errorf("real_server_up series = %d after forgetting a virtual server, want %d", got, count+2)
+	}
+	if got := testutil.CollectAndCount(activeConnections); got != count+2 {
+		t.Errorf("real_server_active_connections series = %d, want %d", got, count+2)
+	}
+
+	// the flaps are counted from scratch after forgotten
+	observeProbe(vs1, rs1, time.Millisecond, false)
+	if got := testutil.ToFloat64(realServerFlaps.WithLabelValues(vs1, rs1)); got != 0 {
+		t.Errorf("real_server_flaps_total = %v, want 0", got)
+	}
+	forgetMetrics(vs1, "")
+}
+
+func TestServeMetrics(t *testing.T) {
+	l := listenLocal(t)
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	var healthzErr error
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serveMetrics(ctx, addr, func() error { return healthzErr })
+	}()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		var (
+			resp *http.Response
+			err  error
+		)
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + addr + path); err == nil {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get("/healthz"); code != http.StatusOK || body != "ok" {
+		t.Errorf("GET /healthz = %d %q, want 200 ok", code, body)
+	}
+	healthzErr = errors.New("real servers are not checked yet")
+	if code, body := get("/healthz"); code != http.StatusServiceUnavailable || !strings.Contains(body, healthzErr.Error()) {
+		t.Errorf("GET /healthz = %d %q, want 503 with the error", code, body)
+	}
+
+	observeProbe("10.103.97.103:6443", "192.168.0.2:6443", time.Millisecond, true)
+	defer forgetMetrics("10.103.97.103:6443", "")
+	if code, body := get("/metrics"); code != http.StatusOK ||
+		!strings.Contains(body, `lvscare_real_server_up{real_server="192.168.0.2:6443",virtual_server="10.103.97.103:6443"} 1`) {
+		t.Errorf("GET /metrics = %d, want 200 with real_server_up, got:\n%s", code, body)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveMetrics() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("serveMetrics() is not returned after the context is done")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/labring/sealos/pkg/constants"
@@ -37,9 +38,12 @@ const (
 )
 
 type options struct {
+	ConfigFile    string
 	VirtualServer string
 	RealServer    []string
+	Weights       map[string]int
 	scheduler     string
+	Persistence   time.Duration
 	IfaceName     string
 	Logger        string
 	Mode          string
@@ -48,12 +52,16 @@ type options struct {
 	Interval      durationOrSecondValue
 	TargetIP      net.IP
 	MasqueradeBit int
+	MetricsAddr   string
 }
 
 func (o *options) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.ConfigFile, "config", "f", "", "config file of virtual servers, which is reloaded on change")
 	fs.StringVar(&o.VirtualServer, "vs", "", "virtual server address, for example 169.254.0.1:6443")
 	fs.StringSliceVar(&o.RealServer, "rs", []string{}, "real server address like 192.168.0.2:6443")
+	fs.StringToIntVar(&o.Weights, "rs-weight", map[string]int{}, "weights of real servers like 192.168.0.2:6443=2, 1 if not set")
 	fs.StringVar(&o.scheduler, "scheduler", "rr", "proxier scheduler")
+	fs.DurationVar(&o.Persistence, "persistence-timeout", 0, "time to keep sending the connections of a client to the same real server, disabled if 0")
 	fs.StringVarP(&o.IfaceName, "iface", "i", appName, "name of dummy interface to created, same behavior as kube-proxy")
 	fs.StringVar(&o.Logger, "logger", "INFO", "logger level: DEBG/INFO")
 	fs.StringVar(&o.Mode, "mode", routeMode, fmt.Sprintf("proxy mode: %s/%s", routeMode, linkMode))
@@ -62,6 +70,7 @@ func (o *options) RegisterFlags(fs *pflag.FlagSet) {
 	fs.Var(&o.Interval, "interval", "health check interval")
 	fs.IPVar(&o.TargetIP, "ip", nil, "target ip as route gateway, use with route mode")
 	fs.IntVar(&o.MasqueradeBit, "masqueradebit", 0, "IPTables masquerade bit")
+	fs.StringVar(&o.MetricsAddr, "metrics-bind-address", "", "address to serve /metrics and /healthz on, for example 127.0.0.1:10253, disabled if empty")
 
 	// set klog flag
 	if v := os.Getenv("ENABLE_KLOG_FLAGS"); len(v) > 0 {
@@ -72,31 +81,54 @@ func (o *options) RegisterFlags(fs *pflag.FlagSet) {
 	}
 }
 
-func (o *options) RequiredFlags() []string {
-	return []string{"vs"}
-}
-
 func (o *options) ValidateAndSetDefaults() error {
-	if len(o.RealServer) == 0 && !o.CleanAndExit {
+	if o.VirtualServer == "" && o.ConfigFile == "" {
+		return errors.New(`required flag(s) "vs" or "config" not set`)
+	}
+	if o.VirtualServer != "" && len(o.RealServer) == 0 && !o.CleanAndExit {
 		return errors.New(`required flag(s) "rs" not set`)
 	}
-	switch o.scheduler {
-	case "rr", "lc", "dh", "sh", "wrr", "wlc":
-	default:
+	realServers := sets.New[string](o.RealServer...)
+	for rs, weight := range o.Weights {
+		if !realServers.Has(rs) {
+			return fmt.Errorf(`invalid flag "rs-weight", %s is not a real server`, rs)
+		}
+		if weight < 1 {
+			return fmt.Errorf(`invalid flag "rs-weight", weight of %s must be greater than 0`, rs)
+		}
+	}
+	if err := validateScheduler(o.scheduler); err != nil {
 		return fmt.Errorf(`invalid flag "scheduler=%s"`, o.scheduler)
 	}
+	if o.Persistence < 0 {
+		return fmt.Errorf(`invalid flag "persistence-timeout=%s"`, o.Persistence)
+	}
 	if o.TargetIP == nil && o.Mode == routeMode {
 		hf := &hosts.HostFile{Path: constants.DefaultHostsPath}
 		if ip, ok := hf.HasDomain(constants.DefaultLvscareDomain); ok {
 			o.TargetIP = net.ParseIP(ip)
 		}
 	}
+	if o.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(o.MetricsAddr); err != nil {
+			return fmt.Errorf(`invalid flag "metrics-bind-address=%s": %v`, o.MetricsAddr, err)
+		}
+	}
 	if o.Interval == 0 {
 		o.Interval = durationOrSecondValue(5 * time.Second)
 	}
 	return nil
 }
 
+func validateScheduler(scheduler string) error {
+	switch scheduler {
+	case "rr", "lc", "dh", "sh", "wrr", "wlc":
+		return nil
+	default:
+		return fmt.Errorf("unsupported scheduler %s", scheduler)
+	}
+}
+
 type durationOrSecondValue time.Duration
 
 func (d *durationOrSecondValue) Set(s string) error {
@@ -27,6 +27,10 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -34,6 +38,165 @@ type Prober interface {
 	Probe(string, string) error
 }
 
+const (
+	healthTypeHTTP = "http"
+	healthTypeTCP  = "tcp"
+	healthTypeGRPC = "grpc"
+)
+
+// healthProber probes the real servers with the prober of health type.
+type healthProber struct {
+	HealthType string
+
+	http *httpProber
+	tcp  *tcpProber
+	grpc *grpcProber
+
+	timeout            time.Duration
+	insecureSkipVerify bool
+	policy             HealthPolicy
+	selected           Prober
+}
+
+func newHealthProber() *healthProber {
+	return &healthProber{
+		http: &httpProber{},
+		tcp:  &tcpProber{},
+		grpc: &grpcProber{},
+	}
+}
+
+func (p *healthProber) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&p.HealthType, "health-type", healthTypeHTTP,
+		fmt.Sprintf("health check type: %s/%s/%s", healthTypeHTTP, healthTypeTCP, healthTypeGRPC))
+	fs.DurationVar(&p.timeout, "health-timeout", 10*time.Second, "probe timeout")
+	fs.BoolVar(&p.insecureSkipVerify, "health-insecure-skip-verify", true, "skip verify insecure request")
+	fs.IntVar(&p.policy.FailureThreshold, "health-failure-threshold", 1, "consecutive failures before a real server is drained")
+	fs.IntVar(&p.policy.SuccessThreshold, "health-success-threshold", 1, "consecutive successes before a real server receives traffic again")
+	fs.DurationVar(&p.policy.DrainTimeout, "drain-timeout", 30*time.Second,
+		"max time to wait for the active connections of a drained real server to be closed before deleting it")
+	p.http.RegisterFlags(fs)
+	p.grpc.RegisterFlags(fs)
+}
+
+func (p *healthProber) ValidateAndSetDefaults() error {
+	if err := p.policy.validate(); err != nil {
+		return err
+	}
+	prober, err := p.newProber(p.HealthType)
+	if err != nil {
+		return err
+	}
+	p.selected = prober
+	return nil
+}
+
+// newProber returns the prober of health type with the flags, so that a
+// virtual server can be probed in a different way.
+func (p *healthProber) newProber(healthType string) (Prober, error) {
+	switch healthType {
+	case healthTypeHTTP, "":
+		prober := *p.http
+		prober.InsecureSkipVerify, prober.timeout = p.insecureSkipVerify, p.timeout
+		return &prober, prober.ValidateAndSetDefaults()
+	case healthTypeTCP:
+		prober := *p.tcp
+		prober.timeout = p.timeout
+		return &prober, nil
+	case healthTypeGRPC:
+		prober := *p.grpc
+		prober.insecureSkipVerify, prober.timeout = p.insecureSkipVerify, p.timeout
+		return &prober, nil
+	default:
+		return nil, fmt.Errorf("unsupported health type %s", healthType)
+	}
+}
+
+// newProberFor returns the prober of health check, the fields not set are
+// defaulted by the flags.
+func (p *healthProber) newProberFor(hc *HealthCheck) (Prober, error) {
+	if hc == nil {
+		return p.selected, nil
+	}
+	httpProber, grpcProber := *p.http, *p.grpc
+	base := &healthProber{
+		http:               &httpProber,
+		tcp:                p.tcp,
+		grpc:               &grpcProber,
+		timeout:            p.timeout,
+		insecureSkipVerify: p.insecureSkipVerify,
+	}
+	if hc.Path != "" {
+		httpProber.HealthPath = hc.Path
+	}
+	if hc.Scheme != "" {
+		httpProber.HealthScheme = hc.Scheme
+	}
+	if hc.Method != "" {
+		httpProber.Method = hc.Method
+	}
+	if hc.Body != "" {
+		httpProber.Body = hc.Body
+	}
+	if len(hc.Headers) > 0 {
+		httpProber.Headers = hc.Headers
+	}
+	if len(hc.Status) > 0 {
+		httpProber.ValidStatusCodes, httpProber.validStatus = hc.Status, nil
+	}
+	if hc.GRPCService != "" {
+		grpcProber.Service = hc.GRPCService
+	}
+	if hc.GRPCTLS != nil {
+		grpcProber.TLS = *hc.GRPCTLS
+	}
+	if hc.InsecureSkipVerify != nil {
+		base.insecureSkipVerify = *hc.InsecureSkipVerify
+	}
+	if hc.Timeout != nil {
+		base.timeout = hc.Timeout.Duration
+	}
+	healthType := hc.Type
+	if healthType == "" {
+		healthType = p.HealthType
+	}
+	return base.newProber(healthType)
+}
+
+// policyFor returns the health policy of health check, the fields not set are
+// defaulted by the flags.
+func (p *healthProber) policyFor(hc *HealthCheck) (HealthPolicy, error) {
+	policy := p.policy
+	if hc == nil {
+		return policy, nil
+	}
+	if hc.FailureThreshold != 0 {
+		policy.FailureThreshold = hc.FailureThreshold
+	}
+	if hc.SuccessThreshold != 0 {
+		policy.SuccessThreshold = hc.SuccessThreshold
+	}
+	if hc.DrainTimeout != nil {
+		policy.DrainTimeout = hc.DrainTimeout.Duration
+	}
+	return policy, policy.validate()
+}
+
+func (policy HealthPolicy) validate() error {
+	if policy.FailureThreshold < 1 || policy.SuccessThreshold < 1 {
+		return fmt.Errorf("health thresholds must be greater than 0, got failure %d and success %d",
+			policy.FailureThreshold, policy.SuccessThreshold)
+	}
+	if policy.DrainTimeout < 0 {
+		return fmt.Errorf("invalid drain timeout %s", policy.DrainTimeout)
+	}
+	return nil
+}
+
+func (p *healthProber) Probe(host, port string) error {
+	return p.selected.Probe(host, port)
+}
+
 type httpProber struct {
 	HealthPath         string
 	HealthScheme       string
@@ -55,8 +218,6 @@ func (p *httpProber) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&p.Body, "health-req-body", "", "body to send for health checker")
 	fs.StringToStringVar(&p.Headers, "health-req-headers", map[string]string{}, "http request headers")
 	fs.IntSliceVar(&p.ValidStatusCodes, "health-status", []int{}, "extra valid status codes greater than 400")
-	fs.BoolVar(&p.InsecureSkipVerify, "health-insecure-skip-verify", true, "skip verify insecure request")
-	fs.DurationVar(&p.timeout, "health-timeout", 10*time.Second, "http probe timeout")
 }
 
 func (p *httpProber) ValidateAndSetDefaults() error {
@@ -122,3 +283,53 @@ func (p *httpProber) Probe(host, port string) error {
 	}
 	return nil
 }
+
+// tcpProber considers the real server is healthy if it accepts connections.
+type tcpProber struct {
+	timeout time.Duration
+}
+
+func (p *tcpProber) Probe(host, port string) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), p.timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// grpcProber checks the real server with the gRPC health checking protocol.
+type grpcProber struct {
+	Service            string
+	TLS                bool
+	insecureSkipVerify bool
+	timeout            time.Duration
+}
+
+func (p *grpcProber) RegisterFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&p.Service, "health-grpc-service", "", "service name of grpc health check, empty for the whole server")
+	fs.BoolVar(&p.TLS, "health-grpc-tls", false, "use tls for grpc health check")
+}
+
+func (p *grpcProber) Probe(host, port string) error {
+	creds := insecure.NewCredentials()
+	if p.TLS {
+		// nosemgrep
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: p.insecureSkipVerify})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort(host, port),
+		grpc.WithTransportCredentials(creds), grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.Service})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("unexpected serving status %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func listenLocal(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestTCPProber(t *testing.T) {
+	l := listenLocal(t)
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	closed := listenLocal(t)
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	_ = closed.Close()
+	defer l.Close()
+
+	tests := []struct {
+		name    string
+		port    string
+		wantErr bool
+	}{
+		{name: "listening", port: port},
+		{name: "closed", port: closedPort, wantErr: true},
+	}
+	p := &tcpProber{timeout: time.Second}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.Probe(host, tt.port); (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGRPCProber(t *testing.T) {
+	l := listenLocal(t)
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	hs := health.NewServer()
+	hs.SetServingStatus("etcd", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("registry", healthpb.HealthCheckResponse_NOT_SERVING)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, hs)
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Stop()
+
+	tests := []struct {
+		name    string
+		service string
+		tls     bool
+		wantErr bool
+	}{
+		{name: "whole server", service: ""},
+		{name: "serving", service: "etcd"},
+		{name: "not serving", service: "registry", wantErr: true},
+		{name: "unknown service", service: "kube-apiserver", wantErr: true},
+		{name: "tls to plaintext server", service: "etcd", tls: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &grpcProber{Service: tt.service, TLS: tt.tls, insecureSkipVerify: true, timeout: time.Second}
+			if err := p.Probe(host, port); (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPProber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			_, _ = w.Write([]byte("ok"))
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	tests := []struct {
+		name    string
+		path    string
+		status  []int
+		wantErr bool
+	}{
+		{name: "ok", path: "healthz"},
+		{name: "server error", path: "/broken", wantErr: true},
+		{name: "unexpected status", path: "/unauthorized", wantErr: true},
+		{name: "extra valid status", path: "/unauthorized", status: []int{401}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &httpProber{HealthPath: tt.path, HealthScheme: "http", Method: http.MethodGet, ValidStatusCodes: tt.status, timeout: time.Second}
+			if err := p.ValidateAndSetDefaults(); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Probe(host, port); (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewProberFor(t *testing.T) {
+	p := newHealthProber()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	p.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--health-path", "/livez", "--health-timeout", "5s", "--health-failure-threshold", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	tls := true
+
+	tests := []struct {
+		name       string
+		hc         *HealthCheck
+		check      func(t *testing.T, prober Prober)
+		wantPolicy HealthPolicy
+		wantErr    bool
+	}{
+		{
+			name: "defaulted by flags",
+			check: func(t *testing.T, prober Prober) {
+				if got := prober.(*httpProber); got.HealthPath != "/livez" || got.timeout != 5*time.Second {
+					t.Errorf("prober = %+v, want path /livez and timeout 5s", got)
+				}
+			},
+			wantPolicy: HealthPolicy{FailureThreshold: 2, SuccessThreshold: 1, DrainTimeout: 30 * time.Second},
+		},
+		{
+			name: "http overrides",
+			hc:   &HealthCheck{Path: "readyz", Scheme: "http", Status: []int{401}, SuccessThreshold: 3},
+			check: func(t *testing.T, prober Prober) {
+				got := prober.(*httpProber)
+				if got.HealthPath != "/readyz" || got.HealthScheme != "http" || !got.validStatus.Has(401) {
+					t.Errorf("prober = %+v, want path /readyz, scheme http and status 401", got)
+				}
+			},
+			wantPolicy: HealthPolicy{FailureThreshold: 2, SuccessThreshold: 3, DrainTimeout: 30 * time.Second},
+		},
+		{
+			name: "tcp",
+			hc:   &HealthCheck{Type: healthTypeTCP, Timeout: &metav1.Duration{Duration: time.Second}},
+			check: func(t *testing.T, prober Prober) {
+				if got := prober.(*tcpProber); got.timeout != time.Second {
+					t.Errorf("prober = %+v, want timeout 1s", got)
+				}
+			},
+			wantPolicy: HealthPolicy{FailureThreshold: 2, SuccessThreshold: 1, DrainTimeout: 30 * time.Second},
+		},
+		{
+			name: "grpc",
+			hc:   &HealthCheck{Type: healthTypeGRPC, GRPCService: "etcd", GRPCTLS: &tls, DrainTimeout: &metav1.Duration{Duration: time.Minute}},
+			check: func(t *testing.T, prober Prober) {
+				if got := prober.(*grpcProber); got.Service != "etcd" || !got.TLS || got.timeout != 5*time.Second {
+					t.Errorf("prober = %+v, want service etcd with tls and timeout 5s", got)
+				}
+			},
+			wantPolicy: HealthPolicy{FailureThreshold: 2, SuccessThreshold: 1, DrainTimeout: time.Minute},
+		},
+		{
+			name:    "unsupported scheme",
+			hc:      &HealthCheck{Scheme: "ftp"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober, err := p.newProberFor(tt.hc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newProberFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.hc == nil {
+				prober = p.selected
+			}
+			tt.check(t, prober)
+			policy, err := p.policyFor(tt.hc)
+			if err != nil {
+				t.Fatalf("policyFor() error = %v", err)
+			}
+			if policy != tt.wantPolicy {
+				t.Errorf("policyFor() = %+v, want %+v", policy, tt.wantPolicy)
+			}
+		})
+	}
+	// the overrides never change the flags
+	if p.http.HealthPath != "/livez" || p.http.HealthScheme != "https" {
+		t.Errorf("flags of http prober are changed: %+v", p.http)
+	}
+}
@@ -17,9 +17,11 @@ package care
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/kubernetes/pkg/util/ipvs"
@@ -32,10 +34,57 @@ type Proxier interface {
 	DeleteVirtualServer(vs string) error
 	EnsureRealServer(vs, rs string) error
 	DeleteRealServer(vs, rs string) error
+	SetScheduler(vs, scheduler string) error
+	SetProber(vs string, prober Prober) error
+	SetWeight(vs, rs string, weight int) error
+	SetHealthPolicy(vs string, policy HealthPolicy) error
 	RunLoop(context.Context) error
 	TryRun() error
 }
 
+// HealthPolicy decides when a real server is considered down or up again, and
+// how long it's drained before being deleted.
+type HealthPolicy struct {
+	// FailureThreshold is the consecutive failures before the real server is drained.
+	FailureThreshold int
+	// SuccessThreshold is the consecutive successes before the real server receives traffic again.
+	SuccessThreshold int
+	// DrainTimeout is the max time to wait for the active connections to be closed
+	// after the weight is set to 0.
+	DrainTimeout time.Duration
+}
+
+var defaultHealthPolicy = HealthPolicy{FailureThreshold: 1, SuccessThreshold: 1}
+
+// realServerState is the health of real server, which is changed only if the
+// results of consecutive checks reach the threshold.
+type realServerState struct {
+	healthy    bool
+	failures   int
+	successes  int
+	drainSince time.Time
+}
+
+// observe records the result of check, and returns true if the health is changed.
+func (s *realServerState) observe(ok bool, policy HealthPolicy) bool {
+	if ok {
+		s.successes++
+		s.failures = 0
+	} else {
+		s.failures++
+		s.successes = 0
+	}
+	switch {
+	case s.healthy && s.failures >= policy.FailureThreshold:
+		s.healthy = false
+		return true
+	case !s.healthy && s.successes >= policy.SuccessThreshold:
+		s.healthy = true
+		return true
+	}
+	return false
+}
+
 type endpoint struct {
 	IP   string
 	Port uint16
@@ -45,28 +94,47 @@ func (ep *endpoint) String() string {
 	return net.JoinHostPort(ep.IP, strconv.Itoa(int(ep.Port)))
 }
 
-func NewProxier(scheduler string, interval time.Duration, prober Prober, syncFn func() error) Proxier {
+// NewProxier returns the proxier of virtual servers, the connections of a
+// client are sent to the same real server within persistence if it's not 0.
+func NewProxier(scheduler string, persistence time.Duration, interval time.Duration, prober Prober, syncFn func() error) Proxier {
 	return &realProxier{
-		scheduler:  scheduler,
-		ipvsHandle: ipvs.New(),
-		syncFn:     syncFn,
-		serviceMap: make(map[endpoint]map[string]endpoint),
-		prober:     prober,
-		ticker:     time.NewTicker(interval),
-		tryCh:      make(chan struct{}, 1),
-		errCh:      make(chan error, 1),
+		scheduler:   scheduler,
+		persistence: persistence,
+		ipvsHandle:  ipvs.New(),
+		syncFn:      syncFn,
+		serviceMap:  make(map[endpoint]map[string]endpoint),
+		prober:      prober,
+		services:    make(map[endpoint]*serviceConfig),
+		states:      make(map[endpoint]map[string]*realServerState),
+		ticker:      time.NewTicker(interval),
+		interval:    interval,
+		tryCh:       make(chan struct{}, 1),
+		errCh:       make(chan error, 1),
 	}
 }
 
+// serviceConfig overrides the defaults of proxier for a virtual server.
+type serviceConfig struct {
+	scheduler string
+	prober    Prober
+	policy    *HealthPolicy
+	weights   map[string]int
+}
+
 type realProxier struct {
-	scheduler  string
-	ipvsHandle ipvs.Interface
-	syncFn     func() error
+	scheduler   string
+	persistence time.Duration
+	ipvsHandle  ipvs.Interface
+	syncFn      func() error
 
 	// for prober
 	serviceMap map[endpoint]map[string]endpoint
 	prober     Prober
+	services   map[endpoint]*serviceConfig
+	states     map[endpoint]map[string]*realServerState
 	ticker     *time.Ticker
+	interval   time.Duration
+	lastCheck  atomic.Value
 	tryCh      chan struct{}
 	errCh      chan error
 }
@@ -118,9 +186,108 @@ func (p *realProxier) DeleteVirtualServer(vs string) error {
 		}
 	}
 	delete(p.serviceMap, ep)
+	delete(p.services, ep)
+	delete(p.states, ep)
+	forgetMetrics(ep.String(), "")
+	return nil
+}
+
+func (p *realProxier) serviceConfigOf(vs string) (*serviceConfig, error) {
+	ep, err := parseEndpoint(vs)
+	if err != nil {
+		return nil, err
+	}
+	conf, ok := p.services[ep]
+	if !ok {
+		conf = &serviceConfig{weights: make(map[string]int)}
+		p.services[ep] = conf
+	}
+	return conf, nil
+}
+
+// SetScheduler sets the scheduler of virtual server instead of the default one,
+// it takes effect on next ensuring.
+func (p *realProxier) SetScheduler(vs, scheduler string) error {
+	conf, err := p.serviceConfigOf(vs)
+	if err != nil {
+		return err
+	}
+	conf.scheduler = scheduler
 	return nil
 }
 
+// SetProber sets the prober of virtual server instead of the default one.
+func (p *realProxier) SetProber(vs string, prober Prober) error {
+	conf, err := p.serviceConfigOf(vs)
+	if err != nil {
+		return err
+	}
+	conf.prober = prober
+	return nil
+}
+
+// SetWeight sets the weight of real server when it's healthy, which is 1 by default.
+func (p *realProxier) SetWeight(vs, rs string, weight int) error {
+	conf, err := p.serviceConfigOf(vs)
+	if err != nil {
+		return err
+	}
+	rsEp, err := parseEndpoint(rs)
+	if err != nil {
+		return err
+	}
+	conf.weights[rsEp.String()] = weight
+	return nil
+}
+
+// SetHealthPolicy sets the health policy of virtual server instead of the
+// default one, which drains a real server once it fails.
+func (p *realProxier) SetHealthPolicy(vs string, policy HealthPolicy) error {
+	conf, err := p.serviceConfigOf(vs)
+	if err != nil {
+		return err
+	}
+	conf.policy = &policy
+	return nil
+}
+
+func (p *realProxier) policyOf(vs endpoint) HealthPolicy {
+	if conf, ok := p.services[vs]; ok && conf.policy != nil {
+		return *conf.policy
+	}
+	return defaultHealthPolicy
+}
+
+func (p *realProxier) stateOf(vs, rs endpoint) *realServerState {
+	states, ok := p.states[vs]
+	if !ok {
+		states = make(map[string]*realServerState)
+		p.states[vs] = states
+	}
+	state, ok := states[rs.String()]
+	if !ok {
+		state = &realServerState{healthy: true}
+		states[rs.String()] = state
+	}
+	return state
+}
+
+func (p *realProxier) proberOf(vs endpoint) Prober {
+	if conf, ok := p.services[vs]; ok && conf.prober != nil {
+		return conf.prober
+	}
+	return p.prober
+}
+
+func (p *realProxier) weightOf(vs, rs *endpoint) int {
+	if conf, ok := p.services[*vs]; ok {
+		if weight, ok := conf.weights[rs.String()]; ok && weight > 0 {
+			return weight
+		}
+	}
+	return 1
+}
+
 func (p *realProxier) getRealServer(vs *ipvs.VirtualServer, rs *ipvs.RealServer) (*ipvs.RealServer, error) {
 	applied, err := p.ipvsHandle.GetRealServers(vs)
 	if err != nil {
@@ -139,7 +306,7 @@ func (p *realProxier) getServersByEndpoint(vs, rs endpoint) (*ipvs.VirtualServer
 	if err != nil {
 		return nil, nil, err
 	}
-	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&rs))
+	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&vs, &rs))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -165,9 +332,17 @@ func (p *realProxier) EnsureRealServer(vs, rs string) error {
 		}
 	}()
 	if rSrv != nil {
+		// update the weight if it's changed, unless it's deactivated by prober
+		if weight := p.weightOf(&vsEp, &rsEp); rSrv.Weight != 0 && rSrv.Weight != weight {
+			rSrv.Weight = weight
+			if err = p.ipvsHandle.UpdateRealServer(vSrv, rSrv); err != nil {
+				logger.Error("Failed to update real server weight: %v", err)
+				return err
+			}
+		}
 		return nil
 	}
-	rSrv = p.buildRealServer(&rsEp)
+	rSrv = p.buildRealServer(&vsEp, &rsEp)
 	if err = p.ipvsHandle.AddRealServer(vSrv, rSrv); err != nil {
 		logger.Error("Failed to add real server: %v", err)
 		return err
@@ -188,6 +363,13 @@ func (p *realProxier) DeleteRealServer(vs, rs string) error {
 	if err != nil {
 		return err
 	}
+	if rsMap, ok := p.serviceMap[vsEp]; ok {
+		delete(rsMap, rsEp.String())
+	}
+	if states, ok := p.states[vsEp]; ok {
+		delete(states, rsEp.String())
+	}
+	forgetMetrics(vsEp.String(), rsEp.String())
 	if rSrv == nil {
 		return nil
 	}
@@ -240,36 +422,65 @@ func (p *realProxier) Stop() {
 	close(p.errCh)
 }
 
-func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vSrv *ipvs.VirtualServer, rs endpoint) {
+// checkRealServer probes the real server, it's drained with weight 0 once it's
+// unhealthy, and deleted after the active connections are closed or timed out.
+func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vs endpoint, vSrv *ipvs.VirtualServer, rs endpoint, state *realServerState) {
 	defer wg.Done()
-	probeErr := p.prober.Probe(rs.IP, strconv.Itoa(int(rs.Port)))
-	rSrv, err := p.getRealServer(vSrv, p.buildRealServer(&rs))
+	prober, policy, weight := p.proberOf(vs), p.policyOf(vs), p.weightOf(&vs, &rs)
+	start := time.Now()
+	probeErr := prober.Probe(rs.IP, strconv.Itoa(int(rs.Port)))
+	vsLabel, rsLabel := virtualServerLabel(vSrv), rs.String()
+	observeProbe(vsLabel, rsLabel, time.Since(start), probeErr == nil)
+	if probeErr != nil {
+		logger.Debug("probe error: %v", probeErr)
+	}
+	if state.observe(probeErr == nil, policy) {
+		if state.healthy {
+			logger.Info("real server %s of %s is healthy after %d successful checks", rs.String(), vs.String(), state.successes)
+		} else {
+			logger.Info("real server %s of %s is unhealthy after %d failed checks", rs.String(), vs.String(), state.failures)
+		}
+	}
+	desired := &ipvs.RealServer{Address: net.ParseIP(rs.IP), Port: rs.Port, Weight: weight}
+	rSrv, err := p.getRealServer(vSrv, desired)
 	if err != nil {
 		logger.Warn("Failed to get real server: %v", err)
 		return
 	}
-	if probeErr != nil {
-		logger.Debug("probe error: %v", probeErr)
-		if rSrv != nil {
-			if rSrv.Weight != 0 {
-				logger.Debug("Trying to update wight to 0 for graceful termination")
-				rSrv.Weight = 0
-				if err = p.ipvsHandle.UpdateRealServer(vSrv, rSrv); err != nil {
-					logger.Warn("Failed to update real server wight: %v", err)
-				}
+	observeConnections(vsLabel, rsLabel, rSrv)
+	if !state.healthy {
+		if rSrv == nil {
+			return
+		}
+		if rSrv.Weight != 0 {
+			logger.Debug("Trying to update wight to 0 for graceful termination")
+			rSrv.Weight = 0
+			if err = p.ipvsHandle.UpdateRealServer(vSrv, rSrv); err != nil {
+				logger.Warn("Failed to update real server wight: %v", err)
 				return
 			}
-			logger.Debug("Trying to delete real server")
-			if err = p.ipvsHandle.DeleteRealServer(vSrv, rSrv); err != nil {
-				logger.Warn("Failed to delete real server: %v", err)
-			}
+			state.drainSince = time.Now()
+			return
+		}
+		if state.drainSince.IsZero() {
+			// drained before started
+			state.drainSince = time.Now()
+		}
+		if rSrv.ActiveConn > 0 && time.Since(state.drainSince) < policy.DrainTimeout {
+			logger.Debug("Waiting for %d active connection(s) of real server %s to be closed", rSrv.ActiveConn, rs.String())
+			return
+		}
+		logger.Debug("Trying to delete real server")
+		if err = p.ipvsHandle.DeleteRealServer(vSrv, rSrv); err != nil {
+			logger.Warn("Failed to delete real server: %v", err)
 		}
 		return
 	}
+	state.drainSince = time.Time{}
 	if rSrv != nil {
 		if rSrv.Weight == 0 {
-			logger.Debug("Trying to update wight to 1 to receive traffic")
-			rSrv.Weight = 1
+			logger.Debug("Trying to update wight to %d to receive traffic", weight)
+			rSrv.Weight = weight
 			if err = p.ipvsHandle.UpdateRealServer(vSrv, rSrv); err != nil {
 				logger.Warn("Failed to update real server wight: %v", err)
 			}
@@ -277,7 +488,7 @@ func (p *realProxier) checkRealServer(wg *sync.WaitGroup, vSrv *ipvs.VirtualServ
 		return
 	}
 	logger.Debug("Trying to add real server back")
-	if err = p.ipvsHandle.AddRealServer(vSrv, p.buildRealServer(&rs)); err != nil {
+	if err = p.ipvsHandle.AddRealServer(vSrv, desired); err != nil {
 		logger.Warn("Failed to add real server back: %v", err)
 	}
 }
@@ -292,28 +503,50 @@ func (p *realProxier) runCheck() {
 		}
 		for _, rs := range rsMap {
 			wg.Add(1)
-			go p.checkRealServer(wg, vSrv, rs)
+			go p.checkRealServer(wg, vs, vSrv, rs, p.stateOf(vs, rs))
 		}
 	}
 	wg.Wait()
+	p.lastCheck.Store(time.Now())
+}
+
+// Healthz returns error if the real servers are not checked for a while,
+// which means the loop is stuck or exited.
+func (p *realProxier) Healthz() error {
+	last, ok := p.lastCheck.Load().(time.Time)
+	if !ok {
+		return errors.New("real servers are not checked yet")
+	}
+	if elapsed := time.Since(last); elapsed > 3*p.interval+time.Minute {
+		return fmt.Errorf("real servers are not checked for %s", elapsed.Round(time.Second))
+	}
+	return nil
 }
 
 func (p *realProxier) buildVirtualServer(ep *endpoint) *ipvs.VirtualServer {
-	return &ipvs.VirtualServer{
+	scheduler := p.scheduler
+	if conf, ok := p.services[*ep]; ok && conf.scheduler != "" {
+		scheduler = conf.scheduler
+	}
+	vs := &ipvs.VirtualServer{
 		Address:   net.ParseIP(ep.IP),
 		Protocol:  "TCP",
 		Port:      ep.Port,
-		Scheduler: p.scheduler,
+		Scheduler: scheduler,
 		Flags:     0,
 		Timeout:   0,
 	}
+	if p.persistence > 0 {
+		vs.Flags, vs.Timeout = ipvs.FlagPersistent, uint32(p.persistence.Seconds())
+	}
+	return vs
 }
 
-func (p *realProxier) buildRealServer(ep *endpoint) *ipvs.RealServer {
+func (p *realProxier) buildRealServer(vs, rs *endpoint) *ipvs.RealServer {
 	return &ipvs.RealServer{
-		Address: net.ParseIP(ep.IP),
-		Port:    ep.Port,
-		Weight:  1,
+		Address: net.ParseIP(rs.IP),
+		Port:    rs.Port,
+		Weight:  p.weightOf(vs, rs),
 	}
 }
 
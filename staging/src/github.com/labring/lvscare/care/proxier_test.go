@@ -0,0 +1,252 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/kubernetes/pkg/util/ipvs"
+	ipvstest "k8s.io/kubernetes/pkg/util/ipvs/testing"
+)
+
+// fakeProber probes the real servers with fn.
+type fakeProber func(host, port string) error
+
+func (f fakeProber) Probe(host, port string) error {
+	return f(host, port)
+}
+
+func newFakeProxier(prober Prober) (*realProxier, *ipvstest.FakeIPVS) {
+	fake := ipvstest.NewFake()
+	return &realProxier{
+		scheduler:  "rr",
+		ipvsHandle: fake,
+		serviceMap: make(map[endpoint]map[string]endpoint),
+		prober:     prober,
+		services:   make(map[endpoint]*serviceConfig),
+		states:     make(map[endpoint]map[string]*realServerState),
+		ticker:     time.NewTicker(time.Hour),
+		interval:   time.Hour,
+		tryCh:      make(chan struct{}, 1),
+		errCh:      make(chan error, 1),
+	}, fake
+}
+
+// ipvsRules returns the weights of real servers by virtual server in fake.
+func ipvsRules(fake *ipvstest.FakeIPVS) map[string]map[string]int {
+	rules := make(map[string]map[string]int)
+	for key, vs := range fake.Services {
+		rss := make(map[string]int)
+		for _, rs := range fake.Destinations[key] {
+			rss[net.JoinHostPort(rs.Address.String(), strconv.Itoa(int(rs.Port)))] = rs.Weight
+		}
+		rules[virtualServerLabel(vs)] = rss
+	}
+	return rules
+}
+
+func TestRealServerStateObserve(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      HealthPolicy
+		results     []bool
+		wantChanged []bool
+		wantHealthy bool
+	}{
+		{
+			name:        "down once failed by default",
+			policy:      defaultHealthPolicy,
+			results:     []bool{true, false},
+			wantChanged: []bool{false, true},
+			wantHealthy: false,
+		},
+		{
+			name:        "up after the success threshold",
+			policy:      HealthPolicy{FailureThreshold: 1, SuccessThreshold: 2},
+			results:     []bool{false, true, true, true},
+			wantChanged: []bool{true, false, true, false},
+			wantHealthy: true,
+		},
+		{
+			name:        "flaps below the failure threshold",
+			policy:      HealthPolicy{FailureThreshold: 3, SuccessThreshold: 1},
+			results:     []bool{false, false, true, false, false},
+			wantChanged: []bool{false, false, false, false, false},
+			wantHealthy: true,
+		},
+		{
+			name:        "down after consecutive failures",
+			policy:      HealthPolicy{FailureThreshold: 3, SuccessThreshold: 1},
+			results:     []bool{false, true, false, false, false, false},
+			wantChanged: []bool{false, false, false, false, true, false},
+			wantHealthy: false,
+		},
+		{
+			name:        "success resets the failures after going down",
+			policy:      HealthPolicy{FailureThreshold: 2, SuccessThreshold: 2},
+			results:     []bool{false, false, true, false, true, true},
+			wantChanged: []bool{false, true, false, false, false, true},
+			wantHealthy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &realServerState{healthy: true}
+			for i, ok := range tt.results {
+				if got := s.observe(ok, tt.policy); got != tt.wantChanged[i] {
+					t.Errorf("observe(%v) #%d = %v, want %v", ok, i, got, tt.wantChanged[i])
+				}
+			}
+			if s.healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v", s.healthy, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestCheckRealServerDrain(t *testing.T) {
+	const (
+		vs = "10.103.97.2:6443"
+		rs = "192.168.0.2:6443"
+	)
+	// absent means the real server is deleted
+	const absent = -1
+	type step struct {
+		healthy    bool
+		activeConn int
+		// elapsed moves the start of draining backwards before checking
+		elapsed    time.Duration
+		wantWeight int
+	}
+	tests := []struct {
+		name   string
+		policy HealthPolicy
+		steps  []step
+	}{
+		{
+			name:   "deleted after drained without connections",
+			policy: HealthPolicy{FailureThreshold: 1, SuccessThreshold: 1, DrainTimeout: time.Minute},
+			steps: []step{
+				{healthy: false, wantWeight: 0},
+				{healthy: false, wantWeight: absent},
+				{healthy: true, wantWeight: 2},
+			},
+		},
+		{
+			name:   "waits for active connections until drain timeout",
+			policy: HealthPolicy{FailureThreshold: 2, SuccessThreshold: 1, DrainTimeout: time.Minute},
+			steps: []step{
+				{healthy: false, activeConn: 3, wantWeight: 2},
+				{healthy: false, activeConn: 3, wantWeight: 0},
+				{healthy: false, activeConn: 3, wantWeight: 0},
+				{healthy: false, activeConn: 3, elapsed: 30 * time.Second, wantWeight: 0},
+				{healthy: false, activeConn: 3, elapsed: time.Minute, wantWeight: absent},
+			},
+		},
+		{
+			name:   "restores weight when healthy during draining",
+			policy: HealthPolicy{FailureThreshold: 1, SuccessThreshold: 2, DrainTimeout: time.Minute},
+			steps: []step{
+				{healthy: false, activeConn: 1, wantWeight: 0},
+				{healthy: true, activeConn: 1, wantWeight: 0},
+				{healthy: true, activeConn: 1, wantWeight: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthy := true
+			p, fake := newFakeProxier(fakeProber(func(string, string) error {
+				if healthy {
+					return nil
+				}
+				return errors.New("connection refused")
+			}))
+			if err := p.SetHealthPolicy(vs, tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.SetWeight(vs, rs, 2); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.EnsureVirtualServer(vs); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.EnsureRealServer(vs, rs); err != nil {
+				t.Fatal(err)
+			}
+			vsEp, _ := parseEndpoint(vs)
+			rsEp, _ := parseEndpoint(rs)
+			state := p.stateOf(vsEp, rsEp)
+			for i, s := range tt.steps {
+				healthy = s.healthy
+				for _, dest := range fake.Destinations[fakeServiceKey(vsEp)] {
+					dest.ActiveConn = s.activeConn
+				}
+				if !state.drainSince.IsZero() {
+					state.drainSince = state.drainSince.Add(-s.elapsed)
+				}
+				wg := &sync.WaitGroup{}
+				wg.Add(1)
+				p.checkRealServer(wg, vsEp, p.buildVirtualServer(&vsEp), rsEp, state)
+				weight, ok := ipvsRules(fake)[vs][rs]
+				if !ok {
+					weight = absent
+				}
+				if weight != s.wantWeight {
+					t.Errorf("step #%d: weight = %d, want %d", i, weight, s.wantWeight)
+				}
+			}
+		})
+	}
+}
+
+func fakeServiceKey(ep endpoint) ipvstest.ServiceKey {
+	return ipvstest.ServiceKey{IP: ep.IP, Port: ep.Port, Protocol: "TCP"}
+}
+
+func TestEnsureVirtualServerPersistence(t *testing.T) {
+	const vs = "10.103.97.3:5000"
+	tests := []struct {
+		name        string
+		persistence time.Duration
+		wantFlags   ipvs.ServiceFlags
+		wantTimeout uint32
+	}{
+		{name: "disabled", persistence: 0},
+		{name: "enabled", persistence: 10 * time.Minute, wantFlags: ipvs.FlagPersistent, wantTimeout: 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake := newFakeProxier(nil)
+			p.persistence = tt.persistence
+			if err := p.EnsureVirtualServer(vs); err != nil {
+				t.Fatal(err)
+			}
+			for _, applied := range fake.Services {
+				if applied.Flags != tt.wantFlags || applied.Timeout != tt.wantTimeout {
+					t.Errorf("virtual server flags = %v, timeout = %d, want %v and %d", applied.Flags, applied.Timeout, tt.wantFlags, tt.wantTimeout)
+				}
+			}
+			if len(fake.Services) != 1 {
+				t.Errorf("virtual servers = %v, want %s", fake.Services, vs)
+			}
+		})
+	}
+}
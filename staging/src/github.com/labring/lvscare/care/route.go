@@ -21,21 +21,33 @@ import (
 )
 
 type routeImpl struct {
-	*route.Route
+	routes []*route.Route
 }
 
-func newRouteImpl(target, gw string) (Ruler, error) {
-	return &routeImpl{
-		route.New(target, gw),
-	}, nil
+func newRouteImpl(gw string, targets ...string) (Ruler, error) {
+	impl := &routeImpl{}
+	for _, target := range targets {
+		impl.routes = append(impl.routes, route.New(target, gw))
+	}
+	return impl, nil
 }
 
 func (impl *routeImpl) Setup() error {
 	logger.Info("Trying to add route")
-	return impl.SetRoute()
+	for _, r := range impl.routes {
+		if err := r.SetRoute(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (impl *routeImpl) Cleanup() error {
 	logger.Info("Trying to delete route")
-	return impl.DelRoute()
+	for _, r := range impl.routes {
+		if err := r.DelRoute(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -15,6 +15,7 @@
 package care
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strings"
@@ -28,16 +29,20 @@ import (
 
 var LVS = &runner{
 	options: &options{},
-	prober:  &httpProber{},
+	prober:  newHealthProber(),
 }
 
 type runner struct {
 	*options
-	prober Prober
+	prober *healthProber
 
 	proxier      Proxier
 	ruler        Ruler
 	cleanupFuncs []func() error
+
+	// services are the virtual servers from flags and config file
+	services   []VirtualServer
+	configData []byte
 }
 
 func (r *runner) Run() (err error) {
@@ -58,11 +63,13 @@ func (r *runner) Run() (err error) {
 		}
 	}
 
-	cleanVirtualServer := func() error {
-		logger.Info("delete IPVS service %s", r.options.VirtualServer)
-		err := r.proxier.DeleteVirtualServer(r.options.VirtualServer)
-		if err != nil {
-			logger.Warn("failed to delete IPVS service: %v", err)
+	cleanVirtualServer := func() (err error) {
+		for _, vs := range r.services {
+			logger.Info("delete IPVS service %s", vs.Address)
+			if deleteErr := r.proxier.DeleteVirtualServer(vs.Address); deleteErr != nil {
+				logger.Warn("failed to delete IPVS service: %v", deleteErr)
+				err = deleteErr
+			}
 		}
 		return err
 	}
@@ -73,14 +80,20 @@ func (r *runner) Run() (err error) {
 		}
 		return
 	}
-	errCh := make(chan error, 1)
-	ctx := signals.SetupSignalHandler()
-	go func() {
-		errCh <- r.proxier.RunLoop(ctx)
-	}()
-	// fire at once, no need to check error here
-	_ = r.proxier.TryRun()
-	// ensure ipvs
+	if r.options.ConfigFile != "" {
+		closeWatcher, err := watchConfig(r.options.ConfigFile, func() {
+			// it's reloaded by the queued task if failed
+			_ = r.proxier.TryRun()
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch config file %s: %v", r.options.ConfigFile, err)
+		}
+		defer func() {
+			_ = closeWatcher()
+		}()
+	}
+	// ensure ipvs before the loop starts, since the states of proxier are only
+	// changed in the loop afterwards while the real servers are being checked
 	if err := r.ensureIPVSRules(); err != nil {
 		return err
 	}
@@ -89,25 +102,233 @@ func (r *runner) Run() (err error) {
 			return err
 		}
 	}
+	errCh := make(chan error, 1)
+	ctx := signals.SetupSignalHandler()
+	go func() {
+		errCh <- r.proxier.RunLoop(ctx)
+	}()
+	if r.options.MetricsAddr != "" {
+		go func() {
+			// the virtual servers are still cared if failed
+			if err := serveMetrics(ctx, r.options.MetricsAddr, r.healthz); err != nil {
+				logger.Error("failed to serve metrics: %v", err)
+			}
+		}()
+	}
+	// fire at once, no need to check error here
+	_ = r.proxier.TryRun()
 	return <-errCh
 }
 
-// run once at startup
+// run once at startup before the loop of proxier and in the loop after the config file is reloaded
 func (r *runner) ensureIPVSRules() error {
-	if err := r.proxier.EnsureVirtualServer(r.options.VirtualServer); err != nil {
-		return err
-	}
-	for i := range r.options.RealServer {
-		if err := r.proxier.EnsureRealServer(r.options.VirtualServer, r.options.RealServer[i]); err != nil {
+	for _, vs := range r.services {
+		prober, err := r.prober.newProberFor(vs.Health)
+		if err != nil {
+			return err
+		}
+		if err = r.proxier.SetProber(vs.Address, prober); err != nil {
+			return err
+		}
+		policy, err := r.prober.policyFor(vs.Health)
+		if err != nil {
+			return err
+		}
+		if err = r.proxier.SetHealthPolicy(vs.Address, policy); err != nil {
+			return err
+		}
+		if err = r.proxier.SetScheduler(vs.Address, vs.Scheduler); err != nil {
+			return err
+		}
+		for _, rs := range vs.RealServers {
+			if err = r.proxier.SetWeight(vs.Address, rs.Address, rs.Weight); err != nil {
+				return err
+			}
+		}
+		if err = r.proxier.EnsureVirtualServer(vs.Address); err != nil {
 			return err
 		}
+		for _, rs := range vs.RealServers {
+			if err = r.proxier.EnsureRealServer(vs.Address, rs.Address); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// periodicRun reloads the config file if it's changed, it's run in the loop of
+// proxier so that the rules are never changed during checking.
 func (r *runner) periodicRun() error {
-	// ensure ipset/iptables ruler?
-	// or only run once at startup?
+	if r.options.ConfigFile == "" {
+		return nil
+	}
+	data, err := readConfigFile(r.options.ConfigFile)
+	if err != nil {
+		logger.Warn("skip reloading: %v", err)
+		return nil
+	}
+	if bytes.Equal(data, r.configData) {
+		return nil
+	}
+	// the invalid config is reported once until it's changed again
+	r.configData = data
+	services, err := r.loadServices(data)
+	if err != nil {
+		logger.Error("failed to reload config file %s, keep caring the current virtual servers: %v", r.options.ConfigFile, err)
+		return nil
+	}
+	logger.Info("config file %s changed, reconciling IPVS rules", r.options.ConfigFile)
+	if err = r.reconcile(services); err != nil {
+		logger.Error("failed to reconcile IPVS rules: %v", err)
+	}
+	return nil
+}
+
+// loadServices returns the virtual servers from flags and config file data.
+func (r *runner) loadServices(data []byte) ([]VirtualServer, error) {
+	var services []VirtualServer
+	if r.options.VirtualServer != "" {
+		vs := VirtualServer{Address: r.options.VirtualServer}
+		for _, rs := range r.options.RealServer {
+			vs.RealServers = append(vs.RealServers, RealServer{Address: rs, Weight: r.options.Weights[rs]})
+		}
+		services = append(services, vs)
+	}
+	if r.options.ConfigFile == "" {
+		return services, nil
+	}
+	config, err := loadConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", r.options.ConfigFile, err)
+	}
+	for _, vs := range config.VirtualServers {
+		if vs.Address == r.options.VirtualServer {
+			return nil, fmt.Errorf("virtual server %s is both in flags and config file", vs.Address)
+		}
+		if _, err = r.prober.newProberFor(vs.Health); err != nil {
+			return nil, fmt.Errorf("invalid health check of virtual server %s: %v", vs.Address, err)
+		}
+		if _, err = r.prober.policyFor(vs.Health); err != nil {
+			return nil, fmt.Errorf("invalid health check of virtual server %s: %v", vs.Address, err)
+		}
+	}
+	return append(services, config.VirtualServers...), nil
+}
+
+// reconcile deletes the virtual servers and real servers not cared anymore,
+// and ensures the rules of services.
+func (r *runner) reconcile(services []VirtualServer) error {
+	desired := make(map[string]VirtualServer, len(services))
+	for _, vs := range services {
+		desired[vs.Address] = vs
+	}
+	var removed []string
+	for _, vs := range r.services {
+		want, ok := desired[vs.Address]
+		if !ok {
+			logger.Info("delete IPVS service %s", vs.Address)
+			if err := r.proxier.DeleteVirtualServer(vs.Address); err != nil {
+				logger.Warn("failed to delete IPVS service: %v", err)
+			}
+			removed = append(removed, vs.Address)
+			continue
+		}
+		wantRS := make(map[string]bool, len(want.RealServers))
+		for _, rs := range want.RealServers {
+			wantRS[rs.Address] = true
+		}
+		for _, rs := range vs.RealServers {
+			if wantRS[rs.Address] {
+				continue
+			}
+			logger.Info("delete real server %s of IPVS service %s", rs.Address, vs.Address)
+			if err := r.proxier.DeleteRealServer(vs.Address, rs.Address); err != nil {
+				logger.Warn("failed to delete real server: %v", err)
+			}
+		}
+	}
+	added := len(services) + len(removed) - len(r.services)
+	r.services = services
+	if err := r.ensureIPVSRules(); err != nil {
+		return err
+	}
+	if added == 0 && len(removed) == 0 {
+		return nil
+	}
+	return r.reconcileRuler(removed)
+}
+
+// reconcileRuler sets up the ruler of current virtual servers, and removes the
+// routes to the virtual IPs deleted.
+func (r *runner) reconcileRuler(removed []string) error {
+	ruler, err := r.newRuler()
+	if err != nil || ruler == nil {
+		return err
+	}
+	if r.Mode == routeMode && len(removed) > 0 {
+		current := make(map[string]bool)
+		for _, vip := range r.virtualIPs() {
+			current[vip] = true
+		}
+		var stale []string
+		for _, vs := range removed {
+			if vip, _, err := splitHostPort(vs); err == nil && !current[vip] {
+				stale = append(stale, vip)
+			}
+		}
+		if len(stale) > 0 {
+			staleRuler, _ := newRouteImpl(r.options.TargetIP.String(), stale...)
+			if err = staleRuler.Cleanup(); err != nil {
+				logger.Warn("failed to delete route of removed virtual servers: %v", err)
+			}
+		}
+	}
+	r.ruler = ruler
+	return ruler.Setup()
+}
+
+// virtualIPs returns the distinct IPs of virtual servers.
+func (r *runner) virtualIPs() []string {
+	var vips []string
+	seen := make(map[string]bool)
+	for _, vs := range r.services {
+		vip, _, err := splitHostPort(vs.Address)
+		if err != nil || seen[vip] {
+			continue
+		}
+		seen[vip] = true
+		vips = append(vips, vip)
+	}
+	return vips
+}
+
+func (r *runner) newRuler() (Ruler, error) {
+	switch r.Mode {
+	case routeMode:
+		if r.options.TargetIP == nil {
+			logger.Warn("running routeMode and Target IP is not valid IP, skipping")
+			return nil, nil
+		}
+		return newRouteImpl(r.options.TargetIP.String(), r.virtualIPs()...)
+	case linkMode:
+		addresses := make([]string, 0, len(r.services))
+		for _, vs := range r.services {
+			addresses = append(addresses, vs.Address)
+		}
+		return newIptablesImpl(r.options.IfaceName, r.options.MasqueradeBit, addresses...)
+	case "":
+		// do nothing, disable ruler
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("not yet support mode %s", r.Mode)
+	}
+}
+
+func (r *runner) healthz() error {
+	if checker, ok := r.proxier.(healthzChecker); ok {
+		return checker.Healthz()
+	}
 	return nil
 }
 
@@ -145,30 +366,23 @@ func (r *runner) ValidateAndSetDefaults() error {
 			}
 		}
 	}
-	r.proxier = NewProxier(r.options.scheduler, time.Duration(r.options.Interval), r.prober, r.periodicRun)
-	virtualIP, _, err := splitHostPort(r.options.VirtualServer)
+	if r.options.ConfigFile != "" {
+		data, err := readConfigFile(r.options.ConfigFile)
+		if err != nil {
+			return err
+		}
+		r.configData = data
+	}
+	services, err := r.loadServices(r.configData)
 	if err != nil {
 		return err
 	}
+	r.services = services
+	r.proxier = NewProxier(r.options.scheduler, r.options.Persistence, time.Duration(r.options.Interval), r.prober, r.periodicRun)
 
-	var ruler Ruler
-	switch r.Mode {
-	case routeMode:
-		if r.options.TargetIP == nil {
-			logger.Warn("running routeMode and Target IP is not valid IP, skipping")
-			break
-		}
-		ruler, err = newRouteImpl(virtualIP, r.options.TargetIP.String())
-	case linkMode:
-		ruler, err = newIptablesImpl(r.options.IfaceName, r.options.MasqueradeBit, r.options.VirtualServer)
-	case "":
-		// do nothing, disable ruler
-	default:
-		return fmt.Errorf("not yet support mode %s", r.Mode)
-	}
+	ruler, err := r.newRuler()
 	if err == nil {
 		r.ruler = ruler
 	}
-
 	return err
 }
@@ -0,0 +1,315 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package care
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+	ipvstest "k8s.io/kubernetes/pkg/util/ipvs/testing"
+)
+
+// newFakeRunner returns a runner with the default flags, which cares the
+// virtual servers with fake IPVS and no ruler.
+func newFakeRunner(t *testing.T, o *options) (*runner, *ipvstest.FakeIPVS) {
+	t.Helper()
+	prober := newHealthProber()
+	prober.RegisterFlags(pflag.NewFlagSet("test", pflag.ContinueOnError))
+	if err := prober.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	proxier, fake := newFakeProxier(prober)
+	return &runner{options: o, prober: prober, proxier: proxier}, fake
+}
+
+func TestLoadServices(t *testing.T) {
+	tests := []struct {
+		name    string
+		options options
+		config  string
+		want    []VirtualServer
+		wantErr bool
+	}{
+		{
+			name: "flags only",
+			options: options{
+				VirtualServer: "10.103.97.2:6443",
+				RealServer:    []string{"192.168.0.2:6443", "192.168.0.3:6443"},
+				Weights:       map[string]int{"192.168.0.3:6443": 2},
+			},
+			want: []VirtualServer{{
+				Address:     "10.103.97.2:6443",
+				RealServers: []RealServer{{Address: "192.168.0.2:6443"}, {Address: "192.168.0.3:6443", Weight: 2}},
+			}},
+		},
+		{
+			name:    "config file only",
+			options: options{ConfigFile: "lvscare.yaml"},
+			config: `virtualServers:
+- address: 10.103.97.3:2379
+  scheduler: wrr
+  realServers:
+  - address: 192.168.0.2:2379
+    weight: 2
+`,
+			want: []VirtualServer{{
+				Address:     "10.103.97.3:2379",
+				Scheduler:   "wrr",
+				RealServers: []RealServer{{Address: "192.168.0.2:2379", Weight: 2}},
+			}},
+		},
+		{
+			name: "flags and config file",
+			options: options{
+				ConfigFile:    "lvscare.yaml",
+				VirtualServer: "10.103.97.2:6443",
+				RealServer:    []string{"192.168.0.2:6443"},
+			},
+			config: `virtualServers:
+- address: 10.103.97.3:2379
+  realServers:
+  - address: 192.168.0.2:2379
+`,
+			want: []VirtualServer{
+				{Address: "10.103.97.2:6443", RealServers: []RealServer{{Address: "192.168.0.2:6443"}}},
+				{Address: "10.103.97.3:2379", RealServers: []RealServer{{Address: "192.168.0.2:2379"}}},
+			},
+		},
+		{
+			name: "virtual server both in flags and config file",
+			options: options{
+				ConfigFile:    "lvscare.yaml",
+				VirtualServer: "10.103.97.2:6443",
+				RealServer:    []string{"192.168.0.2:6443"},
+			},
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  realServers:
+  - address: 192.168.0.3:6443
+`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			options: options{ConfigFile: "lvscare.yaml"},
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  realServer:
+  - address: 192.168.0.3:6443
+`,
+			wantErr: true,
+		},
+		{
+			name:    "duplicated virtual server",
+			options: options{ConfigFile: "lvscare.yaml"},
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+- address: 10.103.97.2:6443
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid real server",
+			options: options{ConfigFile: "lvscare.yaml"},
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  realServers:
+  - address: 192.168.0.3
+`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported health type",
+			options: options{ConfigFile: "lvscare.yaml"},
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  health:
+    type: udp
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid health threshold",
+			options: options{ConfigFile: "lvscare.yaml"},
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  health:
+    failureThreshold: -1
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.options
+			r, _ := newFakeRunner(t, &o)
+			got, err := r.loadServices([]byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadServices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadServices() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	current := []VirtualServer{
+		{Address: "10.103.97.2:6443", RealServers: []RealServer{{Address: "192.168.0.2:6443"}, {Address: "192.168.0.3:6443"}}},
+		{Address: "10.103.97.3:2379", RealServers: []RealServer{{Address: "192.168.0.2:2379"}}},
+	}
+	tests := []struct {
+		name           string
+		desired        []VirtualServer
+		want           map[string]map[string]int
+		wantSchedulers map[string]string
+	}{
+		{
+			name:    "unchanged",
+			desired: current,
+			want: map[string]map[string]int{
+				"10.103.97.2:6443": {"192.168.0.2:6443": 1, "192.168.0.3:6443": 1},
+				"10.103.97.3:2379": {"192.168.0.2:2379": 1},
+			},
+		},
+		{
+			name: "add and delete real servers",
+			desired: []VirtualServer{
+				{Address: "10.103.97.2:6443", RealServers: []RealServer{{Address: "192.168.0.3:6443"}, {Address: "192.168.0.4:6443"}}},
+				{Address: "10.103.97.3:2379", RealServers: []RealServer{{Address: "192.168.0.2:2379"}}},
+			},
+			want: map[string]map[string]int{
+				"10.103.97.2:6443": {"192.168.0.3:6443": 1, "192.168.0.4:6443": 1},
+				"10.103.97.3:2379": {"192.168.0.2:2379": 1},
+			},
+		},
+		{
+			name: "add and delete virtual servers",
+			desired: []VirtualServer{
+				{Address: "10.103.97.2:6443", RealServers: []RealServer{{Address: "192.168.0.2:6443"}, {Address: "192.168.0.3:6443"}}},
+				{Address: "10.103.97.4:5000", RealServers: []RealServer{{Address: "192.168.0.2:5000"}}},
+			},
+			want: map[string]map[string]int{
+				"10.103.97.2:6443": {"192.168.0.2:6443": 1, "192.168.0.3:6443": 1},
+				"10.103.97.4:5000": {"192.168.0.2:5000": 1},
+			},
+		},
+		{
+			name: "change scheduler and weights",
+			desired: []VirtualServer{
+				{Address: "10.103.97.2:6443", Scheduler: "wrr", RealServers: []RealServer{{Address: "192.168.0.2:6443", Weight: 3}, {Address: "192.168.0.3:6443"}}},
+				{Address: "10.103.97.3:2379", RealServers: []RealServer{{Address: "192.168.0.2:2379"}}},
+			},
+			want: map[string]map[string]int{
+				"10.103.97.2:6443": {"192.168.0.2:6443": 3, "192.168.0.3:6443": 1},
+				"10.103.97.3:2379": {"192.168.0.2:2379": 1},
+			},
+			wantSchedulers: map[string]string{"10.103.97.2:6443": "wrr", "10.103.97.3:2379": "rr"},
+		},
+		{
+			name:    "delete all",
+			desired: nil,
+			want:    map[string]map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newFakeRunner(t, &options{})
+			r.services = current
+			if err := r.ensureIPVSRules(); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.reconcile(tt.desired); err != nil {
+				t.Fatalf("reconcile() error = %v", err)
+			}
+			if got := ipvsRules(fake); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IPVS rules = %v, want %v", got, tt.want)
+			}
+			for _, vs := range fake.Services {
+				want, ok := tt.wantSchedulers[virtualServerLabel(vs)]
+				if !ok {
+					want = "rr"
+				}
+				if vs.Scheduler != want {
+					t.Errorf("scheduler of %s = %s, want %s", virtualServerLabel(vs), vs.Scheduler, want)
+				}
+			}
+			if !reflect.DeepEqual(r.services, tt.desired) {
+				t.Errorf("services = %+v, want %+v", r.services, tt.desired)
+			}
+		})
+	}
+}
+
+func TestPeriodicRunReloadsConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lvscare.yaml")
+	r, fake := newFakeRunner(t, &options{ConfigFile: file})
+	steps := []struct {
+		name   string
+		config string
+		want   map[string]map[string]int
+	}{
+		{
+			name: "initial",
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  realServers:
+  - address: 192.168.0.2:6443
+`,
+			want: map[string]map[string]int{"10.103.97.2:6443": {"192.168.0.2:6443": 1}},
+		},
+		{
+			name:   "invalid config keeps the current rules",
+			config: "virtualServers: [",
+			want:   map[string]map[string]int{"10.103.97.2:6443": {"192.168.0.2:6443": 1}},
+		},
+		{
+			name: "changed",
+			config: `virtualServers:
+- address: 10.103.97.2:6443
+  realServers:
+  - address: 192.168.0.3:6443
+    weight: 2
+`,
+			want: map[string]map[string]int{"10.103.97.2:6443": {"192.168.0.3:6443": 2}},
+		},
+	}
+	for _, s := range steps {
+		if err := os.WriteFile(file, []byte(s.config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.periodicRun(); err != nil {
+			t.Fatalf("%s: periodicRun() error = %v", s.name, err)
+		}
+		if got := ipvsRules(fake); !reflect.DeepEqual(got, s.want) {
+			t.Errorf("%s: IPVS rules = %v, want %v", s.name, got, s.want)
+		}
+	}
+
+	// the current rules are kept if the config file can't be read
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.periodicRun(); err != nil {
+		t.Fatalf("periodicRun() error = %v", err)
+	}
+	if got := ipvsRules(fake); len(got) != 1 {
+		t.Errorf("IPVS rules = %v, want kept after the config file is removed", got)
+	}
+}